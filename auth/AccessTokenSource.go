@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// OAuth scope to call Google Cloud APIs
+const CloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// Creates a source of OAuth access tokens to call Google Cloud APIs with Application Default Credentials:
+// the service account key file set by GOOGLE_APPLICATION_CREDENTIALS environment variable
+// or the default service account from the metadata server. Tokens are cached until they expire.
+// Returns the token source that returns access tokens as IdToken values.
+func NewDefaultAccessTokenSource() IIdTokenSource {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return newKeyFileAccessTokenSource(path)
+	}
+	return NewCachedIdTokenSource(newMetadataAccessTokenSource(), DefaultRefreshLeeway)
+}
+
+// Token source that obtains OAuth access tokens of the default service account
+// from the metadata server to call Google Cloud APIs.
+// Access tokens are returned as IdToken values with their expiration time.
+type metadataAccessTokenSource struct {
+	host   string
+	client *http.Client
+}
+
+func newMetadataAccessTokenSource() *metadataAccessTokenSource {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = DefaultMetadataHost
+	}
+
+	return &metadataAccessTokenSource{
+		host:   host,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *metadataAccessTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	uri := "http://" + c.host + "/computeMetadata/v1/instance/service-accounts/default/token" +
+		"?scopes=" + url.QueryEscape(CloudPlatformScope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_METADATA_HOST", "Metadata server host is invalid").
+			WithDetails("host", c.host).WithCause(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	return doAccessTokenRequest(c.client, req, correlationId)
+}
+
+// Token source that generates OAuth access tokens for a service account.
+// It signs JWT token with "scope" claim using the service account key
+// and exchanges it for an access token at the key token endpoint.
+type serviceAccountAccessTokenSource struct {
+	key        *ServiceAccountKey
+	privateKey *rsa.PrivateKey
+	client     *http.Client
+}
+
+func newServiceAccountAccessTokenSource(keyJson []byte) (*serviceAccountAccessTokenSource, error) {
+	source, err := NewServiceAccountIdTokenSource(keyJson, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &serviceAccountAccessTokenSource{
+		key:        source.key,
+		privateKey: source.privateKey,
+		client:     source.Client,
+	}, nil
+}
+
+func (c *serviceAccountAccessTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	req, err := newJwtBearerRequest(ctx, correlationId, c.key, c.privateKey, "scope", CloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return doAccessTokenRequest(c.client, req, correlationId)
+}
+
+func doAccessTokenRequest(client *http.Client, req *http.Request, correlationId string) (*IdToken, error) {
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_GET_ACCESS_TOKEN", "Failed to call token endpoint").
+			WithDetails("host", req.URL.Host).WithCause(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_GET_ACCESS_TOKEN", "Failed to read token response").
+			WithCause(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, cerr.NewUnauthorizedError(correlationId, "ACCESS_TOKEN_REJECTED", "Token endpoint rejected the request").
+			WithDetails("status", res.StatusCode).WithDetails("response", string(body))
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	err = json.Unmarshal(body, &result)
+	if err != nil || result.AccessToken == "" {
+		return nil, cerr.NewUnauthorizedError(correlationId, "ACCESS_TOKEN_REJECTED", "Token endpoint did not return access_token").
+			WithCause(err)
+	}
+
+	expiresIn := time.Duration(result.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+
+	return &IdToken{Value: result.AccessToken, Expiry: time.Now().Add(expiresIn)}, nil
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+)
+
+// Authenticates requests with API keys passed in "X-API-Key" header.
+// The authenticated Principal with the key name as id is added to the request context.
+// Keys in query parameters can leak to logs and browser history, so they are accepted
+// only when the query parameter is configured.
+//
+//	Configuration parameters
+//		- auth:
+//			- api_key_header:	name of the header with API key (default: X-API-Key)
+//			- api_key_param:	name of the query parameter with API key, e.g. api_key (default: none)
+//		- api_keys:
+//			- <name>:	API key of the named client
+//		- api_key_roles:
+//			- <name>:	comma-separated list of roles granted to the named client
+//
+// see Principal, RoleAuthManager
+//
+// 	Example:
+//		authManager := auth.NewApiKeyAuthManager()
+//		authManager.Configure(ctx, config.NewConfigParamsFromTuples(
+//			"api_keys.billing", "XXX",
+//			"api_key_roles.billing", "admin",
+//		))
+//
+//		c.RegisterActionWithAuth("get_mydata", nil, authManager.Authenticate(), c.getMyData)
+//
+type ApiKeyAuthManager struct {
+	header string
+	param  string
+	// API key names by keys
+	keys map[string]string
+	// Roles by API key names
+	roles map[string][]string
+}
+
+// Creates a new instance of the auth manager.
+func NewApiKeyAuthManager() *ApiKeyAuthManager {
+	return &ApiKeyAuthManager{
+		header: "X-API-Key",
+		keys:   make(map[string]string),
+		roles:  make(map[string][]string),
+	}
+}
+
+// Configure the component with specified parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config *conf.ConfigParams configuration parameters to set.
+func (c *ApiKeyAuthManager) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.header = config.GetAsStringWithDefault("auth.api_key_header", c.header)
+	c.param = config.GetAsStringWithDefault("auth.api_key_param", c.param)
+
+	keys := config.GetSection("api_keys")
+	for _, name := range keys.Keys() {
+		c.AddKey(name, keys.GetAsString(name), nil)
+	}
+
+	roles := config.GetSection("api_key_roles")
+	for _, name := range roles.Keys() {
+		c.roles[name] = splitList(roles.GetAsString(name))
+	}
+}
+
+// Adds an API key.
+// Parameters:
+//		- name	a client name used as principal id.
+//		- key	an API key.
+//		- roles	(optional) roles granted to the client.
+func (c *ApiKeyAuthManager) AddKey(name string, key string, roles []string) {
+	if key == "" {
+		return
+	}
+	c.keys[key] = name
+	if roles != nil {
+		c.roles[name] = roles
+	}
+}
+
+// Creates an interceptor that requires a valid API key.
+// Returns the interceptor that responds with 401 error to unauthenticated requests.
+func (c *ApiKeyAuthManager) Authenticate() func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		correlationId := gcputil.CloudFunctionRequestHelper.GetCorrelationId(req)
+
+		key := req.Header.Get(c.header)
+		if key == "" && c.param != "" {
+			key = req.URL.Query().Get(c.param)
+		}
+
+		name, ok := c.findKey(key)
+		if !ok {
+			rpcserv.HttpResponseSender.SendError(
+				res, req,
+				cerr.NewUnauthorizedError(correlationId, "INVALID_API_KEY",
+					"Valid API key is required to perform this operation").WithStatus(401),
+			)
+			return
+		}
+
+		principal := &Principal{
+			Id:       name,
+			AuthType: "api_key",
+			Roles:    c.roles[name],
+		}
+
+		next.ServeHTTP(res, req.WithContext(ContextWithPrincipal(req.Context(), principal)))
+	}
+}
+
+func (c *ApiKeyAuthManager) findKey(key string) (string, bool) {
+	if key == "" {
+		return "", false
+	}
+
+	// Compare all keys in constant time to avoid timing attacks
+	found := ""
+	matched := false
+	for k, name := range c.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			found = name
+			matched = true
+		}
+	}
+	return found, matched
+}
+
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Default period before token expiration when it gets refreshed
+const DefaultRefreshLeeway = 5 * time.Minute
+
+// Token source that caches ID tokens obtained from another source
+// and refreshes them shortly before they expire.
+//
+// 	Example:
+//		source := auth.NewCachedIdTokenSource(auth.NewMetadataIdTokenSource(audience), auth.DefaultRefreshLeeway)
+//		token, err := source.GetIdToken(ctx, "123")
+//
+type CachedIdTokenSource struct {
+	source IIdTokenSource
+	leeway time.Duration
+	token  *IdToken
+	mtx    sync.Mutex
+}
+
+// Creates a new instance of the token source.
+// Parameters:
+//		- source	a source of ID tokens.
+//		- leeway	a period before token expiration when it gets refreshed.
+func NewCachedIdTokenSource(source IIdTokenSource, leeway time.Duration) *CachedIdTokenSource {
+	return &CachedIdTokenSource{
+		source: source,
+		leeway: leeway,
+	}
+}
+
+// Gets cached ID token or obtains a new one when the cached token is about to expire.
+// Parameters:
+//		- ctx context.Context
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+// Returns an ID token or error.
+func (c *CachedIdTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.token != nil && !c.token.IsExpired(c.leeway) {
+		return c.token, nil
+	}
+
+	token, err := c.source.GetIdToken(ctx, correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	c.token = token
+	return token, nil
+}
+
+// Clears the cached token, so the next call obtains a new one.
+func (c *CachedIdTokenSource) Clear() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.token = nil
+}
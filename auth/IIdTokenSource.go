@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// IdToken is a Google-signed OpenID Connect ID token.
+// Token sources created by NewDefaultAccessTokenSource return OAuth access tokens
+// to call Google Cloud APIs in the same structure.
+type IdToken struct {
+	// The encoded JWT token
+	Value string
+	// The time when the token expires or zero time if the expiration is unknown
+	Expiry time.Time
+}
+
+// Checks if the token is expired or will expire within the given period.
+// Parameters:
+//		- leeway	a period before the expiration time when the token is treated as expired.
+// Returns true if the token shall be refreshed.
+func (c *IdToken) IsExpired(leeway time.Duration) bool {
+	if c.Expiry.IsZero() {
+		return false
+	}
+	return time.Now().Add(leeway).After(c.Expiry)
+}
+
+// Interface for components that obtain ID tokens to call IAM-protected Google Functions.
+// Sources of OAuth access tokens, like ones created by NewDefaultAccessTokenSource, implement it as well,
+// so the returned value may be an access token that is sent the same way as "Bearer" authorization.
+//
+// see StaticIdTokenSource, ServiceAccountIdTokenSource, MetadataIdTokenSource, CachedIdTokenSource
+type IIdTokenSource interface {
+
+	// Gets an ID token.
+	// Parameters:
+	//		- ctx context.Context
+	//		- correlationId	(optional) transaction id to trace execution through call chain.
+	// Returns an ID token or error.
+	GetIdToken(ctx context.Context, correlationId string) (*IdToken, error)
+}
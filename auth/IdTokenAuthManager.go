@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+)
+
+// Authenticates requests with Google-signed ID tokens passed in "Authorization: Bearer" header.
+// The authenticated Principal is added to the request context.
+//
+//	Configuration parameters
+//		- auth:
+//			- audience:		comma-separated list of accepted token audiences
+//			- jwks_uri:		endpoint with public keys (default: https://www.googleapis.com/oauth2/v3/certs)
+//			- roles_claim:	name of the claim with caller roles (default: roles)
+//
+// see Principal, RoleAuthManager
+//
+// 	Example:
+//		authManager := auth.NewIdTokenAuthManager("https://us-east1-myproject.cloudfunctions.net/myfunction")
+//
+//		func (c *MyCloudFunctionService) Register() {
+//			c.RegisterActionWithAuth("get_mydata", nil, authManager.Authenticate(),
+//				func(w http.ResponseWriter, r *http.Request) {
+//					principal, _ := auth.PrincipalFromContext(r.Context())
+//					...
+//				},
+//			)
+//		}
+//
+type IdTokenAuthManager struct {
+	rolesClaim string
+
+	// The ID token verifier.
+	Verifier *IdTokenVerifier
+}
+
+// Creates a new instance of the auth manager.
+// Parameters:
+//		- audiences	a list of accepted token audiences.
+func NewIdTokenAuthManager(audiences ...string) *IdTokenAuthManager {
+	return &IdTokenAuthManager{
+		rolesClaim: "roles",
+		Verifier:   NewIdTokenVerifier(audiences),
+	}
+}
+
+// Configure the component with specified parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config *conf.ConfigParams configuration parameters to set.
+func (c *IdTokenAuthManager) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	if audience := config.GetAsString("auth.audience"); audience != "" {
+		audiences := make([]string, 0)
+		for _, value := range strings.Split(audience, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				audiences = append(audiences, value)
+			}
+		}
+		c.Verifier.SetAudiences(audiences)
+	}
+	c.Verifier.JwksUri = config.GetAsStringWithDefault("auth.jwks_uri", c.Verifier.JwksUri)
+	c.rolesClaim = config.GetAsStringWithDefault("auth.roles_claim", c.rolesClaim)
+}
+
+// Creates an interceptor that requires a valid ID token.
+// Returns the interceptor that responds with 401 error to unauthenticated requests.
+func (c *IdTokenAuthManager) Authenticate() func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		correlationId := gcputil.CloudFunctionRequestHelper.GetCorrelationId(req)
+
+		token := getBearerToken(req)
+		if token == "" {
+			rpcserv.HttpResponseSender.SendError(
+				res, req,
+				cerr.NewUnauthorizedError(correlationId, "NOT_SIGNED",
+					"User must be signed in to perform this operation").WithStatus(401),
+			)
+			return
+		}
+
+		claims, err := c.Verifier.Verify(req.Context(), correlationId, token)
+		if err != nil {
+			rpcserv.HttpResponseSender.SendError(res, req, err)
+			return
+		}
+
+		principal := &Principal{
+			AuthType: "id_token",
+			Roles:    getClaimValues(claims, c.rolesClaim),
+			Claims:   claims,
+		}
+		principal.Id, _ = claims["sub"].(string)
+		principal.Email, _ = claims["email"].(string)
+
+		next.ServeHTTP(res, req.WithContext(ContextWithPrincipal(req.Context(), principal)))
+	}
+}
+
+func getBearerToken(req *http.Request) string {
+	authorization := req.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+func getClaimValues(claims map[string]any, name string) []string {
+	result := make([]string, 0)
+
+	switch value := claims[name].(type) {
+	case string:
+		for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+			result = append(result, item)
+		}
+	case []any:
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	gcpconn "github.com/pip-services3-gox/pip-services3-gcp-gox/connect"
+)
+
+// Creates ID token source from connection parameters.
+// The source is chosen by credential parameters in the following order:
+// "auth_token" - a static token, "key" or "key_file" - a service account key,
+// "use_metadata" - the metadata server. Generated tokens are cached.
+// Parameters:
+//		- connection	GCP connection parameters.
+//		- audience		a default audience of ID tokens used when "audience" parameter is not set.
+// Returns the token source, nil if no credentials are configured, or error.
+func NewIdTokenSourceFromConnection(connection *gcpconn.GcpConnectionParams, audience string) (IIdTokenSource, error) {
+	if value, ok := connection.Audience(); ok && value != "" {
+		audience = value
+	}
+
+	if token, ok := connection.AuthToken(); ok && token != "" {
+		return NewStaticIdTokenSource(token), nil
+	}
+
+	if key, ok := connection.Key(); ok && key != "" {
+		source, err := NewServiceAccountIdTokenSource([]byte(key), audience)
+		if err != nil {
+			return nil, err
+		}
+		return NewCachedIdTokenSource(source, DefaultRefreshLeeway), nil
+	}
+
+	if keyFile, ok := connection.KeyFile(); ok && keyFile != "" {
+		source, err := NewServiceAccountIdTokenSourceFromFile(keyFile, audience)
+		if err != nil {
+			return nil, err
+		}
+		return NewCachedIdTokenSource(source, DefaultRefreshLeeway), nil
+	}
+
+	if connection.UseMetadata() {
+		return NewCachedIdTokenSource(NewMetadataIdTokenSource(audience), DefaultRefreshLeeway), nil
+	}
+
+	return nil, nil
+}
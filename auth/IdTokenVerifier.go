@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Default endpoint with public keys that sign Google ID tokens
+const DefaultJwksUri = "https://www.googleapis.com/oauth2/v3/certs"
+
+// Issuers of Google-signed ID tokens
+var DefaultIdTokenIssuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+// Verifies Google-signed ID tokens.
+// Public keys are fetched from JWKS endpoint and cached. Keys are refetched
+// when the cache expires or a token is signed by an unknown key.
+//
+// 	Example:
+//		verifier := auth.NewIdTokenVerifier([]string{"https://us-east1-myproject.cloudfunctions.net/myfunction"})
+//		claims, err := verifier.Verify(ctx, "123", token)
+//
+type IdTokenVerifier struct {
+	audiences []string
+	keys      map[string]*rsa.PublicKey
+	fetched   time.Time
+	expiry    time.Time
+	mtx       sync.Mutex
+
+	// The endpoint with public keys.
+	JwksUri string
+	// The accepted token issuers.
+	Issuers []string
+	// The allowed clock skew.
+	Leeway time.Duration
+	// The period to cache public keys when JWKS response has no max-age.
+	CacheTimeout time.Duration
+	// The HTTP client to fetch public keys.
+	Client *http.Client
+}
+
+// Creates a new instance of the verifier.
+// Parameters:
+//		- audiences	a list of accepted audiences.
+func NewIdTokenVerifier(audiences []string) *IdTokenVerifier {
+	return &IdTokenVerifier{
+		audiences:    audiences,
+		keys:         make(map[string]*rsa.PublicKey),
+		JwksUri:      DefaultJwksUri,
+		Issuers:      DefaultIdTokenIssuers,
+		Leeway:       time.Minute,
+		CacheTimeout: time.Hour,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Gets the accepted audiences.
+func (c *IdTokenVerifier) Audiences() []string {
+	return c.audiences
+}
+
+// Sets the accepted audiences.
+// Parameters:
+//		- audiences	a list of accepted audiences.
+func (c *IdTokenVerifier) SetAudiences(audiences []string) {
+	c.audiences = audiences
+}
+
+// Verifies ID token signature, audience, issuer and expiration time.
+// Parameters:
+//		- ctx context.Context
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+//		- token			an encoded ID token.
+// Returns verified token claims or UnauthorizedError.
+func (c *IdTokenVerifier) Verify(ctx context.Context, correlationId string, token string) (map[string]any, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, c.invalidToken(correlationId, "Token is not a valid JWT", nil)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	headerJson, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err == nil {
+		err = json.Unmarshal(headerJson, &header)
+	}
+	if err != nil {
+		return nil, c.invalidToken(correlationId, "Token header is invalid", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, c.invalidToken(correlationId, "Token algorithm "+header.Alg+" is not supported", nil)
+	}
+
+	key, err := c.getKey(ctx, correlationId, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, c.invalidToken(correlationId, "Token signature is invalid", err)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], signature)
+	if err != nil {
+		return nil, c.invalidToken(correlationId, "Token signature is invalid", err)
+	}
+
+	var claims map[string]any
+	err = decodeJwtClaims(token, &claims)
+	if err != nil {
+		return nil, c.invalidToken(correlationId, "Token claims are invalid", err)
+	}
+
+	err = c.verifyClaims(claims)
+	if err != nil {
+		return nil, c.invalidToken(correlationId, err.Error(), nil)
+	}
+
+	return claims, nil
+}
+
+func (c *IdTokenVerifier) verifyClaims(claims map[string]any) error {
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.Add(-c.Leeway).After(time.Unix(int64(exp), 0)) {
+		return errors.New("Token is expired")
+	}
+
+	if iat, ok := claims["iat"].(float64); ok && now.Add(c.Leeway).Before(time.Unix(int64(iat), 0)) {
+		return errors.New("Token is issued in the future")
+	}
+
+	issuer, _ := claims["iss"].(string)
+	if !containsString(c.Issuers, issuer) {
+		return errors.New("Token issuer " + issuer + " is not accepted")
+	}
+
+	audiences := make([]string, 0)
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, aud)
+	case []any:
+		for _, item := range aud {
+			if value, ok := item.(string); ok {
+				audiences = append(audiences, value)
+			}
+		}
+	}
+
+	for _, audience := range audiences {
+		if containsString(c.audiences, audience) {
+			return nil
+		}
+	}
+	return errors.New("Token audience is not accepted")
+}
+
+func (c *IdTokenVerifier) getKey(ctx context.Context, correlationId string, kid string) (*rsa.PublicKey, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	now := time.Now()
+	key, ok := c.keys[kid]
+	if ok && now.Before(c.expiry) {
+		return key, nil
+	}
+
+	// Limit refetching keys for tokens with unknown key ids
+	if !ok && now.Before(c.expiry) && now.Sub(c.fetched) < time.Minute {
+		return nil, c.invalidToken(correlationId, "Token is signed by unknown key "+kid, nil)
+	}
+
+	err := c.fetchKeys(ctx, correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, c.invalidToken(correlationId, "Token is signed by unknown key "+kid, nil)
+	}
+	return key, nil
+}
+
+func (c *IdTokenVerifier) fetchKeys(ctx context.Context, correlationId string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.JwksUri, nil)
+	if err != nil {
+		return cerr.NewConfigError(correlationId, "INVALID_JWKS_URI", "JWKS uri is invalid").
+			WithDetails("jwks_uri", c.JwksUri).WithCause(err)
+	}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_GET_KEYS", "Failed to fetch public keys").
+			WithDetails("jwks_uri", c.JwksUri).WithCause(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return cerr.NewConnectionError(correlationId, "CANNOT_GET_KEYS", "Failed to fetch public keys").
+			WithDetails("jwks_uri", c.JwksUri).WithDetails("status", res.StatusCode)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&jwks)
+	if err != nil {
+		return cerr.NewConnectionError(correlationId, "CANNOT_GET_KEYS", "Public keys response is invalid").
+			WithCause(err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, nErr := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, eErr := base64.RawURLEncoding.DecodeString(jwk.E)
+		if nErr != nil || eErr != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	c.keys = keys
+	c.fetched = time.Now()
+	c.expiry = time.Now().Add(getMaxAge(res.Header.Get("Cache-Control"), c.CacheTimeout))
+	return nil
+}
+
+func (c *IdTokenVerifier) invalidToken(correlationId string, message string, cause error) error {
+	err := cerr.NewUnauthorizedError(correlationId, "INVALID_TOKEN", message)
+	if cause != nil {
+		err = err.WithCause(cause)
+	}
+	return err
+}
+
+func getMaxAge(cacheControl string, defaultValue time.Duration) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			seconds, err := time.ParseDuration(strings.TrimPrefix(directive, "max-age=") + "s")
+			if err == nil && seconds > 0 {
+				return seconds
+			}
+		}
+	}
+	return defaultValue
+}
+
+func containsString(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
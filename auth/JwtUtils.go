@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Gets expiration time from "exp" claim of JWT token.
+// The token signature is not verified.
+// Parameters:
+//		- token	an encoded JWT token.
+// Returns the expiration time or error if the token is not a valid JWT.
+func GetJwtExpiry(token string) (time.Time, error) {
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	err := decodeJwtClaims(token, &claims)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if claims.Exp == 0 {
+		return time.Time{}, errors.New("missing exp claim")
+	}
+	return time.Unix(claims.Exp, 0), nil
+}
+
+func decodeJwtClaims(token string, claims any) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("token is not a valid JWT")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(payload, claims)
+}
+
+// Creates a request to exchange JWT token signed with the service account key
+// for a Google-signed token at the key token endpoint (JWT bearer grant).
+// The requested token is set by a claim: "target_audience" for ID tokens or "scope" for access tokens.
+func newJwtBearerRequest(ctx context.Context, correlationId string, key *ServiceAccountKey,
+	privateKey *rsa.PrivateKey, claim string, value string) (*http.Request, error) {
+
+	now := time.Now()
+
+	header := map[string]any{
+		"alg": "RS256",
+		"typ": "JWT",
+	}
+	if key.PrivateKeyId != "" {
+		header["kid"] = key.PrivateKeyId
+	}
+
+	claims := map[string]any{
+		"iss": key.ClientEmail,
+		"sub": key.ClientEmail,
+		"aud": key.TokenUri,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+		claim: value,
+	}
+
+	assertion, err := signJwt(header, claims, privateKey)
+	if err != nil {
+		return nil, cerr.NewInternalError(correlationId, "CANNOT_SIGN_TOKEN", "Failed to sign JWT token").WithCause(err)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, key.TokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_TOKEN_URI", "Token uri is invalid").
+			WithDetails("token_uri", key.TokenUri).WithCause(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
+}
+
+func signJwt(header map[string]any, claims map[string]any, key *rsa.PrivateKey) (string, error) {
+	headerJson, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	claimsJson, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(headerJson) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsJson)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parseRsaPrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, errors.New("private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("private key is not an RSA key")
+		}
+		return rsaKey, nil
+	}
+
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
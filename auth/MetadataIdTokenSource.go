@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Default host of Google Compute metadata server.
+// It can be overridden by GCE_METADATA_HOST environment variable.
+const DefaultMetadataHost = "metadata.google.internal"
+
+// Token source that obtains ID tokens for the default service account
+// from the metadata server available on Cloud Functions, Cloud Run and Compute Engine.
+//
+// The metadata server host can be overridden by GCE_METADATA_HOST environment variable.
+type MetadataIdTokenSource struct {
+	host     string
+	audience string
+
+	// The HTTP client to call the metadata server.
+	Client *http.Client
+}
+
+// Creates a new instance of the token source.
+// Parameters:
+//		- audience	an audience of generated ID tokens.
+func NewMetadataIdTokenSource(audience string) *MetadataIdTokenSource {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = DefaultMetadataHost
+	}
+
+	return &MetadataIdTokenSource{
+		host:     host,
+		audience: audience,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Gets a new ID token from the metadata server.
+// Parameters:
+//		- ctx context.Context
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+// Returns an ID token or error.
+func (c *MetadataIdTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	uri := "http://" + c.host + "/computeMetadata/v1/instance/service-accounts/default/identity" +
+		"?audience=" + url.QueryEscape(c.audience) + "&format=full"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_METADATA_HOST", "Metadata server host is invalid").
+			WithDetails("host", c.host).WithCause(err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_GET_ID_TOKEN", "Failed to call metadata server").
+			WithDetails("host", c.host).WithCause(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_GET_ID_TOKEN", "Failed to read metadata server response").
+			WithCause(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, cerr.NewUnauthorizedError(correlationId, "ID_TOKEN_REJECTED", "Metadata server rejected the request").
+			WithDetails("status", res.StatusCode).WithDetails("response", string(body))
+	}
+
+	token := strings.TrimSpace(string(body))
+	expiry, err := GetJwtExpiry(token)
+	if err != nil {
+		expiry = time.Now().Add(time.Hour)
+	}
+
+	return &IdToken{Value: token, Expiry: expiry}, nil
+}
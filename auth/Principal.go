@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	rpcauth "github.com/pip-services3-gox/pip-services3-rpc-gox/auth"
+)
+
+type principalContextKey struct{}
+
+// Principal is an authenticated caller of Google Function.
+//
+// see IdTokenAuthManager, ApiKeyAuthManager
+type Principal struct {
+	// The unique caller id: "sub" claim of ID token or API key name
+	Id string
+	// The caller email, when it is known
+	Email string
+	// The authentication method: "id_token" or "api_key"
+	AuthType string
+	// The roles granted to the caller
+	Roles []string
+	// The verified token claims
+	Claims map[string]any
+}
+
+// Checks if the principal has one of the roles.
+// Parameters:
+//		- roles	a list of roles to check.
+// Returns true if the principal has any of the roles.
+func (c *Principal) IsInRoles(roles ...string) bool {
+	for _, role := range roles {
+		for _, userRole := range c.Roles {
+			if role == userRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Adds the principal to the context.
+// For compatibility with Pip.Services RPC authorizers the principal is also stored as
+// user map under "user" key and its id under "user_id" key.
+// Parameters:
+//		- ctx		a parent context.
+//		- principal	an authenticated principal.
+// Returns a new context with the principal.
+func ContextWithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	roles := make([]any, 0, len(principal.Roles))
+	for _, role := range principal.Roles {
+		roles = append(roles, role)
+	}
+
+	user := cdata.NewAnyValueMapFromTuples(
+		"id", principal.Id,
+		"email", principal.Email,
+		string(rpcauth.PipAuthRoles), roles,
+	)
+
+	ctx = context.WithValue(ctx, principalContextKey{}, principal)
+	ctx = context.WithValue(ctx, rpcauth.PipAuthUser, *user)
+	ctx = context.WithValue(ctx, rpcauth.PipAuthUserId, principal.Id)
+	return ctx
+}
+
+// Gets authenticated principal from the context.
+// Parameters:
+//		- ctx	a request context.
+// Returns the principal and true, or nil and false if the request is not authenticated.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+)
+
+// Authorizes requests authenticated by IdTokenAuthManager or ApiKeyAuthManager
+// by checking roles and claims of the Principal.
+//
+// 	Example:
+//		roles := auth.RoleAuthManager{}
+//		c.RegisterInterceptor("mydata.delete_.*", roles.UserInRole("admin"))
+//
+type RoleAuthManager struct {
+}
+
+// Creates an interceptor that requires an authenticated principal.
+func (c *RoleAuthManager) Signed() func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return c.authorize(func(correlationId string, principal *Principal) error {
+		return nil
+	})
+}
+
+// Creates an interceptor that requires the principal to have one of the roles.
+// Parameters:
+//		- roles	a list of roles.
+func (c *RoleAuthManager) UserInRoles(roles []string) func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return c.authorize(func(correlationId string, principal *Principal) error {
+		if principal.IsInRoles(roles...) {
+			return nil
+		}
+		return cerr.NewUnauthorizedError(correlationId, "NOT_IN_ROLE",
+			"User must be "+strings.Join(roles, " or ")+" to perform this operation").
+			WithDetails("roles", roles).WithStatus(403)
+	})
+}
+
+// Creates an interceptor that requires the principal to have the role.
+// Parameters:
+//		- role	a role name.
+func (c *RoleAuthManager) UserInRole(role string) func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return c.UserInRoles([]string{role})
+}
+
+// Creates an interceptor that requires the principal to have "admin" role.
+func (c *RoleAuthManager) Admin() func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return c.UserInRole("admin")
+}
+
+// Creates an interceptor that requires the token claim to have one of the values,
+// e.g. "email" of allowed callers or "hd" of allowed Google Workspace domains.
+// Parameters:
+//		- claim		a claim name.
+//		- values	a list of allowed values.
+func (c *RoleAuthManager) ClaimIn(claim string, values ...string) func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return c.authorize(func(correlationId string, principal *Principal) error {
+		for _, value := range getClaimValues(principal.Claims, claim) {
+			if containsString(values, value) {
+				return nil
+			}
+		}
+		return cerr.NewUnauthorizedError(correlationId, "CLAIM_NOT_ALLOWED",
+			"User "+claim+" is not allowed to perform this operation").
+			WithDetails("claim", claim).WithStatus(403)
+	})
+}
+
+func (c *RoleAuthManager) authorize(check func(correlationId string, principal *Principal) error) func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+	return func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc) {
+		correlationId := gcputil.CloudFunctionRequestHelper.GetCorrelationId(req)
+
+		principal, ok := PrincipalFromContext(req.Context())
+		if !ok {
+			rpcserv.HttpResponseSender.SendError(
+				res, req,
+				cerr.NewUnauthorizedError(correlationId, "NOT_SIGNED",
+					"User must be signed in to perform this operation").WithStatus(401),
+			)
+			return
+		}
+
+		if err := check(correlationId, principal); err != nil {
+			rpcserv.HttpResponseSender.SendError(res, req, err)
+			return
+		}
+
+		next.ServeHTTP(res, req)
+	}
+}
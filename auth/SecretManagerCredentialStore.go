@@ -0,0 +1,481 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"hash/crc32"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	cauth "github.com/pip-services3-gox/pip-services3-components-gox/auth"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+)
+
+// Default endpoint of Google Secret Manager API
+const DefaultSecretManagerUri = "https://secretmanager.googleapis.com"
+
+// Default time in milliseconds to cache secrets
+const DefaultSecretCacheTimeout = 300000
+
+// SecretManagerCredentialStore is a credential store that reads credentials from Google Secret Manager.
+//
+// Credential keys are secret resource names like "projects/myproject/secrets/mysecret/versions/3",
+// or short names like "mysecret" or "mysecret/versions/3" resolved in the configured project.
+// Secrets without versions resolve to the configured version, which is "latest" by default.
+// Keys can also be mapped to resource names in "secrets" configuration section.
+//
+// Secrets that contain JSON objects are read as credential parameters, service account keys
+// are returned in "key" parameter and other secrets are returned in "auth_token" parameter.
+//
+// Secrets are cached and refreshed when the cache timeout expires. Pinned versions
+// do not change, so they are cached until the store is reconfigured. When a refresh fails
+// because Secret Manager is not available, the cached credential is returned until
+// the secret becomes available again. Other failures, like denied access or deleted
+// secrets, are returned as errors.
+//
+//	Configuration parameters:
+//		- connection:
+//			- uri:				Secret Manager API endpoint (default: https://secretmanager.googleapis.com)
+//		- credential:
+//			- access_token:		OAuth access token to call Secret Manager
+//			- key_file:			path to the service account JSON key file to generate access tokens
+//								(default: GOOGLE_APPLICATION_CREDENTIALS key file or the default service account)
+//		- options:
+//			- project_id:		project of secrets given by short names (default: GOOGLE_CLOUD_PROJECT environment variable)
+//			- version:			version of secrets given without versions (default: latest)
+//			- cache_timeout:	time in milliseconds to cache secrets, 0 to disable caching (default: 5 mins)
+//			- timeout:			request timeout in milliseconds (default: 10000)
+//		- secrets:
+//			- <key>:			a resource name of the secret to look up the key
+//
+//	References:
+//		- *:logger:*:*:1.0			(optional) ILogger components to log refresh failures
+//
+// see ICredentialStore (in the Pip.Services components package)
+//
+//	Example:
+//		store := auth.NewSecretManagerCredentialStore()
+//		store.Configure(ctx, config.NewConfigParamsFromTuples(
+//			"options.project_id", "myproject",
+//			"secrets.dummies", "dummies-token/versions/2",
+//		))
+//
+//		credential, err := store.Lookup(ctx, "123", "dummies")
+//		token := credential.GetAsString("auth_token")
+//
+//		// Clients resolve credentials from the store by "store_key" parameter
+//		client.Configure(ctx, config.NewConfigParamsFromTuples(
+//			"connection.uri", "https://us-east1-myproject.cloudfunctions.net/dummies",
+//			"credential.store_key", "dummies",
+//		))
+//
+type SecretManagerCredentialStore struct {
+	uri          string
+	projectId    string
+	version      string
+	cacheTimeout int64
+	secrets      map[string]string
+	tokenSource  IIdTokenSource
+	items        map[string]*secretCacheItem
+	logger       *clog.CompositeLogger
+	mtx          sync.Mutex
+
+	// The HTTP client to call Secret Manager.
+	Client *http.Client
+}
+
+type secretCacheItem struct {
+	payload []byte
+	expiry  time.Time
+}
+
+// Creates a new instance of the credential store.
+func NewSecretManagerCredentialStore() *SecretManagerCredentialStore {
+	return &SecretManagerCredentialStore{
+		uri:          DefaultSecretManagerUri,
+		projectId:    os.Getenv("GOOGLE_CLOUD_PROJECT"),
+		version:      "latest",
+		cacheTimeout: DefaultSecretCacheTimeout,
+		secrets:      make(map[string]string),
+		items:        make(map[string]*secretCacheItem),
+		logger:       clog.NewCompositeLogger(),
+		Client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config ConfigParams configuration parameters to be set.
+func (c *SecretManagerCredentialStore) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.uri = strings.TrimSuffix(config.GetAsStringWithDefault("connection.uri", c.uri), "/")
+	c.projectId = config.GetAsStringWithDefault("options.project_id", c.projectId)
+	c.version = config.GetAsStringWithDefault("options.version", c.version)
+	c.cacheTimeout = config.GetAsLongWithDefault("options.cache_timeout", c.cacheTimeout)
+	timeout := config.GetAsLongWithDefault("options.timeout", 10000)
+	c.Client = &http.Client{Timeout: time.Duration(timeout) * time.Millisecond}
+
+	secrets := config.GetSection("secrets")
+	c.secrets = make(map[string]string)
+	for _, key := range secrets.Keys() {
+		c.secrets[key] = secrets.GetAsString(key)
+	}
+
+	c.tokenSource = nil
+	if token := config.GetAsString("credential.access_token"); token != "" {
+		c.tokenSource = NewStaticIdTokenSource(token)
+	} else if keyFile := config.GetAsString("credential.key_file"); keyFile != "" {
+		c.tokenSource = newKeyFileAccessTokenSource(keyFile)
+	}
+
+	c.items = make(map[string]*secretCacheItem)
+}
+
+// SetReferences sets references to dependent components.
+//	Parameters:
+//		- ctx context.Context
+//		- references IReferences references to locate the component dependencies.
+func (c *SecretManagerCredentialStore) SetReferences(ctx context.Context, references crefer.IReferences) {
+	c.logger.SetReferences(ctx, references)
+}
+
+// Gets the resource name of the secret version to look up the key.
+// Parameters:
+//		- key	a credential key.
+// Returns the resource name like "projects/myproject/secrets/mysecret/versions/latest"
+// or empty string when the project is unknown.
+func (c *SecretManagerCredentialStore) GetSecretName(key string) string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.getSecretName(key)
+}
+
+func (c *SecretManagerCredentialStore) getSecretName(key string) string {
+	name := strings.Trim(key, "/")
+	if value, ok := c.secrets[key]; ok && value != "" {
+		name = strings.Trim(value, "/")
+	}
+
+	if !strings.HasPrefix(name, "projects/") {
+		if c.projectId == "" {
+			return ""
+		}
+		name = "projects/" + c.projectId + "/secrets/" + name
+	}
+
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/" + c.version
+	}
+
+	return name
+}
+
+// Lookup credential parameters by its key.
+//	Parameters:
+//		- ctx context.Context.
+//		- correlationId string transaction id to trace execution through call chain.
+//		- key string a key to uniquely identify the credential parameters.
+//	Returns: result *CredentialParams, err error result of lookup and error message
+func (c *SecretManagerCredentialStore) Lookup(ctx context.Context, correlationId string,
+	key string) (*cauth.CredentialParams, error) {
+
+	payload, err := c.getPayload(ctx, correlationId, key)
+	if err != nil {
+		return nil, err
+	}
+	return c.parsePayload(payload), nil
+}
+
+// Gets the secret payload as text, e.g. to substitute secrets in configuration.
+// The secret is read from the cache the same way as credentials in Lookup.
+//	Parameters:
+//		- ctx context.Context.
+//		- correlationId string transaction id to trace execution through call chain.
+//		- key string a credential key or a resource name of the secret.
+//	Returns: the secret payload and error
+func (c *SecretManagerCredentialStore) GetSecret(ctx context.Context, correlationId string,
+	key string) (string, error) {
+
+	payload, err := c.getPayload(ctx, correlationId, key)
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+func (c *SecretManagerCredentialStore) getPayload(ctx context.Context, correlationId string,
+	key string) ([]byte, error) {
+
+	c.mtx.Lock()
+	name := c.getSecretName(key)
+	cached, ok := c.items[name]
+	c.mtx.Unlock()
+
+	if name == "" {
+		return nil, cerr.NewConfigError(correlationId, "NO_PROJECT_ID", "Project of secret "+key+" is not configured").
+			WithDetails("key", key)
+	}
+
+	if ok && (cached.expiry.IsZero() || time.Now().Before(cached.expiry)) {
+		return cached.payload, nil
+	}
+
+	payload, err := c.accessSecret(ctx, correlationId, name)
+	if err != nil {
+		if ok && isTransientSecretError(err) {
+			// Keep using the cached secret while Secret Manager is not available
+			c.logger.Warn(ctx, correlationId, "Using cached secret %s after refresh failed: %s", name, err.Error())
+			return cached.payload, nil
+		}
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	if c.cacheTimeout > 0 {
+		item := &secretCacheItem{payload: payload}
+		if !isPinnedVersion(name) {
+			item.expiry = time.Now().Add(time.Duration(c.cacheTimeout) * time.Millisecond)
+		}
+		c.items[name] = item
+	}
+	c.mtx.Unlock()
+
+	return payload, nil
+}
+
+// Store credential parameters as a new version of the secret.
+// The credential parameters are stored as a JSON object.
+//	Parameters:
+//		- ctx context.Context.
+//		- correlationId string transaction id to trace execution through call chain.
+//		- key string a key to uniquely identify the credential parameters.
+//		- credential *CredentialParams a credential parameters to be stored.
+//	Returns: error
+func (c *SecretManagerCredentialStore) Store(ctx context.Context, correlationId string, key string,
+	credential *cauth.CredentialParams) error {
+
+	if credential == nil {
+		return cerr.NewUnsupportedError(correlationId, "NOT_SUPPORTED", "Deleting secrets is not supported").
+			WithDetails("key", key)
+	}
+
+	c.mtx.Lock()
+	name := c.getSecretName(key)
+	c.mtx.Unlock()
+
+	if name == "" {
+		return cerr.NewConfigError(correlationId, "NO_PROJECT_ID", "Project of secret "+key+" is not configured").
+			WithDetails("key", key)
+	}
+
+	// New versions are added to the secret
+	secret := name[:strings.Index(name, "/versions/")]
+
+	data, err := json.Marshal(credential.Value())
+	if err != nil {
+		return cerr.NewBadRequestError(correlationId, "INVALID_CREDENTIAL", "Credential cannot be converted to JSON").
+			WithCause(err)
+	}
+
+	body, _ := json.Marshal(map[string]any{
+		"payload": map[string]any{
+			"data":       base64.StdEncoding.EncodeToString(data),
+			"dataCrc32c": strconv.FormatUint(uint64(crc32.Checksum(data, crc32cTable)), 10),
+		},
+	})
+
+	_, err = c.call(ctx, correlationId, http.MethodPost, secret+":addVersion", body)
+	if err != nil {
+		return err
+	}
+
+	// Versions resolved from aliases may change
+	c.mtx.Lock()
+	for itemName := range c.items {
+		if strings.HasPrefix(itemName, secret+"/versions/") && !isPinnedVersion(itemName) {
+			delete(c.items, itemName)
+		}
+	}
+	c.mtx.Unlock()
+
+	return nil
+}
+
+// Clears cached secrets, so the next lookups read them from Secret Manager.
+func (c *SecretManagerCredentialStore) ClearCache() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.items = make(map[string]*secretCacheItem)
+}
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+func (c *SecretManagerCredentialStore) accessSecret(ctx context.Context, correlationId string, name string) ([]byte, error) {
+	body, err := c.call(ctx, correlationId, http.MethodGet, name+":access", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Payload struct {
+			Data       string `json:"data"`
+			DataCrc32c string `json:"dataCrc32c"`
+		} `json:"payload"`
+	}
+	err = json.Unmarshal(body, &result)
+	if err == nil {
+		var payload []byte
+		payload, err = base64.StdEncoding.DecodeString(result.Payload.Data)
+		if err == nil {
+			if result.Payload.DataCrc32c != "" &&
+				result.Payload.DataCrc32c != strconv.FormatUint(uint64(crc32.Checksum(payload, crc32cTable)), 10) {
+				return nil, cerr.NewInternalError(correlationId, "CORRUPTED_SECRET", "Secret payload checksum does not match").
+					WithDetails("name", name)
+			}
+			return payload, nil
+		}
+	}
+
+	return nil, cerr.NewInternalError(correlationId, "INVALID_SECRET", "Secret Manager returned invalid response").
+		WithDetails("name", name).WithCause(err)
+}
+
+func (c *SecretManagerCredentialStore) call(ctx context.Context, correlationId string,
+	method string, name string, body []byte) ([]byte, error) {
+
+	c.mtx.Lock()
+	uri := c.uri + "/v1/" + name
+	client := c.Client
+	if c.tokenSource == nil {
+		c.tokenSource = NewDefaultAccessTokenSource()
+	}
+	tokenSource := c.tokenSource
+	c.mtx.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, method, uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_URI", "Secret Manager uri is invalid").
+			WithDetails("uri", uri).WithCause(err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	token, err := tokenSource.GetIdToken(ctx, correlationId)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token.Value)
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_ACCESS_SECRET", "Failed to call Secret Manager").
+			WithDetails("name", name).WithCause(err)
+	}
+	defer res.Body.Close()
+
+	result, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_ACCESS_SECRET", "Failed to read Secret Manager response").
+			WithDetails("name", name).WithCause(err)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, cerr.NewConfigError(correlationId, "MISSING_CREDENTIALS", "Secret "+name+" was not found").
+			WithDetails("name", name)
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return nil, cerr.NewUnauthorizedError(correlationId, "ACCESS_DENIED", "Access to secret "+name+" was denied").
+			WithDetails("name", name).WithDetails("status", res.StatusCode)
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_ACCESS_SECRET", "Secret Manager is not available").
+			WithDetails("name", name).WithDetails("status", res.StatusCode).WithDetails("response", string(result))
+	case res.StatusCode >= 300:
+		return nil, cerr.NewBadRequestError(correlationId, "CANNOT_ACCESS_SECRET", "Secret Manager rejected the request").
+			WithDetails("name", name).WithDetails("status", res.StatusCode).WithDetails("response", string(result))
+	}
+
+	return result, nil
+}
+
+// Checks if the secret can become available later, so the cached secret can be used meanwhile
+func isTransientSecretError(err error) bool {
+	appErr, isApp := err.(*cerr.ApplicationError)
+	return isApp && appErr.Category == cerr.NoResponse
+}
+
+func (c *SecretManagerCredentialStore) parsePayload(payload []byte) *cauth.CredentialParams {
+	var values map[string]any
+	if err := json.Unmarshal(payload, &values); err != nil || values == nil {
+		return cauth.NewCredentialParamsFromTuples("auth_token", strings.TrimSpace(string(payload)))
+	}
+
+	if values["type"] == "service_account" {
+		return cauth.NewCredentialParamsFromTuples("key", string(payload))
+	}
+
+	credential := cauth.NewEmptyCredentialParams()
+	for key, value := range values {
+		if text, ok := value.(string); ok {
+			credential.Put(key, text)
+		} else {
+			credential.Put(key, cconv.StringConverter.ToString(value))
+		}
+	}
+	return credential
+}
+
+// Checks if the resource name refers to a numbered version instead of an alias like "latest"
+func isPinnedVersion(name string) bool {
+	version := name[strings.LastIndex(name, "/")+1:]
+	_, err := strconv.ParseUint(version, 10, 64)
+	return err == nil
+}
+
+// Token source that reads the service account key when the first token is requested
+type keyFileAccessTokenSource struct {
+	path   string
+	source IIdTokenSource
+	mtx    sync.Mutex
+}
+
+func newKeyFileAccessTokenSource(path string) *keyFileAccessTokenSource {
+	return &keyFileAccessTokenSource{path: path}
+}
+
+func (c *keyFileAccessTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	c.mtx.Lock()
+	if c.source == nil {
+		keyJson, err := ioutil.ReadFile(c.path)
+		if err != nil {
+			c.mtx.Unlock()
+			return nil, cerr.NewFileError(correlationId, "READ_FAILED", "Failed to read service account key file").
+				WithDetails("path", c.path).WithCause(err)
+		}
+
+		source, err := newServiceAccountAccessTokenSource(keyJson)
+		if err != nil {
+			c.mtx.Unlock()
+			return nil, err
+		}
+		c.source = NewCachedIdTokenSource(source, DefaultRefreshLeeway)
+	}
+	source := c.source
+	c.mtx.Unlock()
+
+	return source.GetIdToken(ctx, correlationId)
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Default endpoint to exchange self-signed JWT tokens for Google-signed ID tokens
+const DefaultTokenUri = "https://oauth2.googleapis.com/token"
+
+// ServiceAccountKey is a Google service account JSON key
+// as it is downloaded from Google Cloud Console.
+type ServiceAccountKey struct {
+	// The key type, always "service_account"
+	Type string `json:"type"`
+	// The project that owns the service account
+	ProjectId string `json:"project_id"`
+	// The identifier of the private key
+	PrivateKeyId string `json:"private_key_id"`
+	// The PEM encoded RSA private key
+	PrivateKey string `json:"private_key"`
+	// The service account email
+	ClientEmail string `json:"client_email"`
+	// The endpoint to obtain tokens
+	TokenUri string `json:"token_uri"`
+}
+
+// Token source that generates ID tokens for a service account.
+// It signs JWT token with "target_audience" claim using the service account key
+// and exchanges it for Google-signed ID token at the key token endpoint.
+//
+// 	Example:
+//		source, err := auth.NewServiceAccountIdTokenSourceFromFile("./key.json", "https://us-east1-myproject.cloudfunctions.net/myfunction")
+//		token, err := source.GetIdToken(ctx, "123")
+//
+type ServiceAccountIdTokenSource struct {
+	key        *ServiceAccountKey
+	privateKey *rsa.PrivateKey
+	audience   string
+
+	// The HTTP client to call the token endpoint.
+	Client *http.Client
+}
+
+// Creates a new instance of the token source from JSON key.
+// Parameters:
+//		- keyJson	a service account JSON key.
+//		- audience	an audience of generated ID tokens.
+// Returns the token source or error if the key is invalid.
+func NewServiceAccountIdTokenSource(keyJson []byte, audience string) (*ServiceAccountIdTokenSource, error) {
+	var key ServiceAccountKey
+	err := json.Unmarshal(keyJson, &key)
+	if err != nil {
+		return nil, cerr.NewConfigError("", "INVALID_KEY", "Service account key is not a valid JSON").WithCause(err)
+	}
+
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, cerr.NewConfigError("", "INVALID_KEY", "Service account key misses client_email or private_key")
+	}
+
+	if key.TokenUri == "" {
+		key.TokenUri = DefaultTokenUri
+	}
+
+	privateKey, err := parseRsaPrivateKey(key.PrivateKey)
+	if err != nil {
+		return nil, cerr.NewConfigError("", "INVALID_KEY", "Service account private key is invalid").WithCause(err)
+	}
+
+	return &ServiceAccountIdTokenSource{
+		key:        &key,
+		privateKey: privateKey,
+		audience:   audience,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Creates a new instance of the token source from JSON key file.
+// Parameters:
+//		- path		a path to service account JSON key file.
+//		- audience	an audience of generated ID tokens.
+// Returns the token source or error if the key cannot be read.
+func NewServiceAccountIdTokenSourceFromFile(path string, audience string) (*ServiceAccountIdTokenSource, error) {
+	keyJson, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, cerr.NewFileError("", "READ_FAILED", "Failed to read service account key file").
+			WithDetails("path", path).WithCause(err)
+	}
+
+	return NewServiceAccountIdTokenSource(keyJson, audience)
+}
+
+// Gets the service account email.
+func (c *ServiceAccountIdTokenSource) Email() string {
+	return c.key.ClientEmail
+}
+
+// Gets a new ID token for the service account.
+// Parameters:
+//		- ctx context.Context
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+// Returns an ID token or error.
+func (c *ServiceAccountIdTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	req, err := newJwtBearerRequest(ctx, correlationId, c.key, c.privateKey, "target_audience", c.audience)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_GET_ID_TOKEN", "Failed to call token endpoint").
+			WithDetails("token_uri", c.key.TokenUri).WithCause(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, cerr.NewConnectionError(correlationId, "CANNOT_GET_ID_TOKEN", "Failed to read token response").
+			WithCause(err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, cerr.NewUnauthorizedError(correlationId, "ID_TOKEN_REJECTED", "Token endpoint rejected the request").
+			WithDetails("status", res.StatusCode).WithDetails("response", string(body))
+	}
+
+	var result struct {
+		IdToken string `json:"id_token"`
+	}
+	err = json.Unmarshal(body, &result)
+	if err != nil || result.IdToken == "" {
+		return nil, cerr.NewUnauthorizedError(correlationId, "ID_TOKEN_REJECTED", "Token endpoint did not return id_token").
+			WithCause(err)
+	}
+
+	expiry, err := GetJwtExpiry(result.IdToken)
+	if err != nil {
+		expiry = time.Now().Add(time.Hour)
+	}
+
+	return &IdToken{Value: result.IdToken, Expiry: expiry}, nil
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+)
+
+// Token source that returns an ID token given in the configuration
+// via "credential.auth_token" parameter.
+type StaticIdTokenSource struct {
+	token *IdToken
+}
+
+// Creates a new instance of the token source.
+// Parameters:
+//		- token	an ID token.
+func NewStaticIdTokenSource(token string) *StaticIdTokenSource {
+	expiry, _ := GetJwtExpiry(token)
+	return &StaticIdTokenSource{
+		token: &IdToken{Value: token, Expiry: expiry},
+	}
+}
+
+// Gets the configured ID token.
+// Parameters:
+//		- ctx context.Context
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+// Returns an ID token or error.
+func (c *StaticIdTokenSource) GetIdToken(ctx context.Context, correlationId string) (*IdToken, error) {
+	return c.token, nil
+}
@@ -0,0 +1,38 @@
+package build
+
+import (
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	cbuild "github.com/pip-services3-gox/pip-services3-components-gox/build"
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+	gcplog "github.com/pip-services3-gox/pip-services3-gcp-gox/log"
+	gcpotel "github.com/pip-services3-gox/pip-services3-gcp-gox/otel"
+)
+
+// Creates Google Cloud Platform specific components by their descriptors.
+//
+// see CloudLoggingLogger
+// see OtelTracer
+// see OtelCounters
+// see SecretManagerCredentialStore
+type DefaultGcpFactory struct {
+	*cbuild.Factory
+}
+
+var CloudLoggingLoggerDescriptor = crefer.NewDescriptor("pip-services", "logger", "cloudlogging", "*", "1.0")
+var OtelTracerDescriptor = crefer.NewDescriptor("pip-services", "tracer", "otel", "*", "1.0")
+var OtelCountersDescriptor = crefer.NewDescriptor("pip-services", "counters", "otel", "*", "1.0")
+var SecretManagerCredentialStoreDescriptor = crefer.NewDescriptor("pip-services", "credential-store", "secretmanager", "*", "1.0")
+
+// Create a new instance of the factory.
+func NewDefaultGcpFactory() *DefaultGcpFactory {
+	c := DefaultGcpFactory{
+		Factory: cbuild.NewFactory(),
+	}
+
+	c.RegisterType(CloudLoggingLoggerDescriptor, gcplog.NewCloudLoggingLogger)
+	c.RegisterType(OtelTracerDescriptor, gcpotel.NewOtelTracer)
+	c.RegisterType(OtelCountersDescriptor, gcpotel.NewOtelCounters)
+	c.RegisterType(SecretManagerCredentialStoreDescriptor, gcpauth.NewSecretManagerCredentialStore)
+
+	return &c
+}
@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"context"
+	"sync"
+)
+
+type callEndpointKey struct{}
+
+// CallEndpoint reports which endpoint served client calls made with the context
+// returned by WithCallEndpoint. When the function is deployed to several regions,
+// calls can be served by a secondary endpoint after failover.
+//
+// see CloudFunctionClient
+type CallEndpoint struct {
+	uri       string
+	failovers int
+	mtx       sync.Mutex
+}
+
+// WithCallEndpoint returns a copy of the context that records the endpoint
+// which served the last client call made with it.
+// Parameters:
+//		- ctx	a parent context.
+// Returns the context and the endpoint record.
+//
+//	Example:
+//		ctx, endpoint := clients.WithCallEndpoint(context.Background())
+//		result, err := client.GetData(ctx, "123", dataId)
+//		fmt.Println("Served by " + endpoint.Uri())
+func WithCallEndpoint(ctx context.Context) (context.Context, *CallEndpoint) {
+	endpoint := &CallEndpoint{}
+	return context.WithValue(ctx, callEndpointKey{}, endpoint), endpoint
+}
+
+// Gets the uri of the endpoint that served the last call, or the endpoint of
+// the last attempt when the call failed.
+// Returns the endpoint uri, or empty string when no call was made.
+func (c *CallEndpoint) Uri() string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.uri
+}
+
+// Gets the number of times the last call failed over to the next endpoint.
+// Returns the number of failovers.
+func (c *CallEndpoint) Failovers() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.failovers
+}
+
+func (c *CallEndpoint) record(uri string, failovers int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.uri = uri
+	c.failovers = failovers
+}
+
+func callEndpointFromContext(ctx context.Context) (*CallEndpoint, bool) {
+	endpoint, ok := ctx.Value(callEndpointKey{}).(*CallEndpoint)
+	return endpoint, ok
+}
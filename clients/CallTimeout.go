@@ -0,0 +1,48 @@
+package clients
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+type callTimeoutKey struct{}
+
+// WithCallTimeout returns a copy of the context that overrides the invocation timeout
+// of client calls made with it. The timeout applies to each attempt of the call,
+// while the context deadline, if any, limits the call with all its retries.
+// Parameters:
+//		- ctx		a parent context.
+//		- timeout	an invocation timeout, 0 to disable the timeout.
+// Returns the context with the timeout override.
+//
+//	Example:
+//		ctx := clients.WithCallTimeout(context.Background(), 30*time.Second)
+//		result, err := client.GetReport(ctx, "123", reportId)
+func WithCallTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, callTimeoutKey{}, timeout)
+}
+
+// CallTimeoutFromContext gets the invocation timeout override set by WithCallTimeout.
+// Parameters:
+//		- ctx	a context.
+// Returns the timeout and true, or 0 and false when the timeout is not overridden.
+func CallTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	timeout, ok := ctx.Value(callTimeoutKey{}).(time.Duration)
+	return timeout, ok
+}
+
+// Response body that releases the context of the call attempt when it is closed,
+// as the body can be read only while the context is alive.
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (c *cancelOnCloseBody) Close() error {
+	err := c.ReadCloser.Close()
+	c.once.Do(c.cancel)
+	return err
+}
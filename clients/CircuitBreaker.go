@@ -0,0 +1,249 @@
+package clients
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+)
+
+// States of circuit breakers
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// Number of buckets in the rolling window of call outcomes
+const circuitWindowBuckets = 10
+
+// CircuitBreaker stops calls to a failing Google Function to give it time to recover.
+//
+// The circuit is closed while the function works: calls pass and their outcomes are collected
+// in a rolling window. When the failure rate in the window reaches the threshold, and the window has
+// enough calls to judge, the circuit opens and calls are rejected without reaching the function.
+// After the cool-down, the circuit becomes half-open and lets a limited number of trial calls pass.
+// A successful trial call closes the circuit, and a failed one opens it again.
+//
+//	Configuration parameters:
+//		- options:
+//			- circuit_breaker:
+//				- enabled:			true to enable circuit breakers (default: false)
+//				- failure_rate:		failure rate from 0 to 1 that opens the circuit (default: 0.5)
+//				- min_calls:		minimum number of calls in the window to open the circuit (default: 10)
+//				- window:			rolling window of call outcomes in milliseconds (default: 60000)
+//				- cool_down:		time in milliseconds the circuit stays open (default: 30000)
+//				- half_open_calls:	number of trial calls in half-open state (default: 1)
+//
+// see CloudFunctionClient
+type CircuitBreaker struct {
+	// The failure rate from 0 to 1 that opens the circuit.
+	FailureRate float64
+	// The minimum number of calls in the window to open the circuit.
+	MinCalls int
+	// The rolling window of call outcomes in milliseconds.
+	Window int
+	// The time in milliseconds the circuit stays open.
+	CoolDown int
+	// The number of trial calls in half-open state.
+	HalfOpenCalls int
+
+	// Called on state transitions
+	onStateChange func(ctx context.Context, from string, to string)
+
+	state    string
+	openedAt time.Time
+	trials   int
+	buckets  [circuitWindowBuckets]circuitBucket
+	mtx      sync.Mutex
+}
+
+type circuitBucket struct {
+	start    time.Time
+	calls    int
+	failures int
+}
+
+// Creates a new instance of the circuit breaker in closed state.
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureRate:   0.5,
+		MinCalls:      10,
+		Window:        60000,
+		CoolDown:      30000,
+		HalfOpenCalls: 1,
+		state:         CircuitClosed,
+	}
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config ConfigParams configuration parameters to be set.
+func (c *CircuitBreaker) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.FailureRate = config.GetAsDoubleWithDefault("options.circuit_breaker.failure_rate", c.FailureRate)
+	c.MinCalls = config.GetAsIntegerWithDefault("options.circuit_breaker.min_calls", c.MinCalls)
+	c.Window = config.GetAsIntegerWithDefault("options.circuit_breaker.window", c.Window)
+	c.CoolDown = config.GetAsIntegerWithDefault("options.circuit_breaker.cool_down", c.CoolDown)
+	c.HalfOpenCalls = config.GetAsIntegerWithDefault("options.circuit_breaker.half_open_calls", c.HalfOpenCalls)
+}
+
+// Gets the current state of the circuit.
+// Returns CircuitClosed, CircuitOpen or CircuitHalfOpen
+func (c *CircuitBreaker) State() string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.state == CircuitOpen && c.coolDownElapsed() {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// Gets time left until the open circuit lets trial calls pass.
+// Returns the time left, or 0 when the circuit is not open.
+func (c *CircuitBreaker) RetryAfter() time.Duration {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.state != CircuitOpen {
+		return 0
+	}
+	left := time.Until(c.openedAt.Add(time.Duration(c.CoolDown) * time.Millisecond))
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// Checks if a call can pass. Calls that passed shall be completed by
+// RecordSuccess, RecordFailure or Cancel.
+//	Parameters:
+//		- ctx context.Context
+// Returns true if the call can pass, or false when the circuit is open.
+func (c *CircuitBreaker) Allow(ctx context.Context) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.state == CircuitOpen {
+		if !c.coolDownElapsed() {
+			return false
+		}
+		c.setState(ctx, CircuitHalfOpen)
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.trials >= c.HalfOpenCalls {
+			return false
+		}
+		c.trials++
+	}
+
+	return true
+}
+
+// Records a successful call.
+//	Parameters:
+//		- ctx context.Context
+func (c *CircuitBreaker) RecordSuccess(ctx context.Context) {
+	c.record(ctx, false)
+}
+
+// Records a failed call.
+//	Parameters:
+//		- ctx context.Context
+func (c *CircuitBreaker) RecordFailure(ctx context.Context) {
+	c.record(ctx, true)
+}
+
+// Completes a call without outcome, e.g. when it was canceled by the caller.
+func (c *CircuitBreaker) Cancel() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.state == CircuitHalfOpen && c.trials > 0 {
+		c.trials--
+	}
+}
+
+func (c *CircuitBreaker) record(ctx context.Context, failed bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	switch c.state {
+	case CircuitHalfOpen:
+		if c.trials > 0 {
+			c.trials--
+		}
+		if failed {
+			c.open(ctx)
+		} else {
+			c.close(ctx)
+		}
+	case CircuitClosed:
+		bucket := c.currentBucket()
+		bucket.calls++
+		if failed {
+			bucket.failures++
+		}
+
+		calls, failures := c.countCalls()
+		if failed && calls >= c.MinCalls && float64(failures) >= c.FailureRate*float64(calls) {
+			c.open(ctx)
+		}
+	}
+}
+
+func (c *CircuitBreaker) open(ctx context.Context) {
+	c.openedAt = time.Now()
+	c.trials = 0
+	c.setState(ctx, CircuitOpen)
+}
+
+func (c *CircuitBreaker) close(ctx context.Context) {
+	c.trials = 0
+	c.buckets = [circuitWindowBuckets]circuitBucket{}
+	c.setState(ctx, CircuitClosed)
+}
+
+func (c *CircuitBreaker) setState(ctx context.Context, state string) {
+	from := c.state
+	c.state = state
+	if from != state && c.onStateChange != nil {
+		c.onStateChange(ctx, from, state)
+	}
+}
+
+func (c *CircuitBreaker) coolDownElapsed() bool {
+	return time.Since(c.openedAt) >= time.Duration(c.CoolDown)*time.Millisecond
+}
+
+func (c *CircuitBreaker) bucketDuration() time.Duration {
+	duration := time.Duration(c.Window) * time.Millisecond / circuitWindowBuckets
+	if duration <= 0 {
+		duration = time.Millisecond
+	}
+	return duration
+}
+
+func (c *CircuitBreaker) currentBucket() *circuitBucket {
+	duration := c.bucketDuration()
+	start := time.Now().Truncate(duration)
+	bucket := &c.buckets[(start.UnixNano()/int64(duration))%circuitWindowBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = circuitBucket{start: start}
+	}
+	return bucket
+}
+
+func (c *CircuitBreaker) countCalls() (calls int, failures int) {
+	since := time.Now().Add(-time.Duration(c.Window) * time.Millisecond)
+	for _, bucket := range c.buckets {
+		if bucket.start.After(since) {
+			calls += bucket.calls
+			failures += bucket.failures
+		}
+	}
+	return calls, failures
+}
@@ -2,11 +2,16 @@ package clients
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
-	"math"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
@@ -17,7 +22,9 @@ import (
 	ccount "github.com/pip-services3-gox/pip-services3-components-gox/count"
 	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
 	ctrace "github.com/pip-services3-gox/pip-services3-components-gox/trace"
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
 	gcpconn "github.com/pip-services3-gox/pip-services3-gcp-gox/connect"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
 	rpcsrv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
 )
 
@@ -34,13 +41,67 @@ import (
 //			- region:        is the region where your function is deployed
 //			- function:      is the name of the HTTP function you deployed
 //			- org_id:        organization name
+//			- project_number: your project number to call 2nd gen functions and Cloud Run services
+//			- service:       the Cloud Run service of 2nd gen function (default: the function name)
 //		- options:
-//			- retries:               number of retries (default: 3)
+//			- retries:               number of attempts (default: 3)
 //			- connect_timeout:       connection timeout in milliseconds (default: 10 sec)
-//			- timeout:               invocation timeout in milliseconds (default: 10 sec)
+//			- timeout:               invocation timeout of each attempt in milliseconds, 0 to disable (default: 10 sec)
+//			- call_timeout:          time limit of a call with all its attempts and failovers in milliseconds, 0 to disable (default: 30 sec)
+//			- tls_handshake_timeout: TLS handshake timeout in milliseconds (default: connect_timeout)
+//			- keep_alive:            keep-alive period of connections in milliseconds (default: 30 sec)
+//			- idle_timeout:          time to keep idle connections in the pool in milliseconds (default: 90 sec)
+//			- max_idle_conns:        maximum number of idle connections in the pool (default: 100)
+//			- max_idle_conns_per_host: maximum number of idle connections per host (default: 10)
+//			- compression:
+//				- enabled:           true to compress request bodies with gzip (default: false)
+//				- threshold:         minimum size of request bodies in bytes to compress them (default: 1024)
+//			- retry:                 retry policy settings, see RetryPolicy
+//			- circuit_breaker:       circuit breaker settings, see CircuitBreaker
+//			- load_balancing:
+//				- strategy:          round_robin, lowest_latency or failover to pick endpoints (default: failover)
+//				- failover:          true to fail over to the next endpoint on connection errors and 5xx responses (default: true)
 //		- credentials:
 //			- account: the service account name
 //			- auth_token:    Google-generated ID token or null if using custom auth (IAM)
+//			- key_file:      path to the service account JSON key file to generate ID tokens
+//			- key:           service account JSON key to generate ID tokens
+//			- store_key:     key to read credentials from credential stores, e.g. SecretManagerCredentialStore
+//			- use_metadata:  true to obtain ID tokens from the metadata server (default: false)
+//			- audience:      audience of generated ID tokens (default: the function uri)
+//
+// Every attempt of a call is limited by the invocation timeout, which can be overridden
+// for a single call by WithCallTimeout. The call with all its attempts and failovers is limited
+// by the call timeout, which is extended to the invocation timeout when it is shorter, and by the context
+// deadline, so calls are not retried after the deadline and canceled calls are stopped immediately.
+//
+// Compressed request bodies are supported by functions of this library. Responses compressed
+// by functions are decompressed transparently.
+//
+// Every call carries "correlation_id" header and is traced in a client span sent in "traceparent"
+// and "X-Cloud-Trace-Context" headers. When the context carries trace context of the current request,
+// the span continues its trace.
+//
+// Failed calls are retried by the retry policy. By default, calls that fail to reach the function
+// or get 429, 502, 503 or 504 responses are retried with exponential backoff and jitter,
+// honoring "Retry-After" headers. Retries are counted by "<cmd>.call_retries" counters.
+//
+// When circuit breakers are enabled, calls to a failing function uri are rejected with
+// CIRCUIT_OPEN error and 503 status code until the function recovers. State transitions
+// are logged and counted by "circuit_breaker.<state>" counters.
+//
+// When several connections are configured, e.g. for the same function deployed to several regions,
+// every attempt of a call starts from the endpoint picked by the load balancing strategy: endpoints
+// take turns with "round_robin", the fastest one is used with "lowest_latency", and the first configured
+// one is used while it works with "failover". Calls of idempotent commands that fail to reach the endpoint
+// or get 5xx responses fail over to the next endpoint. Other commands could be executed twice, so they fail over
+// only when the connection to the endpoint fails or it responds with 502, 503 or 504. Commands are idempotent
+// when they match "options.retry.idempotent_commands" patterns of RetryPolicy. Endpoints with open circuits
+// are skipped. Failovers are logged and
+// counted by "<cmd>.call_failovers" counters, and the endpoint that served a call is reported by WithCallEndpoint.
+//
+// When credentials are configured, every call carries "Authorization: Bearer <ID token>" header.
+// Generated tokens are cached and refreshed before they expire.
 //
 //	References
 //		- *:logger:*:*:1.0				(optional) ILogger components to pass log messages
@@ -61,13 +122,9 @@ import (
 //			}
 //		}
 //
-//		func (c *MyCloudFunctionClient) GetData(ctx context.Context, correlationId string, id string) MyData {
-//			timing := c.Instrument(ctx, correlationId, "myclient.get_data")
-//
-//			response, err := c.Call(ctx, "get_data", correlationId, data.NewAnyValueMapFromTuples("id", dummyId))
-//
-//			defer timing.EndTiming(ctx, err)
-//			return rpcclients.HandleHttpResponse[MyData](response, correlationId)
+//		func (c *MyCloudFunctionClient) GetData(ctx context.Context, correlationId string, id string) (MyData, error) {
+//			return clients.CallTyped[MyData](ctx, &c.CloudFunctionClient, "get_data", correlationId,
+//				data.NewAnyValueMapFromTuples("id", id))
 //		}
 //
 //		...
@@ -83,25 +140,57 @@ import (
 //		))
 //		result := client.GetData("123", "1")
 //
+//		client.Configure(config.NewConfigParamsFromTuples(
+//			"connections.0.uri", "https://us-central1-id.cloudfunctions.net/myfunction",
+//			"connections.1.uri", "https://europe-west1-id.cloudfunctions.net/myfunction",
+//			"options.load_balancing.strategy", "lowest_latency",
+//		))
+//
 type CloudFunctionClient struct {
 	// The HTTP client.
 	Client *http.Client
 	// The Google Function connection parameters
 	Connection *gcpconn.GcpConnectionParams
-	// The number of retries.
+	// The maximum number of attempts of a call, which limits attempts allowed by RetryPolicy.
 	Retries int
+	// The policy to retry failed calls. Configurable policies are configured by Configure.
+	RetryPolicy IRetryPolicy
 	// The default headers to be added to every request.
 	Headers *cdata.StringValueMap
 	// The connection timeout in milliseconds.
 	ConnectTimeout int
-	// The invocation timeout in milliseconds.
+	// The invocation timeout of each attempt in milliseconds.
 	Timeout int
-	// The remote service uri which is calculated on open.
+	// The time limit of a call with all its attempts and failovers in milliseconds.
+	CallTimeout int
+	// The TLS handshake timeout in milliseconds.
+	TlsHandshakeTimeout int
+	// The keep-alive period of connections in milliseconds.
+	KeepAlive int
+	// The time to keep idle connections in the pool in milliseconds.
+	IdleTimeout int
+	// The maximum number of idle connections in the pool.
+	MaxIdleConns int
+	// The maximum number of idle connections per host.
+	MaxIdleConnsPerHost int
+	// True to compress request bodies with gzip.
+	CompressRequests bool
+	// The minimum size of request bodies in bytes to compress them.
+	CompressionThreshold int
+	// The strategy to pick endpoints: LoadBalancingRoundRobin, LoadBalancingLowestLatency or LoadBalancingFailover.
+	LoadBalancing string
+	// True to fail over to the next endpoint on connection errors and 5xx responses.
+	Failover bool
+	// The remote service uri which is calculated on open. It is the primary uri when several connections are configured.
 	Uri string
+	// The uris of all endpoints in the configured order which are calculated on open.
+	Uris []string
 	// The connection resolver.
 	ConnectionResolver *gcpconn.GcpConnectionResolver
 	// The dependency resolver.
 	DependencyResolver *crefer.DependencyResolver
+	// The source of ID tokens to authenticate calls. It is created from credentials on open when not set.
+	TokenSource gcpauth.IIdTokenSource
 
 	// The logger.
 	Logger *clog.CompositeLogger
@@ -109,12 +198,25 @@ type CloudFunctionClient struct {
 	Counters *ccount.CompositeCounters
 	// The tracer.
 	Tracer *ctrace.CompositeTracer
+
+	balancer       *endpointBalancer
+	ownTokenSource bool
+
+	circuitEnabled  bool
+	circuitConfig   *cconf.ConfigParams
+	circuitBreakers map[string]*CircuitBreaker
+	circuitMtx      sync.Mutex
 }
 
 const (
-	DefaultConnectTimeout = 10000
-	DefaultTimeout        = 10000
-	DefaultRetriesCount   = 3
+	DefaultConnectTimeout      = 10000
+	DefaultTimeout             = 10000
+	DefaultCallTimeout         = 30000
+	DefaultRetriesCount        = 3
+	DefaultKeepAlive           = 30000
+	DefaultIdleTimeout         = 90000
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 10
 )
 
 // Creates new instance of CloudFunctionClient
@@ -127,6 +229,10 @@ func NewCloudFunctionClient() *CloudFunctionClient {
 	c.Counters = ccount.NewCompositeCounters()
 	c.Tracer = ctrace.NewCompositeTracer()
 	c.Headers = cdata.NewEmptyStringValueMap()
+	c.Retries = DefaultRetriesCount
+	c.RetryPolicy = NewRetryPolicy()
+	c.circuitConfig = cconf.NewEmptyConfigParams()
+	c.circuitBreakers = make(map[string]*CircuitBreaker)
 
 	return &c
 }
@@ -140,8 +246,60 @@ func (c *CloudFunctionClient) Configure(ctx context.Context, config *cconf.Confi
 	c.DependencyResolver.Configure(ctx, config)
 
 	c.Retries = config.GetAsIntegerWithDefault("options.retries", DefaultRetriesCount)
+	c.Retries = config.GetAsIntegerWithDefault("options.retry.max_attempts", c.Retries)
+	// "connectTimeout" is kept for compatibility with older configurations
 	c.ConnectTimeout = config.GetAsIntegerWithDefault("options.connectTimeout", DefaultConnectTimeout)
+	c.ConnectTimeout = config.GetAsIntegerWithDefault("options.connect_timeout", c.ConnectTimeout)
 	c.Timeout = config.GetAsIntegerWithDefault("options.timeout", DefaultTimeout)
+	c.CallTimeout = config.GetAsIntegerWithDefault("options.call_timeout", DefaultCallTimeout)
+	c.TlsHandshakeTimeout = config.GetAsIntegerWithDefault("options.tls_handshake_timeout", c.ConnectTimeout)
+	c.KeepAlive = config.GetAsIntegerWithDefault("options.keep_alive", DefaultKeepAlive)
+	c.IdleTimeout = config.GetAsIntegerWithDefault("options.idle_timeout", DefaultIdleTimeout)
+	c.MaxIdleConns = config.GetAsIntegerWithDefault("options.max_idle_conns", DefaultMaxIdleConns)
+	c.MaxIdleConnsPerHost = config.GetAsIntegerWithDefault("options.max_idle_conns_per_host", DefaultMaxIdleConnsPerHost)
+	c.CompressRequests = config.GetAsBooleanWithDefault("options.compression.enabled", false)
+	c.CompressionThreshold = config.GetAsIntegerWithDefault("options.compression.threshold", gcputil.DefaultCompressionThreshold)
+	c.LoadBalancing = config.GetAsStringWithDefault("options.load_balancing.strategy", LoadBalancingFailover)
+	c.Failover = config.GetAsBooleanWithDefault("options.load_balancing.failover", true)
+
+	if configurable, ok := c.RetryPolicy.(cconf.IConfigurable); ok {
+		configurable.Configure(ctx, config)
+	}
+
+	c.circuitMtx.Lock()
+	c.circuitEnabled = config.GetAsBooleanWithDefault("options.circuit_breaker.enabled", c.circuitEnabled)
+	c.circuitConfig = config
+	c.circuitBreakers = make(map[string]*CircuitBreaker)
+	c.circuitMtx.Unlock()
+}
+
+// Gets the circuit breaker of calls to the uri.
+//	Parameters:
+//		- uri string a function uri.
+//	Returns: the circuit breaker or nil when circuit breakers are disabled.
+func (c *CloudFunctionClient) GetCircuitBreaker(uri string) *CircuitBreaker {
+	c.circuitMtx.Lock()
+	defer c.circuitMtx.Unlock()
+
+	if !c.circuitEnabled {
+		return nil
+	}
+
+	circuit, ok := c.circuitBreakers[uri]
+	if !ok {
+		circuit = NewCircuitBreaker()
+		circuit.Configure(context.Background(), c.circuitConfig)
+		circuit.onStateChange = func(ctx context.Context, from string, to string) {
+			c.Counters.IncrementOne(ctx, "circuit_breaker."+to)
+			if to == CircuitOpen {
+				c.Logger.Warn(ctx, "", "Circuit breaker for %s changed from %s to %s", uri, from, to)
+			} else {
+				c.Logger.Info(ctx, "", "Circuit breaker for %s changed from %s to %s", uri, from, to)
+			}
+		}
+		c.circuitBreakers[uri] = circuit
+	}
+	return circuit
 }
 
 // SetReferences sets references to dependent components.
@@ -152,6 +310,7 @@ func (c *CloudFunctionClient) Configure(ctx context.Context, config *cconf.Confi
 func (c *CloudFunctionClient) SetReferences(ctx context.Context, references crefer.IReferences) {
 	c.Logger.SetReferences(ctx, references)
 	c.Counters.SetReferences(ctx, references)
+	c.Tracer.SetReferences(ctx, references)
 	c.ConnectionResolver.SetReferences(ctx, references)
 	c.DependencyResolver.SetReferences(ctx, references)
 }
@@ -164,11 +323,20 @@ func (c *CloudFunctionClient) SetReferences(ctx context.Context, references cref
 //		- name string a method name.
 //	Returns: services.InstrumentTiming object to end the time measurement.
 func (c *CloudFunctionClient) Instrument(ctx context.Context, correlationId string, name string) *rpcsrv.InstrumentTiming {
+	_, timing := c.instrument(ctx, correlationId, name)
+	return timing
+}
+
+// Instruments the method like Instrument does and returns the context of the method span,
+// so calls made with the context are traced as child spans of the method.
+func (c *CloudFunctionClient) instrument(ctx context.Context, correlationId string, name string) (context.Context, *rpcsrv.InstrumentTiming) {
 	c.Logger.Trace(ctx, correlationId, "Calling %s method", name)
 	c.Counters.IncrementOne(ctx, name+".call_count")
 	counterTiming := c.Counters.BeginTiming(ctx, name+".call_time")
-	traceTiming := c.Tracer.BeginTrace(ctx, correlationId, name, "")
-	return rpcsrv.NewInstrumentTiming(correlationId, name, "call",
+	// Trace the method in its own span, client spans are started by Call
+	spanCtx := gcputil.CloudFunctionRequestHelper.StartSpan(ctx, gcputil.SpanKindInternal)
+	traceTiming := ctrace.NewTraceTiming(correlationId, name, "", newSpanTracer(spanCtx, c.Tracer))
+	return spanCtx, rpcsrv.NewInstrumentTiming(correlationId, name, "call",
 		c.Logger, c.Counters, counterTiming, traceTiming)
 }
 
@@ -188,15 +356,49 @@ func (c *CloudFunctionClient) Open(ctx context.Context, correlationId string) er
 		return nil
 	}
 
-	connection, err := c.ConnectionResolver.Resolve(correlationId)
+	connections, err := c.ConnectionResolver.ResolveAll(correlationId)
 	if err != nil {
 		return err
 	}
 
-	c.Uri, _ = connection.Uri()
+	switch c.LoadBalancing {
+	case "", LoadBalancingRoundRobin, LoadBalancingLowestLatency, LoadBalancingFailover:
+	default:
+		return cerr.NewConfigError(
+			correlationId,
+			"WRONG_LOAD_BALANCING",
+			"Load balancing strategy "+c.LoadBalancing+" is not supported",
+		).WithDetails("strategy", c.LoadBalancing)
+	}
+
+	// Tokens of each endpoint are generated for its own audience unless the token source is set
+	ownTokenSource := c.TokenSource == nil
+	endpoints := make([]*cloudFunctionEndpoint, 0, len(connections))
+	uris := make([]string, 0, len(connections))
+	for _, connection := range connections {
+		uri, _ := connection.Uri()
+		tokenSource := c.TokenSource
+		if ownTokenSource {
+			tokenSource, err = gcpauth.NewIdTokenSourceFromConnection(connection, uri)
+			if err != nil {
+				return err
+			}
+		}
+		endpoints = append(endpoints, &cloudFunctionEndpoint{uri: uri, tokenSource: tokenSource})
+		uris = append(uris, uri)
+	}
+
+	c.Uri = uris[0]
+	c.Uris = uris
+	c.balancer = newEndpointBalancer(c.LoadBalancing, endpoints)
+	if ownTokenSource {
+		c.TokenSource = endpoints[0].tokenSource
+		c.ownTokenSource = true
+	}
+
+	// Calls are limited by their contexts, so the client has no overall timeout
 	c.Client = &http.Client{
-		// Timeout includes connection time, any redirects, and reading the response body
-		Timeout: time.Duration(c.Timeout+c.ConnectTimeout) * time.Millisecond,
+		Transport: c.newTransport(),
 	}
 
 	if c.Client == nil {
@@ -207,7 +409,7 @@ func (c *CloudFunctionClient) Open(ctx context.Context, correlationId string) er
 		).WithDetails("url", c.Uri)
 	}
 
-	c.Logger.Debug(ctx, correlationId, "Google function client connected to %s", c.Uri)
+	c.Logger.Debug(ctx, correlationId, "Google function client connected to %s", strings.Join(c.Uris, ", "))
 
 	return nil
 }
@@ -217,9 +419,16 @@ func (c *CloudFunctionClient) Open(ctx context.Context, correlationId string) er
 //		-correlationId	(optional) transaction id to trace execution through call chain.
 func (c *CloudFunctionClient) Close(ctx context.Context, correlationId string) error {
 	if c.Client != nil {
+		c.Client.CloseIdleConnections()
 		c.Logger.Debug(ctx, correlationId, "Closed Google function service at %s", c.Uri)
 		c.Client = nil
 		c.Uri = ""
+		c.Uris = nil
+		c.balancer = nil
+		if c.ownTokenSource {
+			c.TokenSource = nil
+			c.ownTokenSource = false
+		}
 	}
 	return nil
 }
@@ -241,8 +450,29 @@ func (c *CloudFunctionClient) Call(ctx context.Context, cmd string, correlationI
 	}
 
 	if correlationId == "" {
-		correlationId = cdata.IdGenerator.NextShort()
+		// Continue correlation of the current trace
+		if trace, ok := gcputil.TraceContextFromContext(ctx); ok {
+			correlationId = trace.TraceId
+		} else {
+			correlationId = cdata.IdGenerator.NextShort()
+		}
+	}
+
+	// Start a client span of the call
+	ctx = gcputil.CloudFunctionRequestHelper.StartSpan(ctx, gcputil.SpanKindClient)
+	traceTiming := c.Tracer.BeginTrace(ctx, correlationId, cmd, "")
+
+	response, err := c.call(ctx, cmd, correlationId, args)
+	if err != nil {
+		traceTiming.EndFailure(ctx, err)
+	} else {
+		traceTiming.EndTrace(ctx)
 	}
+	return response, err
+}
+
+func (c *CloudFunctionClient) call(ctx context.Context, cmd string, correlationId string,
+	args *cdata.AnyValueMap) (*http.Response, error) {
 
 	if args == nil {
 		args = cdata.NewEmptyAnyValueMap()
@@ -255,34 +485,157 @@ func (c *CloudFunctionClient) Call(ctx context.Context, cmd string, correlationI
 		jsonStr, _ = convert.JsonConverter.ToJson(args.Value())
 	}
 
-	retries := c.Retries
+	body, encoding := c.encodeBody([]byte(jsonStr))
+
+	timeout := time.Duration(c.Timeout) * time.Millisecond
+	if value, ok := CallTimeoutFromContext(ctx); ok {
+		timeout = value
+	}
+
+	callCtx, cancel := c.newCallContext(ctx, timeout)
+
+	// Deadline of the call timeout when it expires before the parent context
+	deadline, ok := callCtx.Deadline()
+	if parentDeadline, parentOk := ctx.Deadline(); !ok || (parentOk && !parentDeadline.After(deadline)) {
+		deadline = time.Time{}
+	}
+
+	response, err := c.invoke(callCtx, cmd, correlationId, body, encoding, timeout, deadline)
+	if response == nil {
+		cancel()
+		return nil, err
+	}
+	// Keep the call context until the response body is read
+	response.Body = &cancelOnCloseBody{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+// Invokes the function by trying endpoints and retrying failed attempts within the call context.
+// Retries are not waited for when they cannot start before the call timeout deadline, if it is set.
+func (c *CloudFunctionClient) invoke(ctx context.Context, cmd string, correlationId string,
+	body []byte, encoding string, timeout time.Duration, deadline time.Time) (*http.Response, error) {
+
 	var response *http.Response
+	failovers := 0
+	idempotent := c.isIdempotent(cmd)
+
+	for attempt := 1; ; attempt++ {
+		var err error
+		var served *cloudFunctionEndpoint
+		var rejected *CircuitBreaker
+		var rejectedUri string
+
+		// Try endpoints in the order picked by the strategy until one of them serves the call
+		for _, endpoint := range c.balancer.order() {
+			attemptCtx, cancel := c.newAttemptContext(ctx, timeout)
+
+			req, reqErr := c.prepareRequest(attemptCtx, correlationId, http.MethodPost, endpoint.uri, endpoint.tokenSource, body)
+			if reqErr != nil {
+				cancel()
+				discardResponse(response)
+				return nil, reqErr
+			}
+			if encoding != "" {
+				req.Header.Set("Content-Encoding", encoding)
+			}
 
-	for retries > 0 {
-		req, err := c.prepareRequest(ctx, correlationId, http.MethodPost, c.Uri, []byte(jsonStr))
-		if err != nil {
-			return nil, err
-		}
+			circuit := c.GetCircuitBreaker(endpoint.uri)
+			if circuit != nil && !circuit.Allow(ctx) {
+				cancel()
+				if rejected == nil {
+					rejected, rejectedUri = circuit, endpoint.uri
+				}
+				continue
+			}
 
-		response, err = c.Client.Do(req)
-		if err != nil {
-			retries--
-			if retries == 0 {
-				return nil, cerr.NewUnknownError(
-					correlationId,
-					"COMMUNICATION_ERROR",
-					"Unknown communication problem on GCP client",
-				).
-					WithCause(err)
+			if served != nil {
+				failovers++
+				discardResponse(response)
+				c.Counters.IncrementOne(ctx, cmd+".call_failovers")
+				c.Logger.Warn(ctx, correlationId, "Failing over %s call from %s to %s", cmd, served.uri, endpoint.uri)
 			}
+			served = endpoint
+
+			start := time.Now()
+			response, err = c.Client.Do(req)
+			if err != nil {
+				response = nil
+				cancel()
+			} else {
+				// Keep the attempt context until the response body is read
+				response.Body = &cancelOnCloseBody{ReadCloser: response.Body, cancel: cancel}
+			}
+
+			if circuit != nil {
+				switch {
+				case ctx.Err() != nil:
+					circuit.Cancel()
+				case err != nil || response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests:
+					circuit.RecordFailure(ctx)
+				default:
+					circuit.RecordSuccess(ctx)
+				}
+			}
+
+			failed := err != nil || response.StatusCode >= 500
+			canFailover := failed && c.Failover && (idempotent || isUnavailable(response, err))
+			if ctx.Err() == nil {
+				if failed {
+					c.balancer.recordFailure(endpoint)
+				} else {
+					c.balancer.recordSuccess(endpoint, time.Since(start))
+				}
+			}
+
+			if !canFailover || ctx.Err() != nil {
+				break
+			}
+		}
+
+		if served == nil {
+			return nil, cerr.NewConnectionError(
+				correlationId,
+				"CIRCUIT_OPEN",
+				"Circuit breaker is open for "+rejectedUri,
+			).
+				WithStatus(http.StatusServiceUnavailable).
+				WithDetails("uri", rejectedUri).
+				WithDetails("retry_after", rejected.RetryAfter().Milliseconds())
+		}
+
+		if recorder, ok := callEndpointFromContext(ctx); ok {
+			recorder.record(served.uri, failovers)
+		}
+
+		if err == nil && response.StatusCode < 400 {
+			break
+		}
+
+		delay, retry := time.Duration(0), false
+		if c.RetryPolicy != nil && ctx.Err() == nil && attempt < c.Retries {
+			delay, retry = c.RetryPolicy.GetRetryDelay(attempt, cmd, response, err)
+		}
+		if retry && !deadline.IsZero() && time.Until(deadline) <= delay {
+			retry = false
+		}
 
-			err = c.waitForRetry(ctx, correlationId, retries)
+		if !retry {
 			if err != nil {
-				return nil, err
+				return nil, c.handleTransportError(ctx, err, cmd, correlationId)
 			}
-			continue
+			break
+		}
+
+		discardResponse(response)
+		response = nil
+
+		c.Counters.IncrementOne(ctx, cmd+".call_retries")
+		c.Logger.Debug(ctx, correlationId, "Retrying %s call in %d ms after attempt %d", cmd, delay.Milliseconds(), attempt)
+
+		err = c.waitForRetry(ctx, correlationId, delay)
+		if err != nil {
+			return nil, err
 		}
-		break
 	}
 
 	if response.StatusCode == 204 {
@@ -298,6 +651,47 @@ func (c *CloudFunctionClient) Call(ctx context.Context, cmd string, correlationI
 	return response, nil
 }
 
+// Calls a batch of remote actions in a single Google Function invocation.
+// The calls are executed by the function in order, or in parallel when the batch requests it.
+// Failures of some calls do not fail the whole batch, they are returned in the call results.
+// Parameters:
+//		- ctx			context.Context
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+//		- batch			a batch of calls.
+// Returns results of the calls in the same order as the calls, or error when the batch failed.
+//
+//	Example:
+//		results, err := client.CallBatch(ctx, "123", &gcputil.BatchRequest{
+//			Parallel: true,
+//			Batch: []*gcputil.BatchItem{
+//				{Cmd: "dummies.get_dummy_by_id", Args: map[string]any{"dummy_id": "1"}},
+//				{Cmd: "dummies.get_dummy_by_id", Args: map[string]any{"dummy_id": "2"}},
+//			},
+//		})
+//
+//		var dummy Dummy
+//		err = results[0].DecodeResult(&dummy)
+func (c *CloudFunctionClient) CallBatch(ctx context.Context, correlationId string,
+	batch *gcputil.BatchRequest) ([]*gcputil.BatchItemResult, error) {
+	if batch == nil {
+		batch = &gcputil.BatchRequest{}
+	}
+
+	args := cdata.NewAnyValueMapFromTuples(
+		"batch", batch.Batch,
+		"parallel", batch.Parallel,
+	)
+
+	results, err := CallTyped[[]*gcputil.BatchItemResult](ctx, c, gcputil.BatchCmd, correlationId, args)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = make([]*gcputil.BatchItemResult, 0)
+	}
+	return results, nil
+}
+
 // AddFilterParams method are adds filter parameters (with the same name as they defined)
 // to invocation parameter map.
 //	Parameters:
@@ -340,11 +734,12 @@ func (c *CloudFunctionClient) AddPagingParams(params *cdata.StringValueMap, pagi
 	return params
 }
 
-func (c *CloudFunctionClient) waitForRetry(ctx context.Context, correlationId string, retries int) error {
-	waitTime := c.Timeout * int(math.Pow(float64(c.Retries-retries), 2))
+func (c *CloudFunctionClient) waitForRetry(ctx context.Context, correlationId string, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
 
 	select {
-	case <-time.After(time.Duration(waitTime) * time.Millisecond):
+	case <-timer.C:
 		return nil
 	case <-ctx.Done():
 		return cerr.ApplicationErrorFactory.Create(
@@ -359,10 +754,131 @@ func (c *CloudFunctionClient) waitForRetry(ctx context.Context, correlationId st
 	}
 }
 
+// Compresses the request body when it is large enough.
+// Returns the body and its content encoding, or empty encoding when the body is not compressed.
+func (c *CloudFunctionClient) encodeBody(body []byte) ([]byte, string) {
+	if !c.CompressRequests || len(body) < c.CompressionThreshold {
+		return body, ""
+	}
+
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(body); err != nil {
+		return body, ""
+	}
+	if err := writer.Close(); err != nil {
+		return body, ""
+	}
+	return buffer.Bytes(), "gzip"
+}
+
+func (c *CloudFunctionClient) newTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(c.ConnectTimeout) * time.Millisecond,
+		KeepAlive: time.Duration(c.KeepAlive) * time.Millisecond,
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   time.Duration(c.TlsHandshakeTimeout) * time.Millisecond,
+		IdleConnTimeout:       time.Duration(c.IdleTimeout) * time.Millisecond,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// Creates a context of a call with all its attempts limited by the call timeout, which is not shorter
+// than the invocation timeout, and the parent context deadline
+func (c *CloudFunctionClient) newCallContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	callTimeout := time.Duration(c.CallTimeout) * time.Millisecond
+	if timeout <= 0 || callTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	if callTimeout < timeout {
+		callTimeout = timeout
+	}
+	return context.WithTimeout(ctx, callTimeout)
+}
+
+// Creates a context of a call attempt limited by the invocation timeout and the parent context deadline
+func (c *CloudFunctionClient) newAttemptContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Checks if the command can be executed again without side effects by the idempotency patterns of the retry policy.
+// Commands are not idempotent when the policy does not define them.
+func (c *CloudFunctionClient) isIdempotent(cmd string) bool {
+	if policy, ok := c.RetryPolicy.(interface{ IsIdempotent(cmd string) bool }); ok {
+		return policy.IsIdempotent(cmd)
+	}
+	return false
+}
+
+// Checks if the endpoint did not execute the call, because the connection failed
+// or the endpoint responded that it is unavailable.
+func isUnavailable(response *http.Response, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		return errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	switch response.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Reads and closes the body of the response that is not returned, so the connection can be reused
+func discardResponse(response *http.Response) {
+	if response != nil {
+		_, _ = io.Copy(ioutil.Discard, response.Body)
+		_ = response.Body.Close()
+	}
+}
+
+func (c *CloudFunctionClient) handleTransportError(ctx context.Context, err error, cmd string, correlationId string) error {
+	if ctx.Err() == context.Canceled {
+		return cerr.ApplicationErrorFactory.Create(
+			&cerr.ErrorDescription{
+				Type:          "Application",
+				Category:      "Application",
+				Code:          "CONTEXT_CANCELLED",
+				Message:       "request canceled by parent context",
+				CorrelationId: correlationId,
+			},
+		).WithCause(err)
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return cerr.NewUnknownError(
+			correlationId,
+			"CALL_TIMEOUT",
+			"Call of "+cmd+" timed out",
+		).
+			WithStatus(http.StatusGatewayTimeout).
+			WithDetails("cmd", cmd).
+			WithCause(err)
+	}
+
+	return cerr.NewUnknownError(
+		correlationId,
+		"COMMUNICATION_ERROR",
+		"Unknown communication problem on GCP client",
+	).
+		WithCause(err)
+}
+
 func (c *CloudFunctionClient) prepareRequest(ctx context.Context, correlationId string,
-	method string, url string, body []byte) (*http.Request, error) {
+	method string, url string, tokenSource gcpauth.IIdTokenSource, body []byte) (*http.Request, error) {
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, cerr.NewUnknownError(
 			correlationId,
@@ -379,6 +895,18 @@ func (c *CloudFunctionClient) prepareRequest(ctx context.Context, correlationId
 		req.Header.Set(k, v)
 	}
 
+	// Propagate correlation id and trace context
+	gcputil.CloudFunctionRequestHelper.SetTraceHeaders(ctx, req, correlationId)
+
+	// Set authorization header
+	if tokenSource != nil {
+		token, err := tokenSource.GetIdToken(ctx, correlationId)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Value)
+	}
+
 	return req, nil
 }
 
@@ -0,0 +1,116 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Calls a remote action in Google Function with typed arguments and decodes its result.
+// The call is instrumented by the action name, and the timing ends on both success and error paths.
+// Go methods cannot have type parameters, so typed calls are made by functions.
+// Parameters:
+//		- ctx			context.Context
+//		- client		a client to call the action.
+//		- cmd			an action name.
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+//		- args			(optional) action arguments: *cdata.AnyValueMap, a map or a struct with JSON tags.
+// Returns the decoded result, or zero value of T when the action returns 204 status code or an empty body.
+//
+//	Example:
+//		func (c *MyCloudFunctionClient) GetData(ctx context.Context, correlationId string, id string) (MyData, error) {
+//			return clients.CallTyped[MyData](ctx, c.CloudFunctionClient, "get_data", correlationId,
+//				data.NewAnyValueMapFromTuples("id", id))
+//		}
+func CallTyped[T any](ctx context.Context, client *CloudFunctionClient, cmd string, correlationId string, args any) (T, error) {
+	return callTyped[T](ctx, client, cmd, cmd, correlationId, args)
+}
+
+// Calls a remote command in commandable Google Function with typed arguments and decodes its result.
+// The call is instrumented the same way as CallCommand does.
+// see CallTyped
+// Parameters:
+//		- ctx			context.Context
+//		- client		a client to call the command.
+//		- cmd			a command name.
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+//		- args			(optional) command arguments: *cdata.AnyValueMap, a map or a struct with JSON tags.
+// Returns the decoded result, or zero value of T when the command returns 204 status code or an empty body.
+func CallCommandTyped[T any](ctx context.Context, client *CommandableCloudFunctionClient, cmd string, correlationId string, args any) (T, error) {
+	return callTyped[T](ctx, client.CloudFunctionClient, client.name+"."+cmd, cmd, correlationId, args)
+}
+
+func callTyped[T any](ctx context.Context, client *CloudFunctionClient, name string, cmd string,
+	correlationId string, args any) (result T, err error) {
+	spanCtx, timing := client.instrument(ctx, correlationId, name)
+	defer func() {
+		timing.EndTiming(ctx, err)
+	}()
+
+	params, err := toAnyValueMap(correlationId, args)
+	if err != nil {
+		return result, err
+	}
+
+	response, err := client.Call(spanCtx, cmd, correlationId, params)
+	if err != nil || response == nil {
+		return result, err
+	}
+
+	return decodeTypedResponse[T](response, correlationId)
+}
+
+func toAnyValueMap(correlationId string, args any) (*cdata.AnyValueMap, error) {
+	switch value := args.(type) {
+	case nil:
+		return nil, nil
+	case *cdata.AnyValueMap:
+		return value, nil
+	case map[string]any:
+		return cdata.NewAnyValueMap(value), nil
+	}
+
+	// Structs are converted by their JSON tags
+	buffer, err := json.Marshal(args)
+	if err != nil {
+		return nil, cerr.NewBadRequestError(correlationId, "JSON_CNV_ERR", "Failed to convert arguments to JSON").
+			WithCause(err)
+	}
+
+	var values map[string]any
+	if err = json.Unmarshal(buffer, &values); err != nil {
+		return nil, cerr.NewBadRequestError(correlationId, "INVALID_ARGS", "Arguments must be a JSON object").
+			WithCause(err)
+	}
+	return cdata.NewAnyValueMap(values), nil
+}
+
+func decodeTypedResponse[T any](response *http.Response, correlationId string) (result T, err error) {
+	defer response.Body.Close()
+
+	buffer, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return result, cerr.NewUnknownError(correlationId, "READ_ERROR", "Failed to read response body").
+			WithDetails("status", response.StatusCode).
+			WithCause(err)
+	}
+
+	buffer = bytes.TrimSpace(buffer)
+	if len(buffer) == 0 || bytes.Equal(buffer, []byte("null")) {
+		return result, nil
+	}
+
+	result, err = cconv.NewDefaultCustomTypeJsonConvertor[T]().FromJson(string(buffer))
+	if err != nil {
+		return result, cerr.NewUnknownError(correlationId, "JSON_CNV_ERR", "Failed to decode response body").
+			WithDetails("status", response.StatusCode).
+			WithCause(err)
+	}
+	return result, nil
+}
@@ -20,10 +20,13 @@ import (
 //			- region:        is the region where your function is deployed
 //			- function:      is the name of the HTTP function you deployed
 //			- org_id:        organization name
+//			- project_number: your project number to call 2nd gen functions and Cloud Run services
+//			- service:       the Cloud Run service of 2nd gen function (default: the function name)
 //		- options:
 //			- retries:               number of retries (default: 3)
 //			- connect_timeout:       connection timeout in milliseconds (default: 10 sec)
 //			- timeout:               invocation timeout in milliseconds (default: 10 sec)
+//			- load_balancing:        strategy and failover between several connections, see CloudFunctionClient
 //		- credentials:
 //			- account: the service account name
 //			- auth_token:    Google-generated ID token or null if using custom auth (IAM)
@@ -47,13 +50,9 @@ import (
 //			}
 //		}
 //
-//		func (c *MyCommandableGoogleClient) GetData(ctx context.Context, correlationId string, id string) MyData {
-//			response, err := c.CallCommand(ctx, "dummies.get_dummies", correlationId, cdata.NewAnyValueMapFromTuples("id", id))
-//			if err != nil {
-//				return MyData{}, err
-//			}
-//
-//			return rpcclient.HandleHttpResponse[MyData](response, correlationId)
+//		func (c *MyCommandableGoogleClient) GetData(ctx context.Context, correlationId string, id string) (MyData, error) {
+//			return clients.CallCommandTyped[MyData](ctx, &c.CommandableCloudFunctionClient, "dummies.get_dummies", correlationId,
+//				map[string]any{"id": id})
 //		}
 //
 //		...
@@ -90,8 +89,8 @@ func NewCommandableCloudFunctionClient(name string) *CommandableCloudFunctionCli
 //		- params	command parameters.
 // Returns action result.
 func (c *CommandableCloudFunctionClient) CallCommand(ctx context.Context, cmd string, correlationId string, params *cdata.AnyValueMap) (*http.Response, error) {
-	timing := c.Instrument(ctx, correlationId, c.name+"."+cmd)
-	r, err := c.Call(ctx, cmd, correlationId, params)
+	spanCtx, timing := c.instrument(ctx, correlationId, c.name+"."+cmd)
+	r, err := c.Call(spanCtx, cmd, correlationId, params)
 	timing.EndTiming(ctx, err)
 	return r, err
 }
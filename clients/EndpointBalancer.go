@@ -0,0 +1,109 @@
+package clients
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+)
+
+// Strategies to pick endpoints when the function is deployed to several regions
+const (
+	// Endpoints take turns to serve calls
+	LoadBalancingRoundRobin = "round_robin"
+	// The endpoint with the lowest average latency serves calls
+	LoadBalancingLowestLatency = "lowest_latency"
+	// The first configured endpoint serves calls while it works, the next ones are secondaries
+	LoadBalancingFailover = "failover"
+)
+
+// Weight of the latest sample in the average latency of endpoints
+const latencySmoothing = 0.3
+
+// Time to try failed endpoints after the others by the lowest latency strategy
+const failurePenalty = 30 * time.Second
+
+// Endpoint of the function resolved from one of configured connections
+type cloudFunctionEndpoint struct {
+	uri         string
+	tokenSource gcpauth.IIdTokenSource
+	latency     time.Duration
+	measured    bool
+	failedAt    time.Time
+}
+
+// Orders endpoints to try for calls by the load balancing strategy
+// and keeps average latencies of the endpoints.
+type endpointBalancer struct {
+	strategy  string
+	endpoints []*cloudFunctionEndpoint
+	next      int
+	mtx       sync.Mutex
+}
+
+func newEndpointBalancer(strategy string, endpoints []*cloudFunctionEndpoint) *endpointBalancer {
+	return &endpointBalancer{
+		strategy:  strategy,
+		endpoints: endpoints,
+	}
+}
+
+// Gets the endpoints in order to try them for a call, starting from the one picked by the strategy
+func (c *endpointBalancer) order() []*cloudFunctionEndpoint {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	count := len(c.endpoints)
+	result := make([]*cloudFunctionEndpoint, 0, count)
+
+	switch c.strategy {
+	case LoadBalancingRoundRobin:
+		start := c.next
+		c.next = (c.next + 1) % count
+		for index := 0; index < count; index++ {
+			result = append(result, c.endpoints[(start+index)%count])
+		}
+	case LoadBalancingLowestLatency:
+		result = append(result, c.endpoints...)
+		// Recently failed endpoints go last, and endpoints without measurements go first to measure them
+		now := time.Now()
+		sort.SliceStable(result, func(i, j int) bool {
+			leftFailed := now.Sub(result[i].failedAt) < failurePenalty
+			rightFailed := now.Sub(result[j].failedAt) < failurePenalty
+			if leftFailed != rightFailed {
+				return rightFailed
+			}
+			if result[i].measured != result[j].measured {
+				return !result[i].measured
+			}
+			return result[i].latency < result[j].latency
+		})
+	default:
+		result = append(result, c.endpoints...)
+	}
+
+	return result
+}
+
+// Adds the latency of a successful call to the average latency of the endpoint and clears its failure
+func (c *endpointBalancer) recordSuccess(endpoint *cloudFunctionEndpoint, latency time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	endpoint.failedAt = time.Time{}
+	if !endpoint.measured {
+		endpoint.latency = latency
+		endpoint.measured = true
+		return
+	}
+	endpoint.latency += time.Duration(latencySmoothing * float64(latency-endpoint.latency))
+}
+
+// Penalizes the failed endpoint without changing its average latency
+func (c *endpointBalancer) recordFailure(endpoint *cloudFunctionEndpoint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	endpoint.failedAt = time.Now()
+}
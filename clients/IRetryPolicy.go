@@ -0,0 +1,21 @@
+package clients
+
+import (
+	"net/http"
+	"time"
+)
+
+// Interface for policies that decide when failed calls of Google Functions are retried.
+//
+// see RetryPolicy
+type IRetryPolicy interface {
+
+	// Gets delay before the next attempt of a failed call.
+	// Parameters:
+	//		- attempt	a number of completed attempts, starting from 1.
+	//		- cmd		a called action name.
+	//		- response	a response of the failed attempt, or nil when the call failed to reach the function.
+	//		- err		a transport error of the failed attempt, or nil when the function returned a response.
+	// Returns the delay and true to retry the call, or false to return the failure.
+	GetRetryDelay(attempt int, cmd string, response *http.Response, err error) (time.Duration, bool)
+}
@@ -0,0 +1,199 @@
+package clients
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+)
+
+// RetryPolicy retries failed calls with exponential backoff and jitter.
+//
+// Calls are retried when they fail to reach the function, or when the function responds with
+// one of retryable status codes. Delays double with each attempt, starting from the base delay
+// up to the max delay, and are randomly reduced by the jitter to spread retries of concurrent clients.
+// When the response carries "Retry-After" header, the call is retried not earlier than requested,
+// but no later than the max delay.
+//
+// Calls of non-idempotent actions can be executed twice when responses are lost, so retries
+// can be limited to idempotent commands. Commands are matched by patterns like "*.get_*".
+//
+//	Configuration parameters:
+//		- options:
+//			- retries:					number of attempts used when max_attempts is not set (default: 3)
+//			- retry:
+//				- max_attempts:			maximum number of attempts including the first one (default: 3)
+//				- base_delay:			delay before the first retry in milliseconds (default: 100)
+//				- max_delay:			maximum delay between attempts in milliseconds (default: 10000)
+//				- jitter:				fraction of the delay randomly subtracted from it, from 0 to 1 (default: 0.2)
+//				- status_codes:			comma-separated retryable status codes (default: 429,502,503,504)
+//				- idempotent_only:		true to retry only idempotent commands (default: false)
+//				- idempotent_commands:	comma-separated patterns of idempotent commands
+//
+// see IRetryPolicy
+type RetryPolicy struct {
+	// The maximum number of attempts including the first one.
+	MaxAttempts int
+	// The delay before the first retry in milliseconds.
+	BaseDelay int
+	// The maximum delay between attempts in milliseconds.
+	MaxDelay int
+	// The fraction of the delay randomly subtracted from it, from 0 to 1.
+	Jitter float64
+	// The status codes of responses to retry.
+	StatusCodes []int
+	// True to retry only idempotent commands.
+	IdempotentOnly bool
+	// The patterns of idempotent commands.
+	IdempotentCommands []string
+}
+
+const (
+	DefaultRetryBaseDelay = 100
+	DefaultRetryMaxDelay  = 10000
+	DefaultRetryJitter    = 0.2
+)
+
+// Creates a new instance of the policy with default settings.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: DefaultRetriesCount,
+		BaseDelay:   DefaultRetryBaseDelay,
+		MaxDelay:    DefaultRetryMaxDelay,
+		Jitter:      DefaultRetryJitter,
+		StatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		IdempotentCommands: make([]string, 0),
+	}
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config ConfigParams configuration parameters to be set.
+func (c *RetryPolicy) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.MaxAttempts = config.GetAsIntegerWithDefault("options.retries", c.MaxAttempts)
+	c.MaxAttempts = config.GetAsIntegerWithDefault("options.retry.max_attempts", c.MaxAttempts)
+	c.BaseDelay = config.GetAsIntegerWithDefault("options.retry.base_delay", c.BaseDelay)
+	c.MaxDelay = config.GetAsIntegerWithDefault("options.retry.max_delay", c.MaxDelay)
+	c.Jitter = config.GetAsDoubleWithDefault("options.retry.jitter", c.Jitter)
+	c.IdempotentOnly = config.GetAsBooleanWithDefault("options.retry.idempotent_only", c.IdempotentOnly)
+
+	if value := config.GetAsString("options.retry.status_codes"); value != "" {
+		c.StatusCodes = make([]int, 0)
+		for _, item := range splitList(value) {
+			if code, err := strconv.Atoi(item); err == nil {
+				c.StatusCodes = append(c.StatusCodes, code)
+			}
+		}
+	}
+
+	if value := config.GetAsString("options.retry.idempotent_commands"); value != "" {
+		c.IdempotentCommands = splitList(value)
+	}
+}
+
+// Gets delay before the next attempt of a failed call.
+// Parameters:
+//		- attempt	a number of completed attempts, starting from 1.
+//		- cmd		a called action name.
+//		- response	a response of the failed attempt, or nil when the call failed to reach the function.
+//		- err		a transport error of the failed attempt, or nil when the function returned a response.
+// Returns the delay and true to retry the call, or false to return the failure.
+func (c *RetryPolicy) GetRetryDelay(attempt int, cmd string, response *http.Response, err error) (time.Duration, bool) {
+	if attempt >= c.MaxAttempts {
+		return 0, false
+	}
+
+	if err == nil && (response == nil || !c.isRetryableStatus(response.StatusCode)) {
+		return 0, false
+	}
+
+	if c.IdempotentOnly && !c.IsIdempotent(cmd) {
+		return 0, false
+	}
+
+	maxDelay := time.Duration(c.MaxDelay) * time.Millisecond
+
+	delay := time.Duration(float64(c.BaseDelay)*math.Pow(2, float64(attempt-1))) * time.Millisecond
+	if delay > maxDelay || delay < 0 {
+		delay = maxDelay
+	}
+	if c.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * math.Min(c.Jitter, 1) * float64(delay))
+	}
+
+	// Wait as long as the function asks, within the max delay
+	if retryAfter, ok := parseRetryAfter(response); ok && retryAfter > delay {
+		delay = retryAfter
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+
+	return delay, true
+}
+
+// Checks if the command is idempotent and can be safely retried.
+// Parameters:
+//		- cmd	a command name.
+// Returns true if the command matches one of idempotent command patterns.
+func (c *RetryPolicy) IsIdempotent(cmd string) bool {
+	for _, pattern := range c.IdempotentCommands {
+		if matched, _ := path.Match(pattern, cmd); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *RetryPolicy) isRetryableStatus(status int) bool {
+	for _, code := range c.StatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Parses "Retry-After" header in seconds or HTTP date format
+func parseRetryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+
+	value := strings.TrimSpace(response.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date), true
+	}
+
+	return 0, false
+}
+
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
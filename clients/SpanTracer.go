@@ -0,0 +1,33 @@
+package clients
+
+import (
+	"context"
+
+	ctrace "github.com/pip-services3-gox/pip-services3-components-gox/trace"
+)
+
+// spanTracer passes traces to the tracer in the context of the span
+// they were started in, so they are not attributed to the span of the caller.
+type spanTracer struct {
+	ctx    context.Context
+	tracer ctrace.ITracer
+}
+
+func newSpanTracer(ctx context.Context, tracer ctrace.ITracer) *spanTracer {
+	return &spanTracer{
+		ctx:    ctx,
+		tracer: tracer,
+	}
+}
+
+func (c *spanTracer) Trace(ctx context.Context, correlationId string, component string, operation string, duration int64) {
+	c.tracer.Trace(c.ctx, correlationId, component, operation, duration)
+}
+
+func (c *spanTracer) Failure(ctx context.Context, correlationId string, component string, operation string, err error, duration int64) {
+	c.tracer.Failure(c.ctx, correlationId, component, operation, err, duration)
+}
+
+func (c *spanTracer) BeginTrace(ctx context.Context, correlationId string, component string, operation string) *ctrace.TraceTiming {
+	return ctrace.NewTraceTiming(correlationId, component, operation, c)
+}
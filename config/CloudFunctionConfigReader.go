@@ -0,0 +1,371 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cconfig "github.com/pip-services3-gox/pip-services3-components-gox/config"
+	"github.com/pip-services3-gox/pip-services3-expressions-gox/mustache"
+	mparsers "github.com/pip-services3-gox/pip-services3-expressions-gox/mustache/parsers"
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+	"gopkg.in/yaml.v2"
+)
+
+// Default path to container configuration of Google Functions
+const DefaultConfigPath = "./config/config.yml"
+
+// Default time in milliseconds to cache config files loaded from Cloud Storage
+const DefaultConfigCacheTimeout = 60000
+
+// CloudFunctionConfigReader reads container configuration of Google Functions from YAML or JSON files.
+//
+// The config file is set by CONFIG_PATH environment variable or by the configured path.
+// Files with ".yml" or ".yaml" extensions are read as YAML, other files are read as JSON.
+// Paths like "gs://bucket/object" are read from Cloud Storage using Application Default Credentials
+// or STORAGE_EMULATOR_HOST environment variable. Other paths are read from the configured
+// file system, e.g. embed.FS compiled into the binary, or from local files.
+//
+// Config files loaded from Cloud Storage are cached between reads, so functions that are
+// opened again on warm instances do not download them. When the cache expires or the config
+// is refreshed, the files are downloaded only when they change.
+//
+// Config files are Mustache templates parameterized by environment variables.
+// When the env prefix is set, only variables that start with the prefix are passed to templates.
+// The prefix can be overriden by CONFIG_ENV_PREFIX environment variable.
+// Variables used outside of sections like {{#if VAR}}...{{/if}} or {{^VAR}}...{{/VAR}} are required,
+// and when they are not set, reading fails with MISSING_CONFIG_PARAM error that names the missing variable.
+//
+// Values with "${secret:<name>}" placeholders are substituted with secrets resolved at startup.
+// Secrets are read from Google Secret Manager by their resource names like "projects/myproject/secrets/mysecret",
+// or short names like "mysecret" in the project set by GOOGLE_CLOUD_PROJECT environment variable,
+// unless another secret resolver is set.
+//
+// see ISecretResolver
+//
+//	Example:
+//		# config.yml
+//		- descriptor: "mygroup:persistence:postgres:default:1.0"
+//		  connection:
+//		    host: {{MYAPP_DB_HOST}}
+//		    port: {{#if MYAPP_DB_PORT}}{{MYAPP_DB_PORT}}{{/if}}{{^MYAPP_DB_PORT}}5432{{/MYAPP_DB_PORT}}
+//		  credential:
+//		    password: ${secret:projects/myproject/secrets/db-password}
+//
+//		reader := config.NewCloudFunctionConfigReader("./config/config.yml")
+//		reader.SetEnvPrefix("MYAPP_")
+//		conf, err := reader.ReadConfig(ctx, "123")
+//
+//		// Config embedded into the binary
+//		//go:embed config
+//		var configFS embed.FS
+//
+//		reader.SetFS(configFS)
+//
+type CloudFunctionConfigReader struct {
+	path           string
+	envPrefix      string
+	parameters     *cconf.ConfigParams
+	secretResolver ISecretResolver
+	fsys           fs.FS
+	cacheTimeout   int64
+	storage        *storageConfigLoader
+}
+
+var secretPlaceholderRegex = regexp.MustCompile(`\$\{secret:([^}]+)\}`)
+
+// Creates a new instance of the config reader.
+// Parameters:
+//		- path	a path to the config file used when CONFIG_PATH environment variable is not set.
+func NewCloudFunctionConfigReader(path string) *CloudFunctionConfigReader {
+	return &CloudFunctionConfigReader{
+		path:         path,
+		cacheTimeout: DefaultConfigCacheTimeout,
+		storage:      newStorageConfigLoader(),
+	}
+}
+
+// Sets the path to the config file.
+// Parameters:
+//		- path	a path to the config file used when CONFIG_PATH environment variable is not set.
+func (c *CloudFunctionConfigReader) SetPath(path string) {
+	c.path = path
+}
+
+// Gets the path to the config file.
+// Returns the value of CONFIG_PATH environment variable or the configured path.
+func (c *CloudFunctionConfigReader) GetPath() string {
+	if env := os.Getenv("CONFIG_PATH"); env != "" {
+		return env
+	}
+	return c.path
+}
+
+// Sets the prefix of environment variables passed to config templates.
+// Parameters:
+//		- prefix	a prefix like "MYAPP_" or empty string to pass all environment variables.
+func (c *CloudFunctionConfigReader) SetEnvPrefix(prefix string) {
+	c.envPrefix = prefix
+}
+
+// Gets the prefix of environment variables passed to config templates.
+// Returns the value of CONFIG_ENV_PREFIX environment variable or the configured prefix.
+func (c *CloudFunctionConfigReader) GetEnvPrefix() string {
+	if env := os.Getenv("CONFIG_ENV_PREFIX"); env != "" {
+		return env
+	}
+	return c.envPrefix
+}
+
+// Sets parameters passed to config templates in addition to environment variables.
+// The parameters override environment variables with the same names.
+// Parameters:
+//		- parameters	template parameters.
+func (c *CloudFunctionConfigReader) SetParameters(parameters *cconf.ConfigParams) {
+	c.parameters = parameters
+}
+
+// Sets the file system to read config files, e.g. embed.FS with config files compiled into the binary.
+// Config files given by "gs://bucket/object" URIs are still read from Cloud Storage.
+// Parameters:
+//		- fsys	a file system or nil to read local files.
+func (c *CloudFunctionConfigReader) SetFS(fsys fs.FS) {
+	c.fsys = fsys
+}
+
+// Sets the time to cache config files loaded from Cloud Storage.
+// Parameters:
+//		- timeout	time in milliseconds to cache config files, 0 to check for changes on every read.
+func (c *CloudFunctionConfigReader) SetCacheTimeout(timeout int64) {
+	c.cacheTimeout = timeout
+}
+
+// Sets the source of OAuth access tokens to read config files from Cloud Storage.
+// Parameters:
+//		- tokenSource	a token source or nil to use Application Default Credentials.
+func (c *CloudFunctionConfigReader) SetTokenSource(tokenSource gcpauth.IIdTokenSource) {
+	c.storage.setTokenSource(tokenSource)
+}
+
+// Sets the resolver of "${secret:<name>}" placeholders.
+// Parameters:
+//		- resolver	a secret resolver or nil to read secrets from Google Secret Manager.
+func (c *CloudFunctionConfigReader) SetSecretResolver(resolver ISecretResolver) {
+	c.secretResolver = resolver
+}
+
+// Gets parameters passed to config templates: environment variables
+// that start with the env prefix overriden by the configured parameters.
+// Returns template parameters.
+func (c *CloudFunctionConfigReader) GetParameters() *cconf.ConfigParams {
+	prefix := c.GetEnvPrefix()
+
+	parameters := cconf.NewEmptyConfigParams()
+	for _, env := range os.Environ() {
+		pos := strings.Index(env, "=")
+		if pos <= 0 {
+			continue
+		}
+
+		name := env[:pos]
+		if strings.HasPrefix(name, prefix) {
+			parameters.Put(name, env[pos+1:])
+		}
+	}
+
+	if c.parameters != nil {
+		parameters = parameters.Override(c.parameters)
+	}
+
+	return parameters
+}
+
+// Reads the config file, parameterizes it and resolves secrets.
+// Config files loaded from Cloud Storage are taken from the cache until it expires.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string transaction id to trace execution through call chain.
+//	Returns: the read configuration and error
+func (c *CloudFunctionConfigReader) ReadConfig(ctx context.Context, correlationId string) (*cconf.ConfigParams, error) {
+	return c.readConfig(ctx, correlationId, false)
+}
+
+// Reads the config file the same way as ReadConfig, but checks
+// config files loaded from Cloud Storage for changes at once.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string transaction id to trace execution through call chain.
+//	Returns: the read configuration and error
+func (c *CloudFunctionConfigReader) RefreshConfig(ctx context.Context, correlationId string) (*cconf.ConfigParams, error) {
+	return c.readConfig(ctx, correlationId, true)
+}
+
+func (c *CloudFunctionConfigReader) readConfig(ctx context.Context, correlationId string,
+	refresh bool) (*cconf.ConfigParams, error) {
+
+	path := c.GetPath()
+	if path == "" {
+		return nil, cerr.NewConfigError(correlationId, "NO_PATH", "Missing config file path")
+	}
+
+	text, err := c.readFile(ctx, correlationId, path, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters := c.GetParameters()
+	err = c.checkParameters(correlationId, path, string(text), parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := c.parseConfig(correlationId, path, string(text), parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.resolveSecrets(ctx, correlationId, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (c *CloudFunctionConfigReader) readFile(ctx context.Context, correlationId string,
+	filePath string, refresh bool) ([]byte, error) {
+
+	if strings.HasPrefix(filePath, "gs://") {
+		return c.storage.load(ctx, correlationId, filePath, time.Duration(c.cacheTimeout)*time.Millisecond, refresh)
+	}
+
+	var text []byte
+	var err error
+	if c.fsys != nil {
+		// File system paths are relative and cannot start with "./" or "/"
+		text, err = fs.ReadFile(c.fsys, strings.TrimPrefix(path.Clean(filePath), "/"))
+	} else {
+		text, err = ioutil.ReadFile(filePath)
+	}
+	if err != nil {
+		return nil, cerr.NewFileError(correlationId, "READ_FAILED", "Failed to read config file "+filePath).
+			WithDetails("path", filePath).WithCause(err)
+	}
+	return text, nil
+}
+
+// Parameterizes the config template and parses it as YAML or JSON.
+func (c *CloudFunctionConfigReader) parseConfig(correlationId string, filePath string, text string,
+	parameters *cconf.ConfigParams) (*cconf.ConfigParams, error) {
+
+	text, err := cconfig.NewConfigReader().Parameterize(text, parameters)
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONFIG_TEMPLATE", "Config file "+filePath+" is not a valid template").
+			WithDetails("path", filePath).WithCause(err)
+	}
+
+	var value any
+	ext := path.Ext(filePath)
+	if ext == ".yml" || ext == ".yaml" {
+		err = yaml.Unmarshal([]byte(text), &value)
+	} else {
+		err = json.Unmarshal([]byte(text), &value)
+	}
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONFIG", "Failed to parse config file "+filePath).
+			WithDetails("path", filePath).WithCause(err)
+	}
+
+	return cconf.NewConfigParamsFromValue(value), nil
+}
+
+// Checks that variables required by the template are set.
+// Variables inside sections are optional as sections check them.
+func (c *CloudFunctionConfigReader) checkParameters(correlationId string, path string, text string,
+	parameters *cconf.ConfigParams) error {
+
+	template, err := mustache.NewMustacheTemplateFromString(text)
+	if err != nil {
+		return cerr.NewConfigError(correlationId, "INVALID_CONFIG_TEMPLATE", "Config file "+path+" is not a valid template").
+			WithDetails("path", path).WithCause(err)
+	}
+
+	// Template variables are case insensitive
+	defined := make(map[string]bool)
+	for _, key := range parameters.Keys() {
+		defined[strings.ToLower(key)] = true
+	}
+
+	for _, token := range template.ResultTokens() {
+		if token.Type() != mparsers.TokenVariable && token.Type() != mparsers.TokenEscapedVariable {
+			continue
+		}
+
+		name := token.Value()
+		if !defined[strings.ToLower(name)] {
+			return cerr.NewConfigError(correlationId, "MISSING_CONFIG_PARAM",
+				"Config parameter "+name+" required by "+path+" is not set").
+				WithDetails("key", name).WithDetails("path", path)
+		}
+	}
+
+	return nil
+}
+
+// Substitutes "${secret:<name>}" placeholders in config values.
+// Each secret is resolved once even when it is used in many values.
+func (c *CloudFunctionConfigReader) resolveSecrets(ctx context.Context, correlationId string,
+	config *cconf.ConfigParams) error {
+
+	secrets := make(map[string]string)
+
+	for _, key := range config.Keys() {
+		value := config.GetAsString(key)
+		matches := secretPlaceholderRegex.FindAllStringSubmatchIndex(value, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		builder := strings.Builder{}
+		last := 0
+		for _, match := range matches {
+			name := strings.TrimSpace(value[match[2]:match[3]])
+
+			secret, ok := secrets[name]
+			if !ok {
+				result, err := c.getSecretResolver().GetSecret(ctx, correlationId, name)
+				if err != nil {
+					return cerr.NewConfigError(correlationId, "CANNOT_RESOLVE_SECRET",
+						"Failed to resolve secret "+name+" for config key "+key).
+						WithDetails("key", key).WithDetails("secret", name).WithCause(err)
+				}
+				secret = strings.TrimSpace(result)
+				secrets[name] = secret
+			}
+
+			builder.WriteString(value[last:match[0]])
+			builder.WriteString(secret)
+			last = match[1]
+		}
+		builder.WriteString(value[last:])
+
+		config.Put(key, builder.String())
+	}
+
+	return nil
+}
+
+func (c *CloudFunctionConfigReader) getSecretResolver() ISecretResolver {
+	if c.secretResolver == nil {
+		c.secretResolver = gcpauth.NewSecretManagerCredentialStore()
+	}
+	return c.secretResolver
+}
@@ -0,0 +1,20 @@
+package config
+
+import "context"
+
+// Interface for components that resolve secrets referenced in configuration
+// by "${secret:<name>}" placeholders.
+//
+// SecretManagerCredentialStore (in the auth package) resolves secrets from Google Secret Manager.
+//
+// see CloudFunctionConfigReader
+type ISecretResolver interface {
+
+	// Gets the secret payload as text.
+	// Parameters:
+	//		- ctx context.Context
+	//		- correlationId	(optional) transaction id to trace execution through call chain.
+	//		- name			a secret name like "projects/myproject/secrets/mysecret/versions/3".
+	// Returns the secret payload or error.
+	GetSecret(ctx context.Context, correlationId string, name string) (string, error)
+}
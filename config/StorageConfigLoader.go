@@ -0,0 +1,162 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+)
+
+// Default endpoint of Google Cloud Storage JSON API
+const DefaultStorageUri = "https://storage.googleapis.com"
+
+// Loader of config files from Cloud Storage objects given by "gs://bucket/object" URIs.
+// Loaded objects are cached with their ETags. When the cache expires, objects are
+// requested again with If-None-Match header, so unchanged objects are not downloaded.
+// When Cloud Storage is not available, the cached objects are returned.
+type storageConfigLoader struct {
+	tokenSource gcpauth.IIdTokenSource
+	client      *http.Client
+	items       map[string]*storageCacheItem
+	mtx         sync.Mutex
+}
+
+type storageCacheItem struct {
+	data   []byte
+	etag   string
+	expiry time.Time
+}
+
+func newStorageConfigLoader() *storageConfigLoader {
+	return &storageConfigLoader{
+		client: &http.Client{Timeout: 10 * time.Second},
+		items:  make(map[string]*storageCacheItem),
+	}
+}
+
+func (c *storageConfigLoader) setTokenSource(tokenSource gcpauth.IIdTokenSource) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.tokenSource = tokenSource
+}
+
+// Loads the object. Cached objects are returned until the cache timeout expires,
+// unless refresh is set to check for changes at once.
+func (c *storageConfigLoader) load(ctx context.Context, correlationId string, uri string,
+	cacheTimeout time.Duration, refresh bool) ([]byte, error) {
+
+	c.mtx.Lock()
+	cached, ok := c.items[uri]
+	c.mtx.Unlock()
+
+	if ok && !refresh && time.Now().Before(cached.expiry) {
+		return cached.data, nil
+	}
+
+	bucket, object, found := strings.Cut(strings.TrimPrefix(uri, "gs://"), "/")
+	if !found || bucket == "" || object == "" {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONFIG_URI", "Config uri "+uri+" shall be gs://bucket/object").
+			WithDetails("uri", uri)
+	}
+
+	// Emulators like fake-gcs-server do not need authentication
+	endpoint := DefaultStorageUri
+	emulatorHost := os.Getenv("STORAGE_EMULATOR_HOST")
+	if emulatorHost != "" {
+		endpoint = strings.TrimSuffix(emulatorHost, "/")
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		endpoint+"/storage/v1/b/"+url.PathEscape(bucket)+"/o/"+url.PathEscape(object)+"?alt=media", nil)
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONFIG_URI", "Config uri "+uri+" is invalid").
+			WithDetails("uri", uri).WithCause(err)
+	}
+	if ok && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	if emulatorHost == "" {
+		c.mtx.Lock()
+		if c.tokenSource == nil {
+			c.tokenSource = gcpauth.NewDefaultAccessTokenSource()
+		}
+		tokenSource := c.tokenSource
+		c.mtx.Unlock()
+
+		token, err := tokenSource.GetIdToken(ctx, correlationId)
+		if err != nil {
+			if ok {
+				return cached.data, nil
+			}
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token.Value)
+	}
+
+	data, etag, err := c.doRequest(correlationId, uri, req)
+	if err != nil {
+		// Keep using the cached config while Cloud Storage is not available
+		if appErr, isApp := err.(*cerr.ApplicationError); ok && isApp && appErr.Category == cerr.NoResponse {
+			return cached.data, nil
+		}
+		return nil, err
+	}
+	if data == nil && ok {
+		data = cached.data
+	}
+
+	c.mtx.Lock()
+	c.items[uri] = &storageCacheItem{
+		data:   data,
+		etag:   etag,
+		expiry: time.Now().Add(cacheTimeout),
+	}
+	c.mtx.Unlock()
+
+	return data, nil
+}
+
+// Sends the request and returns the object with its ETag, or nil data when the object is not modified
+func (c *storageConfigLoader) doRequest(correlationId string, uri string, req *http.Request) ([]byte, string, error) {
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, "", cerr.NewConnectionError(correlationId, "CANNOT_READ_CONFIG", "Failed to call Cloud Storage").
+			WithDetails("uri", uri).WithCause(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, res.Header.Get("ETag"), nil
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", cerr.NewConnectionError(correlationId, "CANNOT_READ_CONFIG", "Failed to read config from Cloud Storage").
+			WithDetails("uri", uri).WithCause(err)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, "", cerr.NewFileError(correlationId, "READ_FAILED", "Config object "+uri+" was not found").
+			WithDetails("path", uri)
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return nil, "", cerr.NewUnauthorizedError(correlationId, "ACCESS_DENIED", "Access to config object "+uri+" was denied").
+			WithDetails("uri", uri).WithDetails("status", res.StatusCode)
+	case res.StatusCode >= 300:
+		return nil, "", cerr.NewConnectionError(correlationId, "CANNOT_READ_CONFIG", "Cloud Storage rejected the request").
+			WithDetails("uri", uri).WithDetails("status", res.StatusCode).WithDetails("response", string(data))
+	}
+
+	return data, res.Header.Get("ETag"), nil
+}
@@ -21,10 +21,20 @@ import (
 //		     - region:        is the region where your function is deployed
 //		     - function:      is the name of the HTTP function you deployed
 //		     - org_id:        organization name
+//		     - platform:      cloudfunctions for 1st gen functions, cloudrun for 2nd gen functions and Cloud Run services,
+//		                      apigateway for API Gateway or custom for custom domains (default: parsed from the uri)
+//		     - service:       the Cloud Run service of 2nd gen function (default: the function name)
+//		     - project_number: your Google Cloud Platform project number used in Cloud Run uris
+//		     - region_code:   the short region code used in legacy Cloud Run and API Gateway uris, e.g. "uc"
+//		     - gateway:       the API Gateway id
 //
 //		- credentials:
 //		    - account: the service account name
 //		    - auth_token:    Google-generated ID token or null if using custom auth (IAM)
+//		    - key_file:      path to the service account JSON key file to generate ID tokens
+//		    - key:           service account JSON key to generate ID tokens, e.g. read from a credential store
+//		    - use_metadata:  true to obtain ID tokens from the metadata server (default: false)
+//		    - audience:      audience of generated ID tokens (default: the function uri)
 //
 // In addition to standard parameters CredentialParams may contain any number of custom parameters.
 //
@@ -178,6 +188,71 @@ func (c *GcpConnectionParams) SetProjectId(value string) {
 	c.SetAsObject("project_id", value)
 }
 
+// Gets the kind of the endpoint: PlatformCloudFunctions, PlatformCloudRun, PlatformApiGateway or PlatformCustom.
+// Returns the platform of deployed function.
+func (c *GcpConnectionParams) Platform() (string, bool) {
+	return c.GetAsNullableString("platform")
+}
+
+// Sets the kind of the endpoint.
+// Parameters:
+//		- value	the platform of deployed function.
+func (c *GcpConnectionParams) SetPlatform(value string) {
+	c.SetAsObject("platform", value)
+}
+
+// Gets the Cloud Run service that runs 2nd gen function.
+// Returns the service name.
+func (c *GcpConnectionParams) Service() (string, bool) {
+	return c.GetAsNullableString("service")
+}
+
+// Sets the Cloud Run service that runs 2nd gen function.
+// Parameters:
+//		- value	a new service name.
+func (c *GcpConnectionParams) SetService(value string) {
+	c.SetAsObject("service", value)
+}
+
+// Gets the Google Cloud Platform project number.
+// Returns the project number.
+func (c *GcpConnectionParams) ProjectNumber() (string, bool) {
+	return c.GetAsNullableString("project_number")
+}
+
+// Sets the Google Cloud Platform project number.
+// Parameters:
+//		- value	a new project number.
+func (c *GcpConnectionParams) SetProjectNumber(value string) {
+	c.SetAsObject("project_number", value)
+}
+
+// Gets the short region code used in legacy Cloud Run and API Gateway uris, e.g. "uc" for us-central1.
+// Returns the region code.
+func (c *GcpConnectionParams) RegionCode() (string, bool) {
+	return c.GetAsNullableString("region_code")
+}
+
+// Sets the short region code used in legacy Cloud Run and API Gateway uris.
+// Parameters:
+//		- value	a new region code.
+func (c *GcpConnectionParams) SetRegionCode(value string) {
+	c.SetAsObject("region_code", value)
+}
+
+// Gets the API Gateway id.
+// Returns the gateway id.
+func (c *GcpConnectionParams) Gateway() (string, bool) {
+	return c.GetAsNullableString("gateway")
+}
+
+// Sets the API Gateway id.
+// Parameters:
+//		- value	a new gateway id.
+func (c *GcpConnectionParams) SetGateway(value string) {
+	c.SetAsObject("gateway", value)
+}
+
 // Gets an ID token with the request to authenticate themselves
 // Returns the ID token.
 func (c *GcpConnectionParams) AuthToken() (string, bool) {
@@ -204,6 +279,58 @@ func (c *GcpConnectionParams) SetAccount(value string) {
 	c.SetAsObject("account", value)
 }
 
+// Gets the path to the service account JSON key file
+// Returns the path to the key file.
+func (c *GcpConnectionParams) KeyFile() (string, bool) {
+	return c.GetAsNullableString("key_file")
+}
+
+// Sets the path to the service account JSON key file
+// Parameters:
+//		- value	a new path to the key file.
+func (c *GcpConnectionParams) SetKeyFile(value string) {
+	c.SetAsObject("key_file", value)
+}
+
+// Gets the service account JSON key
+// Returns the key.
+func (c *GcpConnectionParams) Key() (string, bool) {
+	return c.GetAsNullableString("key")
+}
+
+// Sets the service account JSON key
+// Parameters:
+//		- value	a new service account JSON key.
+func (c *GcpConnectionParams) SetKey(value string) {
+	c.SetAsObject("key", value)
+}
+
+// Gets the flag to obtain ID tokens from the metadata server
+// Returns true if the metadata server shall be used.
+func (c *GcpConnectionParams) UseMetadata() bool {
+	return c.GetAsBooleanWithDefault("use_metadata", false)
+}
+
+// Sets the flag to obtain ID tokens from the metadata server
+// Parameters:
+//		- value	true to use the metadata server.
+func (c *GcpConnectionParams) SetUseMetadata(value bool) {
+	c.SetAsObject("use_metadata", value)
+}
+
+// Gets the audience of generated ID tokens
+// Returns the audience.
+func (c *GcpConnectionParams) Audience() (string, bool) {
+	return c.GetAsNullableString("audience")
+}
+
+// Sets the audience of generated ID tokens
+// Parameters:
+//		- value	a new audience.
+func (c *GcpConnectionParams) SetAudience(value string) {
+	c.SetAsObject("audience", value)
+}
+
 // Get organization name
 // Returns the organization name.
 func (c *GcpConnectionParams) OrgId() (string, bool) {
@@ -224,10 +351,16 @@ func (c *GcpConnectionParams) Validate(correlationId string) error {
 	_, uriOk := c.Uri()
 	protocol, protocolOk := c.Protocol()
 	_, functionNameOk := c.Function()
+	_, serviceOk := c.Service()
 	_, regionOk := c.Region()
 	_, projectIdOk := c.ProjectId()
+	_, projectNumberOk := c.ProjectNumber()
+
+	// 1st gen functions are located by project id, Cloud Run services by project number
+	firstGenOk := projectIdOk && functionNameOk
+	cloudRunOk := projectNumberOk && (serviceOk || functionNameOk)
 
-	if !uriOk && (!projectIdOk || !regionOk || !functionNameOk || !protocolOk) {
+	if !uriOk && (!regionOk || (!firstGenOk && !cloudRunOk)) {
 		return cerr.NewConfigError(
 			correlationId,
 			"NO_CONNECTION_URI",
@@ -235,7 +368,7 @@ func (c *GcpConnectionParams) Validate(correlationId string) error {
 		)
 	}
 
-	if projectIdOk && protocol != "http" && protocol != "https" {
+	if protocolOk && protocol != "http" && protocol != "https" {
 		return cerr.NewConfigError(
 			correlationId,
 			"WRONG_PROTOCOL", "Protocol is not supported by REST connection",
@@ -2,8 +2,8 @@ package connect
 
 import (
 	"context"
-	"net/url"
-	"strings"
+	"sort"
+	"strconv"
 
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	refer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
@@ -22,10 +22,21 @@ import (
 //		     - region:        is the region where your function is deployed
 //		     - function:      is the name of the HTTP function you deployed
 //		     - org_id:        organization name
+//		     - platform:      cloudfunctions, cloudrun, apigateway or custom (default: parsed from the uri)
+//		     - service:       the Cloud Run service of 2nd gen function (default: the function name)
+//		     - project_number: your Google Cloud Platform project number used in Cloud Run uris
+//
+// Uris of 1st gen functions, 2nd gen functions and Cloud Run services, API Gateway and custom domains
+// are parsed by GcpUriParser to fill region, project, function and other parameters that are not configured.
+// When the uri is not configured, it is composed from them.
+//
+// Several connections can be configured in "connections" section, e.g. for the same function
+// deployed to several regions. They are resolved by ResolveAll in the configured order.
 //
 //		- credentials:
 //		    - account: the service account name
 //		    - auth_token:    Google-generated ID token or null if using custom auth (IAM)
+//		    - store_key:     key to read credentials from credential stores
 //
 //	References
 //		- *:credential-store:*:*:1.0	(optional) Credential stores to resolve credentials
@@ -52,6 +63,8 @@ type GcpConnectionResolver struct {
 	connectionResolver *cconn.ConnectionResolver
 	// The credential resolver.
 	credentialResolver *cauth.CredentialResolver
+	// The references to locate credential stores.
+	references refer.IReferences
 }
 
 // Creates new instance of GcpConnectionResolver
@@ -67,7 +80,9 @@ func NewGcpConnectionResolver() *GcpConnectionResolver {
 //		- ctx context.Context
 //		- config *config.ConfigParams configuration parameters to be set.
 func (c *GcpConnectionResolver) Configure(ctx context.Context, config *cconf.ConfigParams) {
-	c.connectionResolver.Configure(ctx, config)
+	for _, connection := range c.readConnections(config) {
+		c.connectionResolver.Add(connection)
+	}
 	c.credentialResolver.Configure(ctx, config)
 }
 
@@ -78,6 +93,7 @@ func (c *GcpConnectionResolver) Configure(ctx context.Context, config *cconf.Con
 func (c *GcpConnectionResolver) SetReferences(ctx context.Context, references refer.IReferences) {
 	c.connectionResolver.SetReferences(ctx, references)
 	c.credentialResolver.SetReferences(ctx, references)
+	c.references = references
 }
 
 // Resolves connection and credential parameters and generates a single
@@ -88,98 +104,180 @@ func (c *GcpConnectionResolver) SetReferences(ctx context.Context, references re
 //
 // see IDiscovery (in the Pip.Services components package)
 func (c *GcpConnectionResolver) Resolve(correlationId string) (*GcpConnectionParams, error) {
-	connection := NewEmptyGcpConnectionParams()
-
 	connectionParams, err := c.connectionResolver.Resolve(correlationId)
 	if err != nil {
 		return nil, err
 	}
-	connection.Append(connectionParams.Value())
 
-	credentialParams, err := c.credentialResolver.Lookup(context.Background(), correlationId)
+	credentialParams, err := c.lookupCredential(context.Background(), correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.resolveConnection(correlationId, connectionParams, credentialParams)
+}
+
+// Resolves all configured connections, e.g. the same function deployed to several regions,
+// and generates a GcpConnectionParams value for each of them with the same credentials.
+// Parameters:
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+// Returns GcpConnectionParams values in the configured order, or error.
+//
+//	Example:
+//		config := config.NewConfigParamsFromTuples(
+//			"connections.0.uri", "https://us-central1-my_project.cloudfunctions.net/myfunction",
+//			"connections.1.uri", "https://europe-west1-my_project.cloudfunctions.net/myfunction",
+//		)
+//		connectionResolver.Configure(ctx, config)
+//		connections, _ := connectionResolver.ResolveAll("123")
+func (c *GcpConnectionResolver) ResolveAll(correlationId string) ([]*GcpConnectionParams, error) {
+	connectionParams, err := c.connectionResolver.ResolveAll(correlationId)
+	if err != nil {
+		return nil, err
+	}
+
+	credentialParams, err := c.lookupCredential(context.Background(), correlationId)
 	if err != nil {
 		return nil, err
 	}
 
+	// Report missing connections as Resolve does
+	if len(connectionParams) == 0 {
+		connectionParams = append(connectionParams, nil)
+	}
+
+	connections := make([]*GcpConnectionParams, 0, len(connectionParams))
+	for _, params := range connectionParams {
+		connection, err := c.resolveConnection(correlationId, params, credentialParams)
+		if err != nil {
+			return nil, err
+		}
+		connections = append(connections, connection)
+	}
+
+	return connections, nil
+}
+
+// Merges connection and credential parameters, validates them and composes the connection.
+func (c *GcpConnectionResolver) resolveConnection(correlationId string, connectionParams *cconn.ConnectionParams,
+	credentialParams *cauth.CredentialParams) (*GcpConnectionParams, error) {
+
+	connection := NewEmptyGcpConnectionParams()
+	if connectionParams != nil {
+		connection.Append(connectionParams.Value())
+	}
 	if credentialParams != nil {
 		connection.Append(credentialParams.Value())
 	}
 
 	// Perform validation
-	err = connection.Validate(correlationId)
+	err := connection.Validate(correlationId)
 	if err != nil {
 		return nil, err
 	}
 
-	connection = c.composeConnection(connection)
-
-	return connection, nil
+	return c.composeConnection(correlationId, connection)
 }
 
-func (c *GcpConnectionResolver) composeConnection(connection *GcpConnectionParams) *GcpConnectionParams {
-	connection = NewGcpConnectionParamsFromMaps(connection.Value())
-
-	uri, uriOk := connection.Uri()
+// Reads connections from "connections" section in the configured order, which defines primary
+// and secondary connections, or a single connection from "connection" section.
+// Numbered sections like "connections.0" go before named ones like "connections.backup".
+func (c *GcpConnectionResolver) readConnections(config *cconf.ConfigParams) []*cconn.ConnectionParams {
+	connections := config.GetSection("connections")
+	if connections.Len() == 0 {
+		return cconn.NewManyConnectionParamsFromConfig(config)
+	}
 
-	if !uriOk || uri == "" {
-		protocol, _ := connection.Protocol()
-		functionName, functionNameOk := connection.Function()
-		projectId, _ := connection.ProjectId()
-		region, _ := connection.Region()
-
-		// https://YOUR_REGION-YOUR_PROJECT_ID.cloudfunctions.net/FUNCTION_NAME
-		uri = protocol + "://" + region + "-" + projectId + ".cloudfunctions.net"
-		if functionNameOk {
-			uri += "/" + functionName
+	names := connections.GetSectionNames()
+	// Numbered sections go first in numeric order, then named sections in alphabetical order
+	sort.SliceStable(names, func(i, j int) bool {
+		left, leftErr := strconv.Atoi(names[i])
+		right, rightErr := strconv.Atoi(names[j])
+		switch {
+		case leftErr == nil && rightErr == nil:
+			return left < right
+		case leftErr == nil || rightErr == nil:
+			return leftErr == nil
+		default:
+			return names[i] < names[j]
 		}
+	})
 
-		connection.SetUri(uri)
-	} else {
-		parsed, err := url.Parse(uri)
-		if err != nil {
-			panic(err)
-		}
+	result := make([]*cconn.ConnectionParams, 0, len(names))
+	for _, name := range names {
+		result = append(result, cconn.NewConnectionParams(connections.GetSection(name).Value()))
+	}
+	return result
+}
 
-		var functionName string
+// Looks up credentials in credential stores registered as "*:credential-store:*:*:1.0"
+// before falling back to CredentialResolver, which looks for "credential_store" components.
+// Store errors are returned only when no other store resolves the credentials.
+func (c *GcpConnectionResolver) lookupCredential(ctx context.Context, correlationId string) (*cauth.CredentialParams, error) {
+	credentials := c.credentialResolver.GetAll()
+	for _, credential := range credentials {
+		if !credential.UseCredentialStore() {
+			return credential, nil
+		}
+	}
 
-		protocol := parsed.Scheme
-		region := parseRegion(uri)
-		projectId := parseProjectId(uri)
+	if c.references != nil {
+		stores := c.references.GetOptional(refer.NewDescriptor("*", "credential-store", "*", "*", "*"))
+		var firstErr error
+		for _, credential := range credentials {
+			for _, component := range stores {
+				store, ok := component.(cauth.ICredentialStore)
+				if !ok {
+					continue
+				}
 
-		if parsed.Path != "" {
-			functionName = parsed.Path[1:]
+				result, err := store.Lookup(ctx, correlationId, credential.StoreKey())
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					continue
+				}
+				if result != nil {
+					return result, nil
+				}
+			}
+		}
+		if firstErr != nil {
+			return nil, firstErr
 		}
-
-		connection.SetRegion(region)
-		connection.SetProjectId(projectId)
-		connection.SetFunction(functionName)
-		connection.SetProtocol(protocol)
 	}
 
-	return connection
+	return c.credentialResolver.Lookup(ctx, correlationId)
 }
 
-func parseProjectId(uri string) string {
-	var startInd int
-	var endInd int
-
-	if !strings.Contains(uri, "-") {
-		return ""
-	}
+// Composes the uri when it is not configured, and fills connection parameters parsed from the uri.
+// Configured parameters take precedence over the parsed ones.
+func (c *GcpConnectionResolver) composeConnection(correlationId string,
+	connection *GcpConnectionParams) (*GcpConnectionParams, error) {
 
-	startInd = strings.Index(uri, "-") + 1
-	endInd = strings.Index(uri, ".")
+	connection = NewGcpConnectionParamsFromMaps(connection.Value())
 
-	return uri[startInd:endInd]
-}
+	uri, uriOk := connection.Uri()
+	if !uriOk || uri == "" {
+		var err error
+		uri, err = GcpUriParser.Compose(correlationId, connection)
+		if err != nil {
+			return nil, err
+		}
+		connection.SetUri(uri)
+	}
 
-func parseRegion(uri string) string {
-	if !strings.Contains(uri, "-") {
-		return ""
+	parsed, err := GcpUriParser.Parse(correlationId, uri)
+	if err != nil {
+		return nil, err
 	}
 
-	endInd := strings.Index(uri, "-")
-	startInd := strings.Index(uri, "//") + 2
+	for _, key := range parsed.Keys() {
+		if value, ok := connection.GetAsNullableString(key); !ok || value == "" {
+			connection.Put(key, parsed.GetAsString(key))
+		}
+	}
 
-	return uri[startInd:endInd]
+	return connection, nil
 }
@@ -0,0 +1,178 @@
+package connect
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Kinds of endpoints recognized in Google Cloud uris
+const (
+	// 1st gen functions at https://REGION-PROJECT_ID.cloudfunctions.net/FUNCTION
+	PlatformCloudFunctions = "cloudfunctions"
+	// 2nd gen functions and Cloud Run services at https://SERVICE-PROJECT_NUMBER.REGION.run.app
+	// or https://SERVICE-HASH-REGION_CODE.a.run.app
+	PlatformCloudRun = "cloudrun"
+	// API Gateway at https://GATEWAY-HASH.REGION_CODE.gateway.dev
+	PlatformApiGateway = "apigateway"
+	// Functions mapped to custom domains
+	PlatformCustom = "custom"
+)
+
+var (
+	// Regions like "us-central1" or "northamerica-northeast1"
+	regionPrefixRegex = regexp.MustCompile(`^([a-z]+-[a-z]+[0-9]+)-(.+)$`)
+	// SERVICE-PROJECT_NUMBER.REGION.run.app
+	cloudRunRegex = regexp.MustCompile(`^([a-z0-9-]+)-([0-9]+)\.([a-z]+-[a-z]+[0-9]+)\.run\.app$`)
+	// SERVICE-HASH-REGION_CODE.a.run.app
+	legacyCloudRunRegex = regexp.MustCompile(`^([a-z0-9-]+)-([a-z0-9]{10})-([a-z]{2})\.a\.run\.app$`)
+	// GATEWAY-HASH.REGION_CODE.gateway.dev
+	apiGatewayRegex = regexp.MustCompile(`^([a-z0-9-]+)-([a-z0-9]+)\.([a-z]{2})\.gateway\.dev$`)
+)
+
+// Helper to parse and compose uris of Google Functions and Cloud Run services.
+//
+// It recognizes uris of 1st gen functions, 2nd gen functions and Cloud Run services,
+// API Gateway and custom domains, and extracts region, project, function and other parts from them.
+//
+// see GcpConnectionParams
+//
+//	Example:
+//		connection, err := connect.GcpUriParser.Parse("123", "https://us-central1-my-project.cloudfunctions.net/myfunction")
+//		region, _ := connection.Region()       // Result: "us-central1"
+//		projectId, _ := connection.ProjectId() // Result: "my-project"
+//		function, _ := connection.Function()   // Result: "myfunction"
+var GcpUriParser = _TGcpUriParser{}
+
+type _TGcpUriParser struct{}
+
+// Parses the uri and extracts the recognized parts into connection parameters.
+// Parameters:
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+//		- uri			a uri of Google Function, Cloud Run service or API Gateway.
+// Returns connection parameters with uri, protocol, platform and the parts found in the uri, or error.
+func (c *_TGcpUriParser) Parse(correlationId string, uri string) (*GcpConnectionParams, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONNECTION_URI", "Connection uri "+uri+" is invalid").
+			WithDetails("uri", uri).WithCause(err)
+	}
+	if parsed.Hostname() == "" {
+		return nil, cerr.NewConfigError(correlationId, "INVALID_CONNECTION_URI", "Connection uri "+uri+" has no host").
+			WithDetails("uri", uri)
+	}
+
+	protocol := strings.ToLower(parsed.Scheme)
+	if protocol != "http" && protocol != "https" {
+		return nil, cerr.NewConfigError(correlationId, "WRONG_PROTOCOL", "Protocol is not supported by REST connection").
+			WithDetails("protocol", protocol)
+	}
+
+	connection := NewEmptyGcpConnectionParams()
+	connection.SetUri(uri)
+	connection.SetProtocol(protocol)
+
+	host := strings.ToLower(parsed.Hostname())
+	path := strings.Trim(parsed.Path, "/")
+	firstSegment := path
+	if pos := strings.Index(path, "/"); pos >= 0 {
+		firstSegment = path[:pos]
+	}
+
+	switch {
+	case strings.HasSuffix(host, ".cloudfunctions.net"):
+		connection.SetPlatform(PlatformCloudFunctions)
+		prefix := strings.TrimSuffix(host, ".cloudfunctions.net")
+		if match := regionPrefixRegex.FindStringSubmatch(prefix); match != nil {
+			connection.SetRegion(match[1])
+			connection.SetProjectId(match[2])
+		} else if pos := strings.Index(prefix, "-"); pos > 0 {
+			// Short regions without numbers like "east"
+			connection.SetRegion(prefix[:pos])
+			connection.SetProjectId(prefix[pos+1:])
+		}
+		if firstSegment != "" {
+			connection.SetFunction(firstSegment)
+		}
+	case strings.HasSuffix(host, ".run.app"):
+		connection.SetPlatform(PlatformCloudRun)
+		if match := cloudRunRegex.FindStringSubmatch(host); match != nil {
+			connection.SetService(match[1])
+			connection.SetFunction(match[1])
+			connection.SetProjectNumber(match[2])
+			connection.SetRegion(match[3])
+		} else if match := legacyCloudRunRegex.FindStringSubmatch(host); match != nil {
+			connection.SetService(match[1])
+			connection.SetFunction(match[1])
+			connection.SetRegionCode(match[3])
+		}
+	case strings.HasSuffix(host, ".gateway.dev"):
+		connection.SetPlatform(PlatformApiGateway)
+		if match := apiGatewayRegex.FindStringSubmatch(host); match != nil {
+			connection.SetGateway(match[1])
+			connection.SetRegionCode(match[3])
+		}
+		if firstSegment != "" {
+			connection.SetFunction(firstSegment)
+		}
+	default:
+		connection.SetPlatform(PlatformCustom)
+		if firstSegment != "" {
+			connection.SetFunction(firstSegment)
+		}
+	}
+
+	return connection, nil
+}
+
+// Composes the uri from connection parameters.
+// Cloud Run uris are composed when the platform is PlatformCloudRun or the project number is set,
+// otherwise 1st gen function uris are composed. The protocol is "https" by default.
+// Parameters:
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+//		- connection	connection parameters.
+// Returns the composed uri or error when the required parameters are not set.
+func (c *_TGcpUriParser) Compose(correlationId string, connection *GcpConnectionParams) (string, error) {
+	protocol, ok := connection.Protocol()
+	if !ok || protocol == "" {
+		protocol = "https"
+	}
+	platform, _ := connection.Platform()
+	region, _ := connection.Region()
+	function, _ := connection.Function()
+	projectId, _ := connection.ProjectId()
+	projectNumber, _ := connection.ProjectNumber()
+	service, _ := connection.Service()
+	if service == "" {
+		service = function
+	}
+
+	if platform == PlatformCloudRun || (platform == "" && projectNumber != "") {
+		if service == "" || projectNumber == "" || region == "" {
+			return "", cerr.NewConfigError(correlationId, "NO_CONNECTION_URI",
+				"No uri or service, project_number and region are configured for Cloud Run service")
+		}
+
+		// https://SERVICE-PROJECT_NUMBER.REGION.run.app
+		return protocol + "://" + strings.ToLower(service) + "-" + projectNumber + "." + region + ".run.app", nil
+	}
+
+	if platform != "" && platform != PlatformCloudFunctions {
+		return "", cerr.NewConfigError(correlationId, "NO_CONNECTION_URI",
+			"Uri shall be configured for "+platform+" platform").WithDetails("platform", platform)
+	}
+
+	if projectId == "" || region == "" {
+		return "", cerr.NewConfigError(correlationId, "NO_CONNECTION_URI",
+			"No uri, project_id, region and function is configured in Google function uri")
+	}
+
+	// https://REGION-PROJECT_ID.cloudfunctions.net/FUNCTION
+	uri := protocol + "://" + region + "-" + projectId + ".cloudfunctions.net"
+	if function != "" {
+		uri += "/" + function
+	}
+	return uri, nil
+}
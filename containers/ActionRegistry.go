@@ -0,0 +1,146 @@
+package containers
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+// RegisteredAction is an action registered in ActionRegistry.
+type RegisteredAction struct {
+	// Command to call the action
+	Cmd string
+	// Schema of action parameters, e.g. ObjectSchema, to describe them in the actions catalog
+	Schema cvalid.ISchema
+	// Descriptor of the service that owns the action or empty string for container actions
+	Service string
+	// Action to be executed
+	Action http.HandlerFunc
+}
+
+// ActionDescription describes a registered action in the actions catalog.
+type ActionDescription struct {
+	// Command to call the action
+	Cmd string `json:"cmd"`
+	// Descriptor of the service that owns the action
+	Service string `json:"service,omitempty"`
+	// JSON Schema of action parameters
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+// ActionRegistry is a thread-safe registry of actions exposed by Google Functions.
+// Actions can be registered and unregistered while the function handles requests.
+//
+// see CloudFunction
+type ActionRegistry struct {
+	actions map[string]*RegisteredAction
+	mtx     sync.RWMutex
+}
+
+// Creates a new empty registry.
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{
+		actions: make(map[string]*RegisteredAction),
+	}
+}
+
+// Registers an action.
+// Parameters:
+//		- action	an action to register.
+// Returns error when the action is incomplete or an action with the same command already exists.
+func (c *ActionRegistry) Register(action *RegisteredAction) error {
+	if action == nil || action.Cmd == "" {
+		return cerr.NewBadRequestError("", "NO_COMMAND", "Cmd parameter is missing")
+	}
+
+	if action.Action == nil {
+		return cerr.NewBadRequestError("", "NO_ACTION", "Missing action").
+			WithDetails("cmd", action.Cmd)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if existing, ok := c.actions[action.Cmd]; ok {
+		return cerr.NewConflictError("", "DUPLICATED_ACTION", "Action "+action.Cmd+" already exists").
+			WithDetails("cmd", action.Cmd).
+			WithDetails("service", existing.Service)
+	}
+
+	c.actions[action.Cmd] = action
+	return nil
+}
+
+// Unregisters an action.
+// Parameters:
+//		- cmd	a command of the action.
+// Returns true if the action was registered and false otherwise.
+func (c *ActionRegistry) Unregister(cmd string) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	_, ok := c.actions[cmd]
+	delete(c.actions, cmd)
+	return ok
+}
+
+// Removes all registered actions.
+func (c *ActionRegistry) Clear() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.actions = make(map[string]*RegisteredAction)
+}
+
+// Gets a registered action.
+// Parameters:
+//		- cmd	a command of the action.
+// Returns the action and true, or nil and false if the action is not registered.
+func (c *ActionRegistry) Get(cmd string) (*RegisteredAction, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	action, ok := c.actions[cmd]
+	return action, ok
+}
+
+// Gets all registered actions ordered by their commands.
+func (c *ActionRegistry) List() []*RegisteredAction {
+	c.mtx.RLock()
+	result := make([]*RegisteredAction, 0, len(c.actions))
+	for _, action := range c.actions {
+		result = append(result, action)
+	}
+	c.mtx.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Cmd < result[j].Cmd
+	})
+	return result
+}
+
+// Gets the number of registered actions.
+func (c *ActionRegistry) Len() int {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return len(c.actions)
+}
+
+// Describes all registered actions with JSON Schemas of their parameters.
+// Returns the actions catalog ordered by commands.
+func (c *ActionRegistry) Describe() []*ActionDescription {
+	actions := c.List()
+	result := make([]*ActionDescription, 0, len(actions))
+	for _, action := range actions {
+		result = append(result, &ActionDescription{
+			Cmd:     action.Cmd,
+			Service: action.Service,
+			Schema:  gcputil.JsonSchemaConverter.ToJsonSchema(action.Schema),
+		})
+	}
+	return result
+}
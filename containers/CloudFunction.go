@@ -1,18 +1,23 @@
 package containers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	ccomand "github.com/pip-services3-gox/pip-services3-commons-gox/commands"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
 	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
@@ -20,14 +25,23 @@ import (
 	crun "github.com/pip-services3-gox/pip-services3-commons-gox/run"
 	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
 	ccount "github.com/pip-services3-gox/pip-services3-components-gox/count"
-	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
 	ctrace "github.com/pip-services3-gox/pip-services3-components-gox/trace"
+	ccontconf "github.com/pip-services3-gox/pip-services3-container-gox/config"
 	ccont "github.com/pip-services3-gox/pip-services3-container-gox/container"
+	gcpbuild "github.com/pip-services3-gox/pip-services3-gcp-gox/build"
+	gcpconf "github.com/pip-services3-gox/pip-services3-gcp-gox/config"
+	gcplog "github.com/pip-services3-gox/pip-services3-gcp-gox/log"
 	gcpserv "github.com/pip-services3-gox/pip-services3-gcp-gox/services"
 	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
 	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
 )
 
+// Built-in commands that return the catalog of registered actions
+const (
+	ActionsCatalogCmd = "_actions"
+	DescribeCmd       = "describe"
+)
+
 type ICloudFunctionOverrides interface {
 	crefer.IReferenceable
 	// Registers all actions in this Google Function.
@@ -42,14 +56,55 @@ type ICloudFunctionOverrides interface {
 // When handling calls "cmd" parameter determines which what action shall be called, while
 // other parameters are passed to the action itself.
 //
+// Requests that carry CloudEvents (Pub/Sub, Cloud Storage or Eventarc triggers) in structured
+// or binary content mode are routed by event type and source to registered event handlers.
+//
 // Container configuration for this Google Function is stored in "./config/config.yml" file.
-// But this path can be overriden by CONFIG_PATH environment variable.
+// But this path can be overriden by CONFIG_PATH environment variable. The configuration is
+// parameterized by environment variables with the prefix set by SetEnvPrefix or CONFIG_ENV_PREFIX
+// environment variable, and "${secret:<name>}" placeholders are substituted with secrets at startup.
+// Missing parameters and secrets fail the startup with errors that name them.
+// The config path can also be "gs://bucket/object" URI to load the configuration from Cloud Storage,
+// or a path in the file system set by SetConfigFS, e.g. embed.FS compiled into the function binary.
+// When the reload interval is set by SetConfigReloadInterval or CONFIG_RELOAD_INTERVAL environment variable,
+// the configuration is reloaded periodically and passed to Configure of components that implement IConfigurable
+// between requests, so the components are not configured while they handle requests.
+// see CloudFunctionConfigReader (in the config package)
+//
+// Settings of the function itself are read from "options" of the context info section
+// of the configuration, e.g. "- descriptor: pip-services:context-info:default:default:1.0".
+//
+// When the actions catalog is enabled by ActionsCatalog or "options.actions_catalog" setting,
+// calls with "_actions" or "describe" command return the catalog of registered actions
+// with their commands, owning services and JSON Schemas of parameters, unless actions
+// with the same commands are registered. The catalog is disabled by default.
+//
+// Calls with "_batch" command execute a batch of actions in a single invocation.
+// The batch is limited by Batch executor settings, which are read from "options.batch.max_items"
+// and "options.batch.max_parallel" settings.
+//
+// Request bodies compressed with gzip are decompressed, and bodies larger than MaxBodySize
+// are rejected with PAYLOAD_TOO_LARGE error and 413 status code. When CompressionThreshold is set,
+// responses larger than the threshold are compressed with gzip when requests accept it.
+// Compression is disabled by default.
+// The settings can be overriden by MAX_BODY_SIZE and COMPRESSION_THRESHOLD environment variables.
+//
+// OpenAPI 3 document of registered actions is served by GET requests at the route
+// set by SetOpenApiRoute or OPENAPI_ROUTE environment variable.
+//
+// The function is opened on the first request when it was not opened before. Concurrent requests
+// wait for it to open. When the function fails to start, requests get 503 error and the next request
+// tries to start the function again.
+//
+// Log messages are written in Cloud Logging JSON format when no loggers are configured,
+// and correlated with traces of the requests via "traceparent" or "X-Cloud-Trace-Context" headers.
 //
 // 	References
 //		- *:logger:*:*:1.0							(optional) ILogger components to pass log messages
 //		- *:counters:*:*:1.0						(optional) ICounters components to pass collected measurements
 //		- *:service:cloudfunc:*:1.0				(optional) ICloudFunctionService services to handle action requests
 //		- *:service:commandable-cloudfunc:*:1.0	(optional) ICloudFunctionService services to handle action requests
+//		- *:service:pubsub-cloudfunc:*:1.0		(optional) PubSubCloudFunctionService services to handle Pub/Sub messages
 //
 //	Example:
 //		type MyCloudFunction struct {
@@ -81,14 +136,48 @@ type CloudFunction struct {
 	Tracer *ctrace.CompositeTracer
 	// The map of registred validation schemas.
 	Schemas map[string]*cvalid.Schema
-	// The map of registered actions.
-	Actions map[string]http.HandlerFunc
+	// The registry of actions.
+	Actions *ActionRegistry
+	// The executor of batch calls.
+	Batch *gcpserv.BatchExecutor
+	// The maximum size of request bodies in bytes, 0 to accept any size.
+	MaxBodySize int64
+	// The minimum size of responses in bytes to compress them, 0 (default) to disable compression.
+	CompressionThreshold int
+	// Enables the catalog of actions returned by "_actions" and "describe" commands, false by default.
+	ActionsCatalog bool
+
+	eventHandlers []*gcpserv.CloudEventHandler
 
 	feedbackChan          crun.ContextShutdownChan
 	feedbackWithErrorChan crun.ContextShutdownWithErrorChan
 
 	// The default path to config file.
 	configPath string
+	// The prefix of environment variables passed to config templates.
+	envPrefix string
+	// The resolver of secrets in configuration or nil to read them from Secret Manager.
+	secretResolver gcpconf.ISecretResolver
+	// The file system to read config files or nil to read local files.
+	configFS fs.FS
+	// The interval in milliseconds to reload configuration, 0 to disable reloading.
+	configReloadInterval int64
+	// The reader of configuration that caches config files between loads.
+	configReader *gcpconf.CloudFunctionConfigReader
+	// The last loaded configuration.
+	loadedConfig *cconf.ConfigParams
+	// Stops periodic reloading of configuration.
+	stopReload func()
+	// The lock to start and stop reloading.
+	reloadMtx sync.Mutex
+	// The route to serve OpenAPI document or empty string to disable it.
+	openApiRoute string
+	// The lock to open the function once when many requests arrive at once.
+	openMtx sync.Mutex
+	// The lock held by requests, so reloaded configuration is applied between them.
+	configMtx sync.RWMutex
+	// The flag set when the function is opened to skip the lock on requests.
+	opened int32
 }
 
 // Creates a new instance of this Google Function function.
@@ -98,13 +187,16 @@ func NewCloudFunction() *CloudFunction {
 		Counters:           ccount.NewCompositeCounters(),
 		Tracer:             ctrace.NewCompositeTracer(),
 		Schemas:            make(map[string]*cvalid.Schema),
-		Actions:            make(map[string]http.HandlerFunc),
-		configPath:         "./config/config.yml",
+		Actions:            NewActionRegistry(),
+		Batch:              gcpserv.NewBatchExecutor(),
+		MaxBodySize:        gcputil.DefaultMaxBodySize,
+		configPath:         gcpconf.DefaultConfigPath,
 	}
 
 	c.Container = ccont.InheritContainer("", "", &c)
 	c.Overrides = &c
-	c.SetLogger(clog.NewConsoleLogger())
+	c.SetLogger(gcplog.NewCloudLoggingLogger())
+	c.AddFactory(gcpbuild.NewDefaultGcpFactory())
 
 	return &c
 }
@@ -119,13 +211,16 @@ func NewCloudFunctionWithParams(name string, description string) *CloudFunction
 		Counters:           ccount.NewCompositeCounters(),
 		Tracer:             ctrace.NewCompositeTracer(),
 		Schemas:            make(map[string]*cvalid.Schema),
-		Actions:            make(map[string]http.HandlerFunc),
-		configPath:         "./config/config.yml",
+		Actions:            NewActionRegistry(),
+		Batch:              gcpserv.NewBatchExecutor(),
+		MaxBodySize:        gcputil.DefaultMaxBodySize,
+		configPath:         gcpconf.DefaultConfigPath,
 	}
 
 	c.Container = ccont.InheritContainer(name, description, &c)
 	c.Overrides = &c
-	c.SetLogger(clog.NewConsoleLogger())
+	c.SetLogger(gcplog.NewCloudLoggingLogger())
+	c.AddFactory(gcpbuild.NewDefaultGcpFactory())
 
 	return &c
 }
@@ -138,12 +233,15 @@ func InheritCloudFunction(overrides ICloudFunctionOverrides) *CloudFunction {
 		Counters:           ccount.NewCompositeCounters(),
 		Tracer:             ctrace.NewCompositeTracer(),
 		Schemas:            make(map[string]*cvalid.Schema),
-		Actions:            make(map[string]http.HandlerFunc),
-		configPath:         "./config/config.yml",
+		Actions:            NewActionRegistry(),
+		Batch:              gcpserv.NewBatchExecutor(),
+		MaxBodySize:        gcputil.DefaultMaxBodySize,
+		configPath:         gcpconf.DefaultConfigPath,
 	}
 
 	c.Container = ccont.InheritContainer("", "", overrides)
-	c.SetLogger(clog.NewConsoleLogger())
+	c.SetLogger(gcplog.NewCloudLoggingLogger())
+	c.AddFactory(gcpbuild.NewDefaultGcpFactory())
 
 	return &c
 }
@@ -159,12 +257,15 @@ func InheritCloudFunctionWithParams(overrides ICloudFunctionOverrides, name stri
 		Counters:           ccount.NewCompositeCounters(),
 		Tracer:             ctrace.NewCompositeTracer(),
 		Schemas:            make(map[string]*cvalid.Schema),
-		Actions:            make(map[string]http.HandlerFunc),
-		configPath:         "./config/config.yml",
+		Actions:            NewActionRegistry(),
+		Batch:              gcpserv.NewBatchExecutor(),
+		MaxBodySize:        gcputil.DefaultMaxBodySize,
+		configPath:         gcpconf.DefaultConfigPath,
 	}
 
 	c.Container = ccont.InheritContainer("", "", overrides)
-	c.SetLogger(clog.NewConsoleLogger())
+	c.SetLogger(gcplog.NewCloudLoggingLogger())
+	c.AddFactory(gcpbuild.NewDefaultGcpFactory())
 
 	return &c
 }
@@ -176,54 +277,298 @@ func (c *CloudFunction) Register() {}
 //		- configPath	path to config file
 func (c *CloudFunction) SetConfigPath(configPath string) {
 	c.configPath = configPath
+	c.configReader = nil
+}
+
+// SetEnvPrefix sets the prefix of environment variables passed to config templates.
+// The prefix can be overriden by CONFIG_ENV_PREFIX environment variable.
+// Parameters:
+//		- prefix	a prefix like "MYAPP_" or empty string to pass all environment variables.
+func (c *CloudFunction) SetEnvPrefix(prefix string) {
+	c.envPrefix = prefix
+	c.configReader = nil
+}
+
+// SetSecretResolver sets the resolver of "${secret:<name>}" placeholders in configuration.
+// Parameters:
+//		- resolver	a secret resolver or nil to read secrets from Google Secret Manager.
+func (c *CloudFunction) SetSecretResolver(resolver gcpconf.ISecretResolver) {
+	c.secretResolver = resolver
+	c.configReader = nil
+}
+
+// SetConfigFS sets the file system to read config files, e.g. embed.FS with config files
+// compiled into the function binary. Config paths like "gs://bucket/object" are still read from Cloud Storage.
+// Parameters:
+//		- fsys	a file system or nil to read local files.
+func (c *CloudFunction) SetConfigFS(fsys fs.FS) {
+	c.configFS = fsys
+	c.configReader = nil
+}
+
+// SetConfigReloadInterval enables periodic reloading of configuration after the function is opened
+// by OpenOnce. The interval can be overriden by CONFIG_RELOAD_INTERVAL environment variable.
+// Parameters:
+//		- interval	an interval in milliseconds to reload configuration, 0 to disable reloading.
+func (c *CloudFunction) SetConfigReloadInterval(interval int64) {
+	c.configReloadInterval = interval
+}
+
+func (c *CloudFunction) getConfigReloadInterval() int64 {
+	if env, err := strconv.ParseInt(os.Getenv("CONFIG_RELOAD_INTERVAL"), 10, 64); err == nil {
+		return env
+	}
+	return c.configReloadInterval
+}
+
+// The reader is created once and shared by loads, so it is not changed while configuration is refreshed.
+// Setters of configuration sources drop it to create a new one on the next load.
+func (c *CloudFunction) getConfigReader() *gcpconf.CloudFunctionConfigReader {
+	if c.configReader == nil {
+		reader := gcpconf.NewCloudFunctionConfigReader(c.configPath)
+		reader.SetEnvPrefix(c.envPrefix)
+		reader.SetSecretResolver(c.secretResolver)
+		reader.SetFS(c.configFS)
+		c.configReader = reader
+	}
+	return c.configReader
+}
+
+// SetOpenApiRoute enables OpenAPI document of registered actions served by GET requests
+// at the route. The route can be overriden by OPENAPI_ROUTE environment variable.
+// The route is matched against the request path after the function name, which is
+// taken from K_SERVICE or FUNCTION_NAME environment variables set by Google Cloud.
+// Parameters:
+//		- route	a route to serve the document, e.g. "/openapi.json", or empty string to disable it.
+func (c *CloudFunction) SetOpenApiRoute(route string) {
+	c.openApiRoute = route
 }
 
-func (c *CloudFunction) getConfigPath() string {
-	env := os.Getenv("CONFIG_PATH")
+func (c *CloudFunction) getOpenApiRoute() string {
+	env := os.Getenv("OPENAPI_ROUTE")
 	if env != "" {
 		return env
 	}
 
-	return c.configPath
+	return c.openApiRoute
 }
 
-func (c *CloudFunction) getConfigParameters() *cconf.ConfigParams {
-	args := os.Environ()
+// Checks if the request asks for the OpenAPI document at the exact route
+func (c *CloudFunction) isOpenApiRequest(req *http.Request) bool {
+	route := c.getOpenApiRoute()
+	if route == "" || req.Method != http.MethodGet {
+		return false
+	}
 
-	line := ""
+	path := req.URL.Path
+	for _, name := range []string{os.Getenv("K_SERVICE"), os.Getenv("FUNCTION_NAME")} {
+		if name == "" {
+			continue
+		}
+		// Functions can receive paths that start with the function name
+		if base := "/" + name; path == base || strings.HasPrefix(path, base+"/") {
+			path = strings.TrimPrefix(path, base)
+			break
+		}
+	}
 
-	for index := 0; index < len(args); index++ {
-		arg := args[index]
-		nextArg := ""
-		if index < len(args)-1 {
-			nextArg = args[index+1]
-			if strings.HasPrefix(nextArg, "-") {
-				nextArg = ""
-			}
+	return "/"+strings.Trim(path, "/") == "/"+strings.Trim(route, "/")
+}
+
+func (c *CloudFunction) getMaxBodySize() int64 {
+	if env, err := strconv.ParseInt(os.Getenv("MAX_BODY_SIZE"), 10, 64); err == nil {
+		return env
+	}
+
+	return c.MaxBodySize
+}
+
+func (c *CloudFunction) getCompressionThreshold() int {
+	if env, err := strconv.Atoi(os.Getenv("COMPRESSION_THRESHOLD")); err == nil {
+		return env
+	}
+
+	return c.CompressionThreshold
+}
+
+// Gets OpenAPI document that describes actions registered in this Google Function.
+// The document is titled by the container name and description.
+// Returns: OpenAPI document
+func (c *CloudFunction) GetOpenApiDocument() *CloudFunctionOpenApiDocument {
+	name := ""
+	description := ""
+	if info := c.Info(); info != nil {
+		name = info.Name
+		description = info.Description
+	}
+
+	return NewCloudFunctionOpenApiDocument(name, description, c.Actions.List())
+}
+
+func (c *CloudFunction) sendOpenApiDocument(res http.ResponseWriter, req *http.Request) {
+	data, err := c.GetOpenApiDocument().ToJson()
+	if err != nil {
+		rpcserv.HttpResponseSender.SendError(res, req, err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(http.StatusOK)
+	_, _ = res.Write(data)
+}
+
+// Loads container configuration from the config file parameterized by environment
+// variables and resolves secrets referenced in the configuration.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *CloudFunction) LoadConfig(ctx context.Context, correlationId string) error {
+	config, err := c.getConfigReader().ReadConfig(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	// Container ignores invalid configuration, so it is validated first
+	_, err = ccontconf.ReadContainerConfigFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c.Configure(ctx, config)
+	c.loadedConfig = config
+	return nil
+}
+
+// Reloads configuration of the opened function. When the configuration changes,
+// it is passed to Configure of the components that implement IConfigurable.
+// Components are not created or removed, so new components are added on the next start.
+// Components are reconfigured between requests: reloading waits until requests in flight are completed
+// and holds new requests meanwhile, so components do not need to support concurrent configuration.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *CloudFunction) ReloadConfig(ctx context.Context, correlationId string) error {
+	c.openMtx.Lock()
+	if !c.IsOpen() {
+		c.openMtx.Unlock()
+		return nil
+	}
+	reader := c.getConfigReader()
+	c.openMtx.Unlock()
+
+	// Requests are not blocked while the configuration is read
+	config, err := reader.RefreshConfig(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	containerConfig, err := ccontconf.ReadContainerConfigFromConfig(config)
+	if err != nil {
+		return err
+	}
+
+	c.openMtx.Lock()
+	changed := c.loadedConfig == nil || !reflect.DeepEqual(config.Value(), c.loadedConfig.Value())
+	c.openMtx.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	c.configMtx.Lock()
+	defer c.configMtx.Unlock()
+
+	c.openMtx.Lock()
+	defer c.openMtx.Unlock()
+
+	if !c.IsOpen() {
+		return nil
+	}
+
+	c.Configure(ctx, config)
+	c.loadedConfig = config
+
+	for _, componentConfig := range containerConfig {
+		if componentConfig.Descriptor == nil {
+			continue
 		}
 
-		if nextArg != "" {
-			if arg == "--param" || arg == "--params" || arg == "-p" {
-				if line != "" {
-					line = line + ";"
-				}
-				line = line + nextArg
-				index++
+		for _, component := range c.References.GetOptional(componentConfig.Descriptor) {
+			if configurable, ok := component.(cconf.IConfigurable); ok {
+				configurable.Configure(ctx, componentConfig.Config)
 			}
 		}
 	}
 
-	parameters := cconf.NewConfigParamsFromString(line)
+	c.Logger().Info(ctx, correlationId, "Configuration of %s is reloaded", c.Info().Name)
+	return nil
+}
+
+func (c *CloudFunction) startConfigReload(correlationId string) {
+	interval := c.getConfigReloadInterval()
+	if interval <= 0 {
+		return
+	}
+
+	c.reloadMtx.Lock()
+	defer c.reloadMtx.Unlock()
 
-	for _, e := range os.Environ() {
-		if env := strings.Split(e, "="); len(env) == 2 {
-			parameters.SetAsObject(env[0], env[1])
-		} else {
-			parameters.SetAsObject(env[0], strings.Join(env[1:], "="))
+	if c.stopReload != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	c.stopReload = func() {
+		cancel()
+		<-done
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(time.Duration(interval) * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.ReloadConfig(ctx, correlationId); err != nil {
+					c.Logger().Error(ctx, correlationId, err, "Failed to reload configuration")
+				}
+			}
 		}
+	}()
+}
+
+// Configure sets the configuration of components created by this container
+// and reads settings of the function from its context info section.
+//	see ConfigParams
+//	Parameters:
+//		- ctx context.Context
+//		- config *ConfigParams the container configuration.
+func (c *CloudFunction) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.Container.Configure(ctx, config)
+
+	containerConfig, err := ccontconf.ReadContainerConfigFromConfig(config)
+	if err != nil {
+		return
 	}
 
-	return parameters
+	contextInfo := crefer.NewDescriptor("*", "context-info", "*", "*", "1.0")
+	for _, componentConfig := range containerConfig {
+		if componentConfig.Descriptor == nil || !componentConfig.Descriptor.Match(contextInfo) {
+			continue
+		}
+
+		options := componentConfig.Config
+		c.ActionsCatalog = options.GetAsBooleanWithDefault("options.actions_catalog", c.ActionsCatalog)
+		c.Batch.Configure(options)
+	}
 }
 
 // SetReferences sets references to dependent components.
@@ -232,6 +577,14 @@ func (c *CloudFunction) getConfigParameters() *cconf.ConfigParams {
 //		- ctx context.Context
 //		- references IReferences references to locate the component dependencies.
 func (c *CloudFunction) SetReferences(ctx context.Context, references crefer.IReferences) {
+	// Write logs to Cloud Logging when no loggers are configured
+	loggers := references.GetOptional(crefer.NewDescriptor("*", "logger", "*", "*", "*"))
+	if len(loggers) == 0 {
+		logger := gcplog.NewCloudLoggingLogger()
+		logger.SetReferences(ctx, references)
+		references.Put(ctx, crefer.NewDescriptor("pip-services", "logger", "cloudlogging", "default", "1.0"), logger)
+	}
+
 	c.Counters.SetReferences(ctx, references)
 	c.DependencyResolver.SetReferences(ctx, references)
 
@@ -257,6 +610,85 @@ func (c *CloudFunction) Open(ctx context.Context, correlationId string) error {
 	return nil
 }
 
+// Opens the function on the first call: loads container configuration,
+// instantiate components and opens them. It is safe to call concurrently,
+// other callers wait until the function is opened. When opening fails,
+// the components are closed and the next call tries to open the function again.
+// Panics while opening are returned as errors.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId: string transaction id to trace execution through call chain.
+//	Return: error or nil when the function is opened.
+func (c *CloudFunction) OpenOnce(ctx context.Context, correlationId string) error {
+	if atomic.LoadInt32(&c.opened) == 1 {
+		return nil
+	}
+
+	c.openMtx.Lock()
+	defer c.openMtx.Unlock()
+
+	if c.IsOpen() {
+		atomic.StoreInt32(&c.opened, 1)
+		return nil
+	}
+
+	err := c.openSafe(ctx, correlationId)
+	if err != nil {
+		_ = c.Close(ctx, correlationId)
+		return err
+	}
+
+	atomic.StoreInt32(&c.opened, 1)
+	c.startConfigReload(correlationId)
+	return nil
+}
+
+func (c *CloudFunction) openSafe(ctx context.Context, correlationId string) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			cause, ok := rec.(error)
+			if !ok {
+				msg := cconv.StringConverter.ToString(rec)
+				cause = errors.New(msg)
+			}
+			err = cerr.NewInternalError(correlationId, "OPEN_PANIC", "Function panicked while opening").
+				WithCause(cause)
+		}
+	}()
+
+	err = c.LoadConfig(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	return c.Open(ctx, correlationId)
+}
+
+// Close closes the function, its components and stops reloading of configuration.
+// Registered actions are removed and registered again when the function is opened.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId: string transaction id to trace execution through call chain.
+//	Return: error
+func (c *CloudFunction) Close(ctx context.Context, correlationId string) error {
+	atomic.StoreInt32(&c.opened, 0)
+
+	c.reloadMtx.Lock()
+	stopReload := c.stopReload
+	c.stopReload = nil
+	c.reloadMtx.Unlock()
+
+	if stopReload != nil {
+		stopReload()
+	}
+
+	// Actions and event handlers are registered again on the next open
+	c.Actions.Clear()
+	c.eventHandlers = nil
+
+	return c.Container.Close(ctx, correlationId)
+}
+
 // Instrument method are adds instrumentation to log calls and measure call time.
 // It returns a Timing object that is used to end the time measurement.
 //	Parameters:
@@ -288,9 +720,6 @@ func (c *CloudFunction) Run(ctx context.Context) {
 	ctx, _ = crun.AddShutdownChanToContext(ctx, c.feedbackChan)
 	ctx, _ = crun.AddErrShutdownChanToContext(ctx, c.feedbackWithErrorChan)
 
-	path := c.getConfigPath()
-	parameters := c.getConfigParameters()
-
 	defer func() {
 		if r := recover(); r != nil {
 			err, ok := r.(error)
@@ -305,18 +734,10 @@ func (c *CloudFunction) Run(ctx context.Context) {
 		}
 	}()
 
-	err := c.ReadConfigFromFile(ctx, correlationId, path, parameters)
-	if err != nil {
-		c.Logger().Fatal(ctx, correlationId, err, "Process is terminated")
-		os.Exit(1)
-		return
-	}
-
 	c.Logger().Info(ctx, correlationId, "Press Control-C to stop the microservice...")
 
-	err = c.Open(ctx, correlationId)
+	err := c.OpenOnce(ctx, correlationId)
 	if err != nil {
-		_ = c.Close(ctx, correlationId)
 		cancel()
 		c.Logger().Fatal(ctx, correlationId, err, "Process is terminated")
 		os.Exit(1)
@@ -351,25 +772,56 @@ func (c *CloudFunction) Run(ctx context.Context) {
 }
 
 // Registers all Google Function services in the container.
+// Actions that conflict with already registered actions are skipped and logged.
 func (c *CloudFunction) RegisterServices() {
-	// Extract regular and commandable Google Function services from references
-	services := c.References.GetOptional(crefer.NewDescriptor("*", "service", "cloudfunc", "*", "*"))
-	cmdServices := c.References.GetOptional(crefer.NewDescriptor("*", "service", "commandable-cloudfunc", "*", "*"))
-	services = append(services, cmdServices...)
-
-	// Register actions defined in those services
-	for _, service := range services {
-		// Check if the service implements required interface
-		if _val, ok := service.(gcpserv.ICloudFunctionService); ok {
-			actions := _val.GetActions()
-			for _, action := range actions {
-				c.RegisterAction(action.Cmd, action.Schema, action.Action)
+	// Extract regular, commandable and Pub/Sub Google Function services from references
+	patterns := []*crefer.Descriptor{
+		crefer.NewDescriptor("*", "service", "cloudfunc", "*", "*"),
+		crefer.NewDescriptor("*", "service", "commandable-cloudfunc", "*", "*"),
+		crefer.NewDescriptor("*", "service", "pubsub-cloudfunc", "*", "*"),
+	}
+
+	for _, pattern := range patterns {
+		for _, locator := range c.References.GetAllLocators() {
+			descriptor, ok := locator.(*crefer.Descriptor)
+			if !ok || !pattern.Match(descriptor) {
+				continue
+			}
+			service := c.References.GetOneOptional(descriptor)
+
+			// Register actions defined in those services
+			if _val, ok := service.(gcpserv.ICloudFunctionService); ok {
+				commandSchemas := getCommandSchemas(service)
+				actions := _val.GetActions()
+				for _, action := range actions {
+					var schema cvalid.ISchema
+					if action.Schema != nil {
+						schema = action.Schema
+					}
+					describeSchema := action.ParamsSchema
+					if isNilSchema(describeSchema) {
+						describeSchema = schema
+					}
+					if describeSchema == nil {
+						describeSchema = commandSchemas[action.Cmd]
+					}
+					err := c.registerAction(action.Cmd, descriptor.String(), schema, describeSchema, action.Action)
+					if err != nil {
+						c.Logger().Error(context.Background(), "", err, "Failed to register action %s", action.Cmd)
+					}
+				}
+			}
+
+			// Event handlers are already instrumented by the services
+			if _val, ok := service.(gcpserv.ICloudEventService); ok {
+				c.eventHandlers = append(c.eventHandlers, _val.GetEventHandlers()...)
 			}
 		}
 	}
 }
 
 // Registers an action in this Google Function.
+// It panics when cmd or action are missing, or the action already exists.
 //	Parameters:
 //		- cmd		a action/command name.
 //		- schema	a validation schema to validate received parameters.
@@ -377,16 +829,41 @@ func (c *CloudFunction) RegisterServices() {
 //
 // Deprecated: This method has been deprecated. Use CloudFunctionService instead.
 func (c *CloudFunction) RegisterAction(cmd string, schema *cvalid.Schema, action http.HandlerFunc) {
-	if cmd == "" {
-		panic("NO_COMMAND: cmd parameter is missing")
+	var params cvalid.ISchema
+	if schema != nil {
+		params = schema
 	}
 
-	if action == nil {
-		panic("NO_ACTION: Missing action")
+	if err := c.TryRegisterAction(cmd, params, action); err != nil {
+		panic(err)
 	}
+}
+
+// Registers an action in this Google Function and returns the error instead of panicking.
+// Unlike RegisterAction, it accepts concrete schemas, like ObjectSchema,
+// which also describe the parameters in the actions catalog and OpenAPI document.
+//	Parameters:
+//		- cmd		a action/command name.
+//		- schema	a validation schema to validate received parameters.
+//		- action	an action function that is called when action is invoked.
+//	Returns: error when cmd or action are missing, or the action already exists.
+//
+// Deprecated: This method has been deprecated. Use CloudFunctionService instead.
+func (c *CloudFunction) TryRegisterAction(cmd string, schema cvalid.ISchema, action http.HandlerFunc) error {
+	return c.registerAction(cmd, "", schema, schema, action)
+}
 
-	if c.Actions[cmd] != nil {
-		panic("DUPLICATED_ACTION: Action already exists")
+func (c *CloudFunction) registerAction(cmd string, service string, schema cvalid.ISchema, describeSchema cvalid.ISchema,
+	action http.HandlerFunc) error {
+	if isNilSchema(schema) {
+		schema = nil
+	}
+	if isNilSchema(describeSchema) {
+		describeSchema = nil
+	}
+
+	if action == nil {
+		return c.Actions.Register(&RegisteredAction{Cmd: cmd, Service: service})
 	}
 
 	// Hack!!! Wrapping action to preserve prototyping request
@@ -402,14 +879,12 @@ func (c *CloudFunction) RegisterAction(cmd string, schema *cvalid.Schema, action
 				params[k] = v
 			}
 
-			// Make copy of request
-			bodyBuf, bodyErr := ioutil.ReadAll(r.Body)
+			// Read the body keeping it in the request
+			bodyBuf, bodyErr := gcputil.CloudFunctionRequestHelper.ReadBody(r, c.getMaxBodySize())
 			if bodyErr != nil {
 				rpcserv.HttpResponseSender.SendError(w, r, bodyErr)
 				return
 			}
-			_ = r.Body.Close()
-			r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBuf))
 			//-------------------------
 			var body any
 			_ = json.Unmarshal(bodyBuf, &body)
@@ -426,7 +901,134 @@ func (c *CloudFunction) RegisterAction(cmd string, schema *cvalid.Schema, action
 		action(w, r)
 	}
 
-	c.Actions[cmd] = actionCurl
+	return c.Actions.Register(&RegisteredAction{
+		Cmd:     cmd,
+		Schema:  describeSchema,
+		Service: service,
+		Action:  actionCurl,
+	})
+}
+
+// Gets schemas of actions generated for commands by commandable services.
+// Command schemas describe request bodies, so they are wrapped to describe action parameters.
+func getCommandSchemas(service any) map[string]cvalid.ISchema {
+	schemas := make(map[string]cvalid.ISchema)
+
+	commandable, ok := service.(interface {
+		GetCommandSet() *ccomand.CommandSet
+		GenerateActionCmd(name string) string
+	})
+	if !ok || commandable.GetCommandSet() == nil {
+		return schemas
+	}
+
+	for _, command := range commandable.GetCommandSet().Commands() {
+		if schema := newCommandSchema(command); schema != nil {
+			schemas[commandable.GenerateActionCmd(command.Name())] = schema
+		}
+	}
+	return schemas
+}
+
+func newCommandSchema(command ccomand.ICommand) cvalid.ISchema {
+	_command, ok := command.(interface{ GetSchema() cvalid.ISchema })
+	if !ok || _command.GetSchema() == nil {
+		return nil
+	}
+	return cvalid.NewObjectSchema().WithOptionalProperty("body", _command.GetSchema())
+}
+
+// Checks if the schema is nil or a nil pointer to a schema
+func isNilSchema(schema cvalid.ISchema) bool {
+	if schema == nil {
+		return true
+	}
+	value := reflect.ValueOf(schema)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// Unregisters an action in this Google Function.
+//	Parameters:
+//		- cmd	a action/command name.
+//	Returns: true if the action was registered and false otherwise.
+func (c *CloudFunction) UnregisterAction(cmd string) bool {
+	return c.Actions.Unregister(cmd)
+}
+
+// Registers a CloudEvents handler in this Google Function.
+//	Parameters:
+//		- eventType	a type of events to handle, e.g. "google.cloud.storage.object.v1.finalized".
+//		- source	a regular expression to match event source or empty string to accept any source.
+//		- schema	a validation schema to validate event data.
+//		- handler	an event handler function that is called when a matching event is received.
+//
+// Deprecated: This method has been deprecated. Use CloudFunctionService instead.
+func (c *CloudFunction) RegisterEventHandler(eventType string, source string, schema *cvalid.Schema,
+	handler func(ctx context.Context, event *gcputil.CloudEvent) error) {
+	if eventType == "" {
+		panic("NO_EVENT_TYPE: eventType parameter is missing")
+	}
+
+	if handler == nil {
+		panic("NO_HANDLER: Missing event handler")
+	}
+
+	handlerWrapper := func(ctx context.Context, event *gcputil.CloudEvent) error {
+		timing := c.Instrument(ctx, event.CorrelationId(), eventType)
+
+		err := gcputil.CloudFunctionRequestHelper.ValidateCloudEventData(event, schema)
+		if err == nil {
+			err = handler(ctx, event)
+		}
+
+		timing.EndTiming(ctx, err)
+		return err
+	}
+
+	c.eventHandlers = append(c.eventHandlers, &gcpserv.CloudEventHandler{
+		Type:    eventType,
+		Source:  source,
+		Schema:  schema,
+		Handler: handlerWrapper,
+	})
+}
+
+// Executes CloudEvent received by this Google Function.
+// The event is dispatched to the first registered handler that matches
+// its type and source. On success the function responds with 204 status code.
+// This method can be overloaded in child classes
+// if they need to change the default behavior
+//	Parameters:
+//		- res the function response
+//		- req the function request
+func (c *CloudFunction) ExecuteEvent(res http.ResponseWriter, req *http.Request) {
+	event, err := gcputil.CloudFunctionRequestHelper.GetCloudEvent(req)
+	if err != nil {
+		err := cerr.NewBadRequestError(
+			c.GetCorrelationId(req),
+			"INVALID_EVENT",
+			"Invalid CloudEvent format",
+		).WithCause(err)
+
+		rpcserv.HttpResponseSender.SendError(res, req, err)
+		return
+	}
+
+	for _, handler := range c.eventHandlers {
+		if handler.Matches(event) {
+			err = handler.Handler(req.Context(), event)
+			rpcserv.HttpResponseSender.SendEmptyResult(res, req, err)
+			return
+		}
+	}
+
+	err = cerr.NewBadRequestError(
+		event.CorrelationId(),
+		"NO_EVENT_HANDLER",
+		"Handler for event "+event.Type+" was not found",
+	).WithDetails("source", event.Source)
+
+	rpcserv.HttpResponseSender.SendError(res, req, err)
 }
 
 // Executes this Google Function and returns the result.
@@ -461,8 +1063,26 @@ func (c *CloudFunction) Execute(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	action := c.Actions[cmd]
-	if action == nil {
+	action, ok := c.Actions.Get(cmd)
+	if !ok {
+		// Built-in catalog of actions, unless overriden by a registered action
+		if c.ActionsCatalog && (cmd == ActionsCatalogCmd || cmd == DescribeCmd) {
+			rpcserv.HttpResponseSender.SendResult(res, req, c.Actions.Describe(), nil)
+			return
+		}
+
+		// Built-in batch of actions
+		if cmd == gcputil.BatchCmd {
+			c.Batch.Execute(res, req, func(cmd string) (http.HandlerFunc, bool) {
+				action, ok := c.Actions.Get(cmd)
+				if !ok {
+					return nil, false
+				}
+				return action.Action, true
+			})
+			return
+		}
+
 		err = cerr.NewBadRequestError(
 			correlationId,
 			"NO_ACTION",
@@ -473,18 +1093,78 @@ func (c *CloudFunction) Execute(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	action(res, req)
+	action.Action(res, req)
 }
 
 func (c *CloudFunction) handler(res http.ResponseWriter, req *http.Request) {
-	// If already started then execute
-	if c.IsOpen() {
-		c.Execute(res, req)
+	// Start before execute
+	if err := c.OpenOnce(context.Background(), c.GetCorrelationId(req)); err != nil {
+		c.Logger().Error(req.Context(), c.GetCorrelationId(req), err, "Failed to start function")
+		err := cerr.NewInternalError(
+			c.GetCorrelationId(req),
+			"FUNCTION_NOT_STARTED",
+			"Function failed to start",
+		).WithStatus(http.StatusServiceUnavailable).WithCause(err)
+		rpcserv.HttpResponseSender.SendError(res, req, err)
 		return
 	}
-	// Start before execute
-	c.Run(req.Context())
-	c.Execute(res, req)
+
+	// Configuration is not reloaded while the request is handled
+	c.configMtx.RLock()
+	defer c.configMtx.RUnlock()
+
+	// Report panics in actions as errors instead of partial responses
+	defer func() {
+		if rec := recover(); rec != nil {
+			err, ok := rec.(error)
+			if !ok {
+				msg := cconv.StringConverter.ToString(rec)
+				err = errors.New(msg)
+			}
+			correlationId := c.GetCorrelationId(req)
+			c.Logger().Error(req.Context(), correlationId, err, "Action panics with error")
+			rpcserv.HttpResponseSender.SendError(res, req,
+				cerr.NewInternalError(correlationId, "ACTION_PANIC", "Action failed").WithCause(err))
+		}
+	}()
+
+	// Compress large responses when the caller accepts them.
+	// The buffered response is sent only when the request is served without panics.
+	if threshold := c.getCompressionThreshold(); threshold > 0 && gcputil.AcceptsGzip(req) {
+		writer := gcputil.NewCompressedResponseWriter(res, threshold)
+		c.serve(writer, req)
+		_ = writer.Close()
+		return
+	}
+
+	c.serve(res, req)
+}
+
+func (c *CloudFunction) serve(res http.ResponseWriter, req *http.Request) {
+	// Serve OpenAPI document when enabled
+	if c.isOpenApiRequest(req) {
+		c.sendOpenApiDocument(res, req)
+		return
+	}
+
+	// Correlate logs and traces with the request
+	ctx := req.Context()
+	if trace, ok := gcputil.CloudFunctionRequestHelper.GetTraceContext(req); ok {
+		ctx = gcputil.ContextWithTraceContext(ctx, trace)
+	}
+	req = req.WithContext(gcputil.CloudFunctionRequestHelper.StartSpan(ctx, gcputil.SpanKindServer))
+
+	// Read the body at once to limit its size and decompress it for actions
+	if _, err := gcputil.CloudFunctionRequestHelper.ReadBody(req, c.getMaxBodySize()); err != nil {
+		rpcserv.HttpResponseSender.SendError(res, req, err)
+		return
+	}
+
+	if gcputil.CloudFunctionRequestHelper.IsCloudEvent(req) {
+		c.ExecuteEvent(res, req)
+	} else {
+		c.Execute(res, req)
+	}
 }
 
 // Gets entry point into this Google Function.
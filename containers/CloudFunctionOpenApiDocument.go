@@ -0,0 +1,201 @@
+package containers
+
+import (
+	"encoding/json"
+
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+// CloudFunctionOpenApiDocument is an OpenAPI 3 document that describes actions
+// registered in Google Function.
+//
+// All actions are called by POST requests to the function URL, so the document
+// has a single operation. Its request body is one of the action schemas,
+// selected by the "cmd" discriminator property. Request body schemas are taken
+// from "body" property of action validation schemas or from command schemas
+// of commandable services. Errors are described by ErrorDescription schema.
+//
+// see CloudFunction.GetOpenApiDocument
+//
+//	Example:
+//		doc := containers.NewCloudFunctionOpenApiDocument("mygroup", "MyGroup Google Function", cloudFunction.Actions.List())
+//		doc.InfoVersion = "1.0.0"
+//		data, err := doc.ToJson()
+type CloudFunctionOpenApiDocument struct {
+	// OpenAPI version (default: "3.0.3")
+	Version string
+
+	InfoTitle       string
+	InfoDescription string
+	InfoVersion     string
+
+	Actions []*RegisteredAction
+}
+
+// Creates a new document.
+// Parameters:
+//		- title			a title of the API.
+//		- description	(optional) a description of the API.
+//		- actions		actions to describe.
+func NewCloudFunctionOpenApiDocument(title string, description string, actions []*RegisteredAction) *CloudFunctionOpenApiDocument {
+	if actions == nil {
+		actions = make([]*RegisteredAction, 0)
+	}
+
+	return &CloudFunctionOpenApiDocument{
+		Version:         "3.0.3",
+		InfoTitle:       title,
+		InfoDescription: description,
+		InfoVersion:     "1",
+		Actions:         actions,
+	}
+}
+
+// Converts the document into JSON.
+// Returns JSON bytes or error when the document cannot be serialized.
+func (c *CloudFunctionOpenApiDocument) ToJson() ([]byte, error) {
+	return json.Marshal(c.ToMap())
+}
+
+// Converts the document into a map that can be serialized into JSON or YAML.
+func (c *CloudFunctionOpenApiDocument) ToMap() map[string]any {
+	info := map[string]any{
+		"title":   c.InfoTitle,
+		"version": c.InfoVersion,
+	}
+	if c.InfoDescription != "" {
+		info["description"] = c.InfoDescription
+	}
+
+	schemas := map[string]any{
+		"ErrorDescription": c.createErrorSchema(),
+	}
+	refs := make([]any, 0, len(c.Actions))
+	mapping := make(map[string]any)
+	for _, action := range c.Actions {
+		ref := "#/components/schemas/" + action.Cmd
+		schemas[action.Cmd] = c.createActionSchema(action)
+		refs = append(refs, map[string]any{"$ref": ref})
+		mapping[action.Cmd] = ref
+	}
+
+	operation := map[string]any{
+		"operationId": "execute",
+		"summary":     "Executes an action selected by cmd property",
+		"requestBody": map[string]any{
+			"required": true,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": map[string]any{
+						"oneOf": refs,
+						"discriminator": map[string]any{
+							"propertyName": "cmd",
+							"mapping":      mapping,
+						},
+					},
+				},
+			},
+		},
+		"responses": c.createResponses(),
+	}
+
+	return map[string]any{
+		"openapi": c.Version,
+		"info":    info,
+		"paths": map[string]any{
+			"/": map[string]any{
+				"post": operation,
+			},
+		},
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+}
+
+func (c *CloudFunctionOpenApiDocument) createActionSchema(action *RegisteredAction) map[string]any {
+	schema := map[string]any{"type": "object"}
+
+	// Validation schemas describe request parameters with request body in "body" property
+	if params := gcputil.JsonSchemaConverter.ToJsonSchema(action.Schema); params != nil {
+		if properties, ok := params["properties"].(map[string]any); ok {
+			if body, ok := properties["body"].(map[string]any); ok && body["type"] == "object" {
+				schema = body
+			}
+		}
+	}
+
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		properties = make(map[string]any)
+		schema["properties"] = properties
+	}
+	properties["cmd"] = map[string]any{
+		"type": "string",
+		"enum": []string{action.Cmd},
+	}
+
+	required, _ := schema["required"].([]string)
+	schema["required"] = append([]string{"cmd"}, required...)
+
+	if action.Service != "" {
+		schema["x-service"] = action.Service
+	}
+
+	return schema
+}
+
+func (c *CloudFunctionOpenApiDocument) createErrorSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type":           map[string]any{"type": "string"},
+			"category":       map[string]any{"type": "string"},
+			"status":         map[string]any{"type": "integer", "format": "int32"},
+			"code":           map[string]any{"type": "string"},
+			"message":        map[string]any{"type": "string"},
+			"details":        map[string]any{"type": "object"},
+			"correlation_id": map[string]any{"type": "string"},
+			"cause":          map[string]any{"type": "string"},
+			"stack_trace":    map[string]any{"type": "string"},
+		},
+	}
+}
+
+func (c *CloudFunctionOpenApiDocument) createResponses() map[string]any {
+	responses := map[string]any{
+		"200": map[string]any{
+			"description": "Successful response",
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": map[string]any{},
+				},
+			},
+		},
+		"204": map[string]any{
+			"description": "Successful response without result",
+		},
+	}
+
+	errorResponses := map[string]string{
+		"400": "Invalid parameters or unknown action",
+		"401": "Unauthorized request",
+		"403": "Access denied",
+		"404": "Requested object was not found",
+		"409": "Conflict with the current state",
+		"500": "Internal error",
+		"503": "Function is not started or unavailable",
+	}
+	for status, description := range errorResponses {
+		responses[status] = map[string]any{
+			"description": description,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": map[string]any{"$ref": "#/components/schemas/ErrorDescription"},
+				},
+			},
+		}
+	}
+
+	return responses
+}
@@ -0,0 +1,256 @@
+package containers
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	gcplog "github.com/pip-services3-gox/pip-services3-gcp-gox/log"
+)
+
+// Default port to serve functions when PORT environment variable is not set
+const DefaultRunnerPort = 8080
+
+// CloudFunctionRunner serves Google Functions over HTTP to run them locally
+// the same way as they run in Google Cloud.
+//
+// The runner loads configuration of each function the same way as CloudFunction.Run does,
+// opens the functions and serves their handlers. Several functions can be mounted under different paths.
+// On SIGINT or SIGTERM signals, or when the context is canceled, the runner stops accepting new requests,
+// waits for active requests to complete and closes the functions.
+//
+// Functions with different configurations shall set their config paths by CloudFunction.SetConfigPath,
+// as CONFIG_PATH environment variable overrides config paths of all functions.
+//
+// The runner exposes liveness and readiness endpoints. The readiness endpoint returns 503 status code
+// until all functions are opened and after the shutdown begins.
+//
+// see CloudFunction
+//
+//	Example:
+//		func main() {
+//			runner := containers.NewCloudFunctionRunner()
+//			runner.AddFunction("/", NewMyCloudFunction().CloudFunction)
+//			runner.AddFunction("/admin", NewMyAdminCloudFunction().CloudFunction)
+//
+//			if err := runner.Run(context.Background()); err != nil {
+//				os.Exit(1)
+//			}
+//		}
+//
+//		// Calls are sent to http://localhost:8080/?cmd=mydata.get_data
+//		// and http://localhost:8080/admin?cmd=admin.get_status
+//
+type CloudFunctionRunner struct {
+	// The port to listen (default: PORT environment variable or 8080).
+	Port int
+	// The path of the liveness endpoint (default: "/healthz").
+	HealthPath string
+	// The path of the readiness endpoint (default: "/readyz").
+	ReadyPath string
+	// The timeout to complete active requests on shutdown (default: 10 seconds).
+	ShutdownTimeout time.Duration
+	// The logger to log runner messages (default: CloudLoggingLogger).
+	Logger clog.ILogger
+
+	paths     []string
+	functions map[string]*CloudFunction
+	ready     bool
+	addr      string
+	mtx       sync.RWMutex
+}
+
+// Creates a new instance of the runner.
+func NewCloudFunctionRunner() *CloudFunctionRunner {
+	port := DefaultRunnerPort
+	if value, err := strconv.Atoi(os.Getenv("PORT")); err == nil && value > 0 {
+		port = value
+	}
+
+	return &CloudFunctionRunner{
+		Port:            port,
+		HealthPath:      "/healthz",
+		ReadyPath:       "/readyz",
+		ShutdownTimeout: 10 * time.Second,
+		Logger:          gcplog.NewCloudLoggingLogger(),
+		paths:           make([]string, 0),
+		functions:       make(map[string]*CloudFunction),
+	}
+}
+
+// Mounts the function under the path.
+// Parameters:
+//		- path		a path to serve the function, "/" serves the function at the root.
+//		- function	a function to serve.
+func (c *CloudFunctionRunner) AddFunction(path string, function *CloudFunction) {
+	path = "/" + strings.Trim(path, "/")
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if _, ok := c.functions[path]; !ok {
+		c.paths = append(c.paths, path)
+	}
+	c.functions[path] = function
+}
+
+// Checks if all functions are opened and the runner accepts requests.
+func (c *CloudFunctionRunner) IsReady() bool {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.ready
+}
+
+// Gets the address the runner listens on, or empty string when the runner is not started.
+func (c *CloudFunctionRunner) Addr() string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.addr
+}
+
+// Gets the handler that routes requests to mounted functions
+// and serves liveness and readiness endpoints.
+func (c *CloudFunctionRunner) GetHandler() http.Handler {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(c.HealthPath, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte("OK"))
+	})
+
+	mux.HandleFunc(c.ReadyPath, func(res http.ResponseWriter, req *http.Request) {
+		if !c.IsReady() {
+			res.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = res.Write([]byte("Not ready"))
+			return
+		}
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte("OK"))
+	})
+
+	for _, path := range c.paths {
+		handler := c.functions[path].GetHandler()
+		if path == "/" {
+			mux.Handle("/", handler)
+			continue
+		}
+		mux.Handle(path, handler)
+		mux.Handle(path+"/", handler)
+	}
+
+	return mux
+}
+
+// Runs the mounted functions: loads their configurations, opens them and serves
+// them over HTTP until the context is canceled or the process receives a termination signal.
+//	Parameters:
+//		- ctx context.Context
+//	Returns: error or nil when the runner was stopped gracefully.
+func (c *CloudFunctionRunner) Run(ctx context.Context) error {
+	correlationId := "runner"
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c.mtx.RLock()
+	functions := make([]*CloudFunction, 0, len(c.paths))
+	for _, path := range c.paths {
+		functions = append(functions, c.functions[path])
+	}
+	c.mtx.RUnlock()
+
+	if len(functions) == 0 {
+		return cerr.NewConfigError(correlationId, "NO_FUNCTIONS", "No functions were added to the runner")
+	}
+
+	err := c.openFunctions(ctx, correlationId, functions)
+	if err != nil {
+		c.Logger.Fatal(ctx, correlationId, err, "Failed to start functions")
+		return err
+	}
+
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(c.Port))
+	if err != nil {
+		c.closeFunctions(ctx, correlationId, functions)
+		err = cerr.NewConnectionError(correlationId, "CANNOT_LISTEN", "Failed to listen on port "+strconv.Itoa(c.Port)).
+			WithCause(err)
+		c.Logger.Fatal(ctx, correlationId, err, "Failed to start functions")
+		return err
+	}
+
+	server := &http.Server{Handler: c.GetHandler()}
+
+	c.mtx.Lock()
+	c.addr = listener.Addr().String()
+	c.ready = true
+	c.mtx.Unlock()
+
+	c.Logger.Info(ctx, correlationId, "Serving functions at %s. Press Control-C to stop...", c.Addr())
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+
+	select {
+	case err = <-serveErr:
+	case <-ctx.Done():
+	}
+
+	c.mtx.Lock()
+	c.ready = false
+	c.mtx.Unlock()
+
+	// Complete active requests before closing the functions
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
+	defer cancel()
+	if shutdownErr := server.Shutdown(shutdownCtx); shutdownErr != nil && err == nil {
+		err = shutdownErr
+	}
+
+	c.closeFunctions(shutdownCtx, correlationId, functions)
+
+	c.mtx.Lock()
+	c.addr = ""
+	c.mtx.Unlock()
+
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		c.Logger.Error(shutdownCtx, correlationId, err, "Functions were stopped with error")
+		return err
+	}
+
+	c.Logger.Info(shutdownCtx, correlationId, "Goodbye!")
+	return nil
+}
+
+func (c *CloudFunctionRunner) openFunctions(ctx context.Context, correlationId string, functions []*CloudFunction) error {
+	for index, function := range functions {
+		err := function.OpenOnce(ctx, correlationId)
+		if err != nil {
+			c.closeFunctions(ctx, correlationId, functions[:index])
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CloudFunctionRunner) closeFunctions(ctx context.Context, correlationId string, functions []*CloudFunction) {
+	for _, function := range functions {
+		if err := function.Close(ctx, correlationId); err != nil {
+			c.Logger.Error(ctx, correlationId, err, "Failed to close function %s", function.Info().Name)
+		}
+	}
+}
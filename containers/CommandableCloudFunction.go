@@ -78,7 +78,7 @@ func (c *CommandableCloudFunction) registerCommandSet(commandSet *ccomand.Comman
 	for index := 0; index < len(commands); index++ {
 		command := commands[index]
 
-		c.RegisterAction(command.Name(), nil, func(w http.ResponseWriter, r *http.Request) {
+		err := c.registerAction(command.Name(), "", nil, newCommandSchema(command), func(w http.ResponseWriter, r *http.Request) {
 			correlationId := c.GetCorrelationId(r)
 			args := c.GetParameters(r)
 
@@ -88,6 +88,9 @@ func (c *CommandableCloudFunction) registerCommandSet(commandSet *ccomand.Comman
 
 			rpcserv.HttpResponseSender.SendResult(w, r, execRes, execErr)
 		})
+		if err != nil {
+			c.Logger().Error(context.Background(), "", err, "Failed to register action %s", command.Name())
+		}
 	}
 }
 
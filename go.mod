@@ -7,8 +7,10 @@ require (
 	github.com/pip-services3-gox/pip-services3-commons-gox v1.0.8
 	github.com/pip-services3-gox/pip-services3-components-gox v1.0.7
 	github.com/pip-services3-gox/pip-services3-container-gox v1.0.7
+	github.com/pip-services3-gox/pip-services3-expressions-gox v1.0.2
 	github.com/pip-services3-gox/pip-services3-rpc-gox v1.0.6
 	github.com/stretchr/testify v1.8.2
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
@@ -16,8 +18,6 @@ require (
 	github.com/felixge/httpsnoop v1.0.1 // indirect
 	github.com/google/uuid v1.3.0 // indirect
 	github.com/gorilla/handlers v1.5.1 // indirect
-	github.com/pip-services3-gox/pip-services3-expressions-gox v1.0.2 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
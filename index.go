@@ -1,9 +1,13 @@
 package gcp
 
 import (
+	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/build"
 	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
 	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/connect"
 	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/containers"
+	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/log"
+	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/otel"
 	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/services"
 	_ "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
 )
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+// CloudLoggingLogger is a logger that writes log messages to standard output
+// as structured JSON lines recognized by Google Cloud Logging.
+//
+// Each line contains "severity", "message", "time" and "logging.googleapis.com/labels" fields.
+// When the context carries trace context of the current request, the line is correlated
+// with the request trace via "logging.googleapis.com/trace" and "logging.googleapis.com/spanId" fields.
+// Errors are written with "error" field, and errors that carry stack traces are also written
+// with "stack_trace" field to be picked up by Error Reporting.
+//
+//	Configuration parameters:
+//		- level:		maximum log level to capture
+//		- source:		source (context) name
+//		- project_id:	Google Cloud project id to compose trace names
+//						(default: GOOGLE_CLOUD_PROJECT, GCP_PROJECT or GCLOUD_PROJECT environment variable)
+//		- labels:
+//			- <name>:	custom labels added to every log entry
+//
+//	References:
+//		- *:context-info:*:*:1.0 (optional) ContextInfo to detect the context id and specify counters source
+//
+// see TraceContext
+//
+//	Example:
+//		logger := log.NewCloudLoggingLogger()
+//		logger.Configure(ctx, config.NewConfigParamsFromTuples(
+//			"project_id", "myproject",
+//			"labels.service", "myservice",
+//		))
+//		logger.Error(ctx, "123", err, "Error occured: %s", err.Error())
+//
+type CloudLoggingLogger struct {
+	*clog.Logger
+
+	projectId string
+	labels    map[string]string
+	mtx       sync.Mutex
+
+	// The destination of log entries (default: os.Stdout).
+	Writer io.Writer
+}
+
+// Creates a new instance of the logger.
+func NewCloudLoggingLogger() *CloudLoggingLogger {
+	c := &CloudLoggingLogger{
+		projectId: getDefaultProjectId(),
+		labels:    make(map[string]string),
+		Writer:    os.Stdout,
+	}
+	c.Logger = clog.InheritLogger(c)
+	return c
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config ConfigParams configuration parameters to be set.
+func (c *CloudLoggingLogger) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.Logger.Configure(ctx, config)
+
+	c.projectId = config.GetAsStringWithDefault("project_id", c.projectId)
+
+	labels := config.GetSection("labels")
+	for _, key := range labels.Keys() {
+		c.labels[key] = labels.GetAsString(key)
+	}
+}
+
+// Gets Google Cloud project id used to compose trace names.
+func (c *CloudLoggingLogger) ProjectId() string {
+	return c.projectId
+}
+
+// Sets Google Cloud project id used to compose trace names.
+//	Parameters:
+//		- value	a project id.
+func (c *CloudLoggingLogger) SetProjectId(value string) {
+	c.projectId = value
+}
+
+// Write a log message to the logger destination.
+//	Parameters:
+//		- ctx context.Context
+//		- level LevelType a log level.
+//		- correlationId string transaction id to trace execution through call chain.
+//		- err error an error object associated with this message.
+//		- message string a human-readable message to log.
+func (c *CloudLoggingLogger) Write(ctx context.Context, level clog.LevelType, correlationId string, err error, message string) {
+	if c.Level() < level {
+		return
+	}
+
+	entry := map[string]any{
+		"severity": getSeverity(level),
+		"time":     time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	labels := make(map[string]string, len(c.labels)+2)
+	for key, value := range c.labels {
+		labels[key] = value
+	}
+	if source := c.Source(); source != "" {
+		labels["source"] = source
+	}
+	if correlationId != "" {
+		labels["correlation_id"] = correlationId
+	}
+	if len(labels) > 0 {
+		entry["logging.googleapis.com/labels"] = labels
+	}
+
+	if trace, ok := gcputil.TraceContextFromContext(ctx); ok {
+		if c.projectId != "" {
+			entry["logging.googleapis.com/trace"] = "projects/" + c.projectId + "/traces/" + trace.TraceId
+		}
+		if trace.SpanId != "" {
+			entry["logging.googleapis.com/spanId"] = trace.SpanId
+		}
+		entry["logging.googleapis.com/trace_sampled"] = trace.Sampled
+	}
+
+	if err != nil {
+		if message == "" {
+			message = "Error: " + c.ComposeError(err)
+		} else {
+			message = message + ": " + c.ComposeError(err)
+		}
+
+		errorInfo := map[string]any{"message": err.Error()}
+		stackTrace := ""
+		if appErr, ok := err.(*cerr.ApplicationError); ok {
+			errorInfo["message"] = appErr.Message
+			errorInfo["code"] = appErr.Code
+			errorInfo["category"] = appErr.Category
+			errorInfo["status"] = appErr.Status
+			if appErr.Cause != "" {
+				errorInfo["cause"] = appErr.Cause
+			}
+			stackTrace = appErr.StackTrace
+		}
+		entry["error"] = errorInfo
+		if stackTrace != "" {
+			entry["stack_trace"] = stackTrace
+			// Error Reporting expects the stack trace in the message
+			entry["@type"] = "type.googleapis.com/google.devtools.clouderrorreporting.v1beta1.ReportedErrorEvent"
+			message = message + "\n" + stackTrace
+		}
+	}
+	entry["message"] = message
+
+	line, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		line = []byte(fmt.Sprintf(`{"severity":"ERROR","message":%q}`, message))
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	_, _ = c.Writer.Write(append(line, '\n'))
+}
+
+func getSeverity(level clog.LevelType) string {
+	switch level {
+	case clog.LevelFatal:
+		return "CRITICAL"
+	case clog.LevelError:
+		return "ERROR"
+	case clog.LevelWarn:
+		return "WARNING"
+	case clog.LevelInfo:
+		return "INFO"
+	case clog.LevelDebug, clog.LevelTrace:
+		return "DEBUG"
+	default:
+		return "DEFAULT"
+	}
+}
+
+func getDefaultProjectId() string {
+	for _, name := range []string{"GOOGLE_CLOUD_PROJECT", "GCP_PROJECT", "GCLOUD_PROJECT"} {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
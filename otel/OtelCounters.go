@@ -0,0 +1,315 @@
+package otel
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	ccount "github.com/pip-services3-gox/pip-services3-components-gox/count"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+)
+
+// Aggregation temporality of cumulative sums defined by OTLP protocol
+const otlpAggregationTemporalityCumulative = 2
+
+// OtelCounters are performance counters that export collected measurements
+// as OpenTelemetry metrics to OpenTelemetry collectors over OTLP/HTTP protocol.
+//
+// Counters are mapped to metrics by their types:
+//		- Increment:			monotonic cumulative sum
+//		- LastValue:			gauge
+//		- Timestamp:			gauge with time in milliseconds since Unix epoch
+//		- Interval, Statistics:	summary with count, sum, min (0.0 quantile) and max (1.0 quantile)
+//
+// Counters are exported periodically and when the component is closed.
+// Cumulative measurements start when counters are created or reset, so the start time
+// of a counter that was reset since the last export is set to the time of that export.
+//
+//	Configuration parameters:
+//		- source:			source (context) name (default: OTEL_SERVICE_NAME environment variable)
+//		- interval:			interval in milliseconds to export counters (default: 5 mins)
+//		- reset_timeout:	timeout in milliseconds to reset the counters. 0 disables the reset (default: 5 mins)
+//		- connection:
+//			- uri:			OTLP/HTTP collector endpoint
+//							(default: OTEL_EXPORTER_OTLP_ENDPOINT environment variable or http://localhost:4318)
+//		- headers:
+//			- <name>:		headers sent with every export request
+//		- options:
+//			- timeout:		export request timeout in milliseconds (default: 10000)
+//
+//	References:
+//		- *:context-info:*:*:1.0	(optional) ContextInfo to detect the context id and specify counters source
+//		- *:logger:*:*:1.0			(optional) ILogger components to log export errors
+//
+//	Example:
+//		counters := otel.NewOtelCounters()
+//		counters.Configure(ctx, config.NewConfigParamsFromTuples(
+//			"source", "myservice",
+//			"connection.uri", "http://localhost:4318",
+//		))
+//		counters.Open(ctx, "123")
+//
+//		counters.IncrementOne(ctx, "mycomponent.mymethod.calls")
+//		timing := counters.BeginTiming(ctx, "mycomponent.mymethod.exec_time")
+//		...
+//		timing.EndTiming(ctx)
+//
+//		counters.Dump(ctx)
+//
+type OtelCounters struct {
+	*ccount.CachedCounters
+	exporter     *otlpExporter
+	logger       *clog.CompositeLogger
+	starts       map[string]*otelCounterStart
+	lastSaveTime time.Time
+	mtx          sync.Mutex
+}
+
+// Start of cumulative measurements of the counter
+type otelCounterStart struct {
+	counter *ccount.AtomicCounter
+	time    time.Time
+}
+
+// Creates a new instance of the performance counters.
+func NewOtelCounters() *OtelCounters {
+	c := &OtelCounters{
+		exporter:     newOtlpExporter(),
+		logger:       clog.NewCompositeLogger(),
+		starts:       make(map[string]*otelCounterStart),
+		lastSaveTime: time.Now(),
+	}
+	c.CachedCounters = ccount.InheritCacheCounters(c)
+	return c
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config ConfigParams configuration parameters to be set.
+func (c *OtelCounters) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.CachedCounters.Configure(ctx, config)
+	c.exporter.configure(config)
+}
+
+// SetReferences sets references to dependent components.
+//	Parameters:
+//		- ctx context.Context
+//		- references IReferences references to locate the component dependencies.
+func (c *OtelCounters) SetReferences(ctx context.Context, references crefer.IReferences) {
+	c.logger.SetReferences(ctx, references)
+	c.exporter.setReferences(references)
+}
+
+// IsOpen checks if the component is opened.
+//	Returns: true if the component has been opened and false otherwise.
+func (c *OtelCounters) IsOpen() bool {
+	return c.exporter.isOpen()
+}
+
+// Open opens the component.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *OtelCounters) Open(ctx context.Context, correlationId string) error {
+	c.exporter.open()
+	return nil
+}
+
+// Close closes component and exports collected measurements.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *OtelCounters) Close(ctx context.Context, correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	err := c.Dump(ctx)
+	c.exporter.close()
+	return err
+}
+
+// Save exports the current counters measurements.
+//	Parameters:
+//		- ctx context.Context
+//		- counters []Counter current counters measurements to be saved.
+//	Returns: error or nil no errors occured.
+func (c *OtelCounters) Save(ctx context.Context, counters []ccount.Counter) error {
+	if !c.IsOpen() || len(counters) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	starts, lastSaveTime := c.updateStartTimes(now)
+	metrics := make([]*otlpMetric, 0, len(counters))
+	for _, counter := range counters {
+		startTime, ok := starts[counter.Name]
+		if !ok {
+			startTime = lastSaveTime
+		}
+		metrics = append(metrics, c.toMetric(counter, startTime, now))
+	}
+
+	request := &otlpMetricsRequest{
+		ResourceMetrics: []*otlpResourceMetrics{
+			{
+				Resource: c.exporter.resource(),
+				ScopeMetrics: []*otlpScopeMetrics{
+					{
+						Scope:   otlpScope{Name: instrumentationScope},
+						Metrics: metrics,
+					},
+				},
+			},
+		},
+	}
+
+	err := c.exporter.send(ctx, "/v1/metrics", request)
+	if err != nil {
+		c.logger.Error(ctx, "", err, "Failed to export counters")
+	}
+	return err
+}
+
+// Gets start times of cumulative measurements of the counters. Cached counters are reset
+// by replacing them with new ones, so counters created since the last export start at its time.
+// Returns the start times by counter names and the time of the last export.
+func (c *OtelCounters) updateStartTimes(now time.Time) (map[string]time.Time, time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	lastSaveTime := c.lastSaveTime
+	starts := make(map[string]*otelCounterStart)
+	result := make(map[string]time.Time)
+	for _, counter := range c.GetAll() {
+		start, ok := c.starts[counter.Name()]
+		if !ok || start.counter != counter {
+			start = &otelCounterStart{counter: counter, time: lastSaveTime}
+		}
+		starts[counter.Name()] = start
+		result[counter.Name()] = start.time
+	}
+
+	c.starts = starts
+	c.lastSaveTime = now
+	return result, lastSaveTime
+}
+
+func (c *OtelCounters) toMetric(counter ccount.Counter, startTime time.Time, now time.Time) *otlpMetric {
+	metric := &otlpMetric{Name: counter.Name}
+	timestamp := formatUnixNano(now)
+
+	switch counter.Type {
+	case ccount.Increment:
+		value := counter.Count
+		metric.Sum = &otlpSum{
+			AggregationTemporality: otlpAggregationTemporalityCumulative,
+			IsMonotonic:            true,
+			DataPoints: []*otlpNumberDataPoint{
+				{
+					StartTimeUnixNano: formatUnixNano(startTime),
+					TimeUnixNano:      timestamp,
+					AsInt:             formatInt(value),
+				},
+			},
+		}
+	case ccount.LastValue:
+		value := counter.Last
+		metric.Gauge = &otlpGauge{
+			DataPoints: []*otlpNumberDataPoint{
+				{TimeUnixNano: timestamp, AsDouble: &value},
+			},
+		}
+	case ccount.Timestamp:
+		value := counter.Time.UnixMilli()
+		metric.Unit = "ms"
+		metric.Gauge = &otlpGauge{
+			DataPoints: []*otlpNumberDataPoint{
+				{TimeUnixNano: timestamp, AsInt: formatInt(value)},
+			},
+		}
+	default:
+		point := &otlpSummaryDataPoint{
+			StartTimeUnixNano: formatUnixNano(startTime),
+			TimeUnixNano:      timestamp,
+			Count:             formatInt(counter.Count),
+			Sum:               counter.Average * float64(counter.Count),
+			QuantileValues:    make([]*otlpQuantileValue, 0, 2),
+		}
+		// Min and max are not set until the first measurement
+		if counter.Min != math.MaxFloat64 {
+			point.QuantileValues = append(point.QuantileValues, &otlpQuantileValue{Quantile: 0, Value: counter.Min})
+		}
+		if counter.Max != -math.MaxFloat64 {
+			point.QuantileValues = append(point.QuantileValues, &otlpQuantileValue{Quantile: 1, Value: counter.Max})
+		}
+		if counter.Type == ccount.Interval {
+			metric.Unit = "ms"
+		}
+		metric.Summary = &otlpSummary{DataPoints: []*otlpSummaryDataPoint{point}}
+	}
+
+	return metric
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []*otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     *otlpResource       `json:"resource"`
+	ScopeMetrics []*otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope     `json:"scope"`
+	Metrics []*otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name    string       `json:"name"`
+	Unit    string       `json:"unit,omitempty"`
+	Sum     *otlpSum     `json:"sum,omitempty"`
+	Gauge   *otlpGauge   `json:"gauge,omitempty"`
+	Summary *otlpSummary `json:"summary,omitempty"`
+}
+
+type otlpSum struct {
+	AggregationTemporality int                    `json:"aggregationTemporality"`
+	IsMonotonic            bool                   `json:"isMonotonic"`
+	DataPoints             []*otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpGauge struct {
+	DataPoints []*otlpNumberDataPoint `json:"dataPoints"`
+}
+
+type otlpNumberDataPoint struct {
+	StartTimeUnixNano string   `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string   `json:"timeUnixNano"`
+	AsInt             *string  `json:"asInt,omitempty"`
+	AsDouble          *float64 `json:"asDouble,omitempty"`
+}
+
+type otlpSummary struct {
+	DataPoints []*otlpSummaryDataPoint `json:"dataPoints"`
+}
+
+type otlpSummaryDataPoint struct {
+	StartTimeUnixNano string               `json:"startTimeUnixNano"`
+	TimeUnixNano      string               `json:"timeUnixNano"`
+	Count             *string              `json:"count"`
+	Sum               float64              `json:"sum"`
+	QuantileValues    []*otlpQuantileValue `json:"quantileValues"`
+}
+
+type otlpQuantileValue struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
@@ -0,0 +1,354 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	ctrace "github.com/pip-services3-gox/pip-services3-components-gox/trace"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+// Span kinds defined by OTLP protocol
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+)
+
+// Span status codes defined by OTLP protocol
+const (
+	otlpStatusOk    = 1
+	otlpStatusError = 2
+)
+
+// OtelTracer is a tracer that records traced operations as OpenTelemetry spans
+// and exports them to OpenTelemetry collectors over OTLP/HTTP protocol.
+//
+// Each traced operation is recorded as the span stored in the context: containers and services
+// record server spans of incoming requests and clients record client spans of outgoing calls.
+// Each span is recorded once, so operations traced in the same context after that
+// are recorded as its child spans with new span ids.
+// Operations traced in a context without trace context are recorded as new root spans.
+// Spans carry "cmd" and "correlation_id" attributes, and failed spans
+// carry "http.response.status_code" and "error.type" attributes taken from application errors.
+//
+// Spans are buffered and exported in background when the batch is full or when the export
+// interval elapses, and they are exported at once by Flush or when the tracer is closed.
+// Spans that failed to export are kept in the buffer to export them again. When the buffer
+// is full, the oldest spans are dropped. Cloud Functions throttle CPU between requests,
+// so call Flush to export spans before responses are sent.
+//
+//	Configuration parameters:
+//		- source:			source (context) name (default: OTEL_SERVICE_NAME environment variable)
+//		- connection:
+//			- uri:			OTLP/HTTP collector endpoint
+//							(default: OTEL_EXPORTER_OTLP_ENDPOINT environment variable or http://localhost:4318)
+//		- headers:
+//			- <name>:		headers sent with every export request
+//		- options:
+//			- batch_size:	number of buffered spans to export them (default: 100)
+//			- max_queue_size:	maximum number of buffered spans (default: 2048)
+//			- interval:		interval in milliseconds to export buffered spans (default: 10000)
+//			- timeout:		export request timeout in milliseconds (default: 10000)
+//
+//	References:
+//		- *:context-info:*:*:1.0	(optional) ContextInfo to detect the context id and specify trace source
+//		- *:logger:*:*:1.0			(optional) ILogger components to log export errors
+//
+// see TraceContext
+//
+//	Example:
+//		tracer := otel.NewOtelTracer()
+//		tracer.Configure(ctx, config.NewConfigParamsFromTuples(
+//			"source", "myservice",
+//			"connection.uri", "http://localhost:4318",
+//		))
+//		tracer.Open(ctx, "123")
+//
+//		timing := tracer.BeginTrace(ctx, "123", "mycomponent", "mymethod")
+//		...
+//		timing.EndTrace(ctx)
+//
+type OtelTracer struct {
+	exporter     *otlpExporter
+	logger       *clog.CompositeLogger
+	batchSize    int
+	maxQueueSize int
+	interval     int64
+	spans        []*otlpSpan
+	lastExported time.Time
+	exporting    bool
+	mtx          sync.Mutex
+}
+
+// Creates a new instance of the tracer.
+func NewOtelTracer() *OtelTracer {
+	return &OtelTracer{
+		exporter:     newOtlpExporter(),
+		logger:       clog.NewCompositeLogger(),
+		batchSize:    100,
+		maxQueueSize: 2048,
+		interval:     10000,
+		spans:        make([]*otlpSpan, 0),
+		lastExported: time.Now(),
+	}
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- ctx context.Context
+//		- config ConfigParams configuration parameters to be set.
+func (c *OtelTracer) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.exporter.configure(config)
+	c.batchSize = config.GetAsIntegerWithDefault("options.batch_size", c.batchSize)
+	c.maxQueueSize = config.GetAsIntegerWithDefault("options.max_queue_size", c.maxQueueSize)
+	c.interval = config.GetAsLongWithDefault("options.interval", c.interval)
+}
+
+// SetReferences sets references to dependent components.
+//	Parameters:
+//		- ctx context.Context
+//		- references IReferences references to locate the component dependencies.
+func (c *OtelTracer) SetReferences(ctx context.Context, references crefer.IReferences) {
+	c.logger.SetReferences(ctx, references)
+	c.exporter.setReferences(references)
+}
+
+// IsOpen checks if the component is opened.
+//	Returns: true if the component has been opened and false otherwise.
+func (c *OtelTracer) IsOpen() bool {
+	return c.exporter.isOpen()
+}
+
+// Open opens the component.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *OtelTracer) Open(ctx context.Context, correlationId string) error {
+	c.exporter.open()
+	return nil
+}
+
+// Close closes component and exports buffered spans.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *OtelTracer) Close(ctx context.Context, correlationId string) error {
+	if !c.IsOpen() {
+		return nil
+	}
+
+	err := c.Flush(ctx)
+	c.exporter.close()
+	return err
+}
+
+// Trace records an operation trace with its name and duration
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//		- component string a name of called component
+//		- operation string a name of the executed operation.
+//		- duration int64 execution duration in milliseconds.
+func (c *OtelTracer) Trace(ctx context.Context, correlationId string, component string, operation string, duration int64) {
+	c.record(ctx, correlationId, component, operation, nil, duration)
+}
+
+// Failure records an operation failure with its name, duration and error
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//		- component string a name of called component
+//		- operation string a name of the executed operation.
+//		- err error an error object associated with this trace.
+//		- duration int64 execution duration in milliseconds.
+func (c *OtelTracer) Failure(ctx context.Context, correlationId string, component string, operation string, err error, duration int64) {
+	c.record(ctx, correlationId, component, operation, err, duration)
+}
+
+// BeginTrace begins recording an operation trace
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId string (optional) transaction id to trace execution through call chain.
+//		- component string a name of called component
+//		- operation string a name of the executed operation.
+//	Returns: a trace timing object.
+func (c *OtelTracer) BeginTrace(ctx context.Context, correlationId string, component string, operation string) *ctrace.TraceTiming {
+	return ctrace.NewTraceTiming(correlationId, component, operation, c)
+}
+
+// Flush exports all buffered spans. Spans that failed to export are kept in the buffer.
+//	Parameters:
+//		- ctx context.Context
+//	Returns: error or nil no errors occured.
+func (c *OtelTracer) Flush(ctx context.Context) error {
+	c.mtx.Lock()
+	spans := c.spans
+	c.spans = make([]*otlpSpan, 0)
+	c.lastExported = time.Now()
+	c.mtx.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	request := &otlpTraceRequest{
+		ResourceSpans: []*otlpResourceSpans{
+			{
+				Resource: c.exporter.resource(),
+				ScopeSpans: []*otlpScopeSpans{
+					{
+						Scope: otlpScope{Name: instrumentationScope},
+						Spans: spans,
+					},
+				},
+			},
+		},
+	}
+
+	err := c.exporter.send(ctx, "/v1/traces", request)
+	if err != nil {
+		c.mtx.Lock()
+		c.spans = c.limitSpans(append(spans, c.spans...))
+		c.mtx.Unlock()
+	}
+	return err
+}
+
+// Keeps the latest spans within the maximum size of the buffer
+func (c *OtelTracer) limitSpans(spans []*otlpSpan) []*otlpSpan {
+	if c.maxQueueSize > 0 && len(spans) > c.maxQueueSize {
+		return spans[len(spans)-c.maxQueueSize:]
+	}
+	return spans
+}
+
+// Exports buffered spans in background, so requests do not wait for the collector
+func (c *OtelTracer) export() {
+	defer func() {
+		c.mtx.Lock()
+		c.exporting = false
+		c.mtx.Unlock()
+	}()
+
+	ctx := context.Background()
+	if err := c.Flush(ctx); err != nil {
+		c.logger.Error(ctx, "", err, "Failed to export spans")
+	}
+}
+
+func (c *OtelTracer) record(ctx context.Context, correlationId string, component string, operation string, err error, duration int64) {
+	name := component
+	if operation != "" {
+		name += "." + operation
+	}
+
+	trace, ok := gcputil.TraceContextFromContext(ctx)
+	if !ok {
+		trace = gcputil.NewTraceContext(true)
+	} else if trace.SpanId == "" {
+		trace = trace.NewChildSpan(trace.Kind)
+	} else if !trace.MarkRecorded() {
+		// The span of the context was recorded by another operation
+		trace = trace.NewChildSpan(gcputil.SpanKindInternal)
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Duration(duration) * time.Millisecond)
+
+	span := &otlpSpan{
+		TraceId:           trace.TraceId,
+		SpanId:            trace.SpanId,
+		ParentSpanId:      trace.ParentSpanId,
+		Name:              name,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: formatUnixNano(start),
+		EndTimeUnixNano:   formatUnixNano(end),
+		Attributes: []*otlpAttribute{
+			newStringAttribute("cmd", name),
+		},
+		Status: otlpStatus{Code: otlpStatusOk},
+	}
+
+	switch trace.Kind {
+	case gcputil.SpanKindServer:
+		span.Kind = otlpSpanKindServer
+	case gcputil.SpanKindClient:
+		span.Kind = otlpSpanKindClient
+	}
+
+	if correlationId != "" {
+		span.Attributes = append(span.Attributes, newStringAttribute("correlation_id", correlationId))
+	}
+
+	if err != nil {
+		status := int64(http.StatusInternalServerError)
+		errorType := "UNKNOWN"
+		var appErr *cerr.ApplicationError
+		if errors.As(err, &appErr) {
+			status = int64(appErr.Status)
+			errorType = appErr.Code
+		}
+
+		span.Attributes = append(span.Attributes,
+			newIntAttribute("http.response.status_code", status),
+			newStringAttribute("error.type", errorType),
+		)
+		span.Status = otlpStatus{Code: otlpStatusError, Message: err.Error()}
+	}
+
+	opened := c.IsOpen()
+
+	c.mtx.Lock()
+	c.spans = c.limitSpans(append(c.spans, span))
+	export := opened && !c.exporting && (len(c.spans) >= c.batchSize ||
+		time.Since(c.lastExported) >= time.Duration(c.interval)*time.Millisecond)
+	if export {
+		c.exporting = true
+	}
+	c.mtx.Unlock()
+
+	if export {
+		go c.export()
+	}
+}
+
+type otlpTraceRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   *otlpResource     `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpSpan struct {
+	TraceId           string           `json:"traceId"`
+	SpanId            string           `json:"spanId"`
+	ParentSpanId      string           `json:"parentSpanId,omitempty"`
+	Name              string           `json:"name"`
+	Kind              int              `json:"kind"`
+	StartTimeUnixNano string           `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string           `json:"endTimeUnixNano"`
+	Attributes        []*otlpAttribute `json:"attributes"`
+	Status            otlpStatus       `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,166 @@
+package otel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	cinfo "github.com/pip-services3-gox/pip-services3-components-gox/info"
+)
+
+// Default endpoint of OTLP/HTTP collector
+const DefaultOtlpEndpoint = "http://localhost:4318"
+
+// Name of the instrumentation scope reported with exported telemetry
+const instrumentationScope = "github.com/pip-services3-gox/pip-services3-gcp-gox/otel"
+
+// otlpExporter sends telemetry to OpenTelemetry collectors
+// over OTLP/HTTP protocol with JSON encoding.
+type otlpExporter struct {
+	source   string
+	endpoint string
+	headers  map[string]string
+	timeout  int64
+	client   *http.Client
+}
+
+func newOtlpExporter() *otlpExporter {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		endpoint = DefaultOtlpEndpoint
+	}
+
+	return &otlpExporter{
+		source:   os.Getenv("OTEL_SERVICE_NAME"),
+		endpoint: endpoint,
+		headers:  make(map[string]string),
+		timeout:  10000,
+	}
+}
+
+func (c *otlpExporter) configure(config *cconf.ConfigParams) {
+	c.source = config.GetAsStringWithDefault("source", c.source)
+	c.endpoint = config.GetAsStringWithDefault("connection.uri", c.endpoint)
+	c.timeout = config.GetAsLongWithDefault("options.timeout", c.timeout)
+
+	headers := config.GetSection("headers")
+	for _, key := range headers.Keys() {
+		c.headers[key] = headers.GetAsString(key)
+	}
+}
+
+func (c *otlpExporter) setReferences(references crefer.IReferences) {
+	ref := references.GetOneOptional(
+		crefer.NewDescriptor("pip-services", "context-info", "*", "*", "1.0"),
+	)
+	if contextInfo, ok := ref.(*cinfo.ContextInfo); ok && contextInfo != nil && c.source == "" {
+		c.source = contextInfo.Name
+	}
+}
+
+func (c *otlpExporter) open() {
+	if c.client == nil {
+		c.client = &http.Client{
+			Timeout: time.Duration(c.timeout) * time.Millisecond,
+		}
+	}
+}
+
+func (c *otlpExporter) close() {
+	if c.client != nil {
+		c.client.CloseIdleConnections()
+		c.client = nil
+	}
+}
+
+func (c *otlpExporter) isOpen() bool {
+	return c.client != nil
+}
+
+func (c *otlpExporter) resource() *otlpResource {
+	attributes := []*otlpAttribute{
+		newStringAttribute("cloud.provider", "gcp"),
+	}
+	if c.source != "" {
+		attributes = append(attributes, newStringAttribute("service.name", c.source))
+	}
+	return &otlpResource{Attributes: attributes}
+}
+
+func (c *otlpExporter) send(ctx context.Context, path string, payload any) error {
+	if c.client == nil {
+		return cerr.NewInvalidStateError("", "NOT_OPENED", "OTLP exporter is not opened")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	uri := strings.TrimSuffix(c.endpoint, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return cerr.NewConnectionError("", "CANNOT_EXPORT", "Failed to export telemetry to "+uri).
+			WithCause(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return cerr.NewConnectionError("", "CANNOT_EXPORT", "Failed to export telemetry to "+uri).
+			WithDetails("status", res.StatusCode)
+	}
+	return nil
+}
+
+type otlpResource struct {
+	Attributes []*otlpAttribute `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+}
+
+func newStringAttribute(key string, value string) *otlpAttribute {
+	return &otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: &value}}
+}
+
+func newIntAttribute(key string, value int64) *otlpAttribute {
+	return &otlpAttribute{Key: key, Value: otlpAttributeValue{IntValue: formatInt(value)}}
+}
+
+func formatUnixNano(value time.Time) string {
+	return strconv.FormatInt(value.UnixNano(), 10)
+}
+
+// 64-bit integers are encoded as strings in OTLP/JSON
+func formatInt(value int64) *string {
+	text := strconv.FormatInt(value, 10)
+	return &text
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+)
+
+// BatchExecutor executes batches of actions received in a single function invocation.
+//
+// Each call in a batch is executed as a separate request to the action with the same headers,
+// so the calls pass through the same authorization, validation and instrumentation as regular calls.
+// The calls are executed in order, or in parallel up to the configured limit when the batch requests it.
+// Results are returned in the same order as the calls with their status codes, results and errors,
+// so failures of some calls do not fail the whole batch.
+//
+//	Configuration parameters:
+//		- options:
+//			- batch:
+//				- max_items:	maximum number of calls in a batch (default: 100)
+//				- max_parallel:	maximum number of calls executed in parallel (default: 10)
+//
+// see gcputil.BatchRequest
+type BatchExecutor struct {
+	// The maximum number of calls in a batch.
+	MaxItems int
+	// The maximum number of calls executed in parallel.
+	MaxParallel int
+}
+
+// Creates a new instance of the executor.
+func NewBatchExecutor() *BatchExecutor {
+	return &BatchExecutor{
+		MaxItems:    100,
+		MaxParallel: 10,
+	}
+}
+
+// Configure configures component by passing configuration parameters.
+//	Parameters:
+//		- config ConfigParams configuration parameters to be set.
+func (c *BatchExecutor) Configure(config *cconf.ConfigParams) {
+	c.MaxItems = config.GetAsIntegerWithDefault("options.batch.max_items", c.MaxItems)
+	c.MaxParallel = config.GetAsIntegerWithDefault("options.batch.max_parallel", c.MaxParallel)
+}
+
+// Executes a batch request and sends results of the calls.
+// Parameters:
+//		- w			a response writer.
+//		- r			a batch request.
+//		- resolve	a function that finds an action by its command.
+func (c *BatchExecutor) Execute(w http.ResponseWriter, r *http.Request, resolve func(cmd string) (http.HandlerFunc, bool)) {
+	correlationId := gcputil.CloudFunctionRequestHelper.GetCorrelationId(r)
+
+	var batch gcputil.BatchRequest
+	if err := gcputil.CloudFunctionRequestHelper.DecodeBody(r, &batch); err != nil {
+		err := cerr.NewBadRequestError(correlationId, "INVALID_JSON", "Invalid json format").WithCause(err)
+		rpcserv.HttpResponseSender.SendError(w, r, err)
+		return
+	}
+
+	if len(batch.Batch) == 0 {
+		err := cerr.NewBadRequestError(correlationId, "NO_BATCH", "Batch parameter is missing or empty")
+		rpcserv.HttpResponseSender.SendError(w, r, err)
+		return
+	}
+
+	if c.MaxItems > 0 && len(batch.Batch) > c.MaxItems {
+		err := cerr.NewBadRequestError(correlationId, "BATCH_TOO_LARGE", "Batch has too many calls").
+			WithDetails("count", len(batch.Batch)).
+			WithDetails("max_items", c.MaxItems)
+		rpcserv.HttpResponseSender.SendError(w, r, err)
+		return
+	}
+
+	results := make([]*gcputil.BatchItemResult, len(batch.Batch))
+
+	parallel := 1
+	if batch.Parallel && c.MaxParallel > 1 {
+		parallel = c.MaxParallel
+	}
+
+	if parallel == 1 {
+		for index, item := range batch.Batch {
+			results[index] = c.executeItem(r, correlationId, item, resolve)
+		}
+	} else {
+		var wg sync.WaitGroup
+		semaphore := make(chan struct{}, parallel)
+		for index, item := range batch.Batch {
+			wg.Add(1)
+			semaphore <- struct{}{}
+			go func(index int, item *gcputil.BatchItem) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+				results[index] = c.executeItem(r, correlationId, item, resolve)
+			}(index, item)
+		}
+		wg.Wait()
+	}
+
+	rpcserv.HttpResponseSender.SendResult(w, r, results, nil)
+}
+
+func (c *BatchExecutor) executeItem(r *http.Request, correlationId string, item *gcputil.BatchItem,
+	resolve func(cmd string) (http.HandlerFunc, bool)) (result *gcputil.BatchItemResult) {
+	if item == nil {
+		item = &gcputil.BatchItem{}
+	}
+
+	if item.Cmd == "" {
+		err := cerr.NewBadRequestError(correlationId, "NO_COMMAND", "Cmd parameter is missing")
+		return newBatchErrorResult(item.Cmd, err)
+	}
+
+	if item.Cmd == gcputil.BatchCmd || strings.HasSuffix(item.Cmd, "."+gcputil.BatchCmd) {
+		err := cerr.NewBadRequestError(correlationId, "NESTED_BATCH", "Batches cannot be nested")
+		return newBatchErrorResult(item.Cmd, err)
+	}
+
+	action, ok := resolve(item.Cmd)
+	if !ok {
+		err := cerr.NewBadRequestError(correlationId, "NO_ACTION", "Action "+item.Cmd+" was not found")
+		return newBatchErrorResult(item.Cmd, err)
+	}
+
+	req, err := newBatchItemRequest(r, correlationId, item)
+	if err != nil {
+		return newBatchErrorResult(item.Cmd, err)
+	}
+
+	// Report panics as failures of the call, not the whole batch
+	defer func() {
+		if r := recover(); r != nil {
+			err := cerr.NewInternalError(correlationId, "ACTION_PANIC", "Action "+item.Cmd+" panicked").
+				WithDetails("panic", fmt.Sprint(r))
+			result = newBatchErrorResult(item.Cmd, err)
+		}
+	}()
+
+	res := newBatchResponseWriter()
+	action(res, req)
+	return res.toResult(item.Cmd, correlationId)
+}
+
+func newBatchItemRequest(r *http.Request, correlationId string, item *gcputil.BatchItem) (*http.Request, error) {
+	args := make(map[string]any, len(item.Args)+2)
+	for k, v := range item.Args {
+		args[k] = v
+	}
+	args["cmd"] = item.Cmd
+	if _, ok := args["correlation_id"]; !ok && correlationId != "" {
+		args["correlation_id"] = correlationId
+	}
+
+	body, err := json.Marshal(args)
+	if err != nil {
+		return nil, cerr.NewBadRequestError(correlationId, "INVALID_ARGS", "Invalid arguments of "+item.Cmd).
+			WithCause(err)
+	}
+
+	req := r.Clone(r.Context())
+	url := *r.URL
+	query := url.Query()
+	query.Set("cmd", item.Cmd)
+	url.RawQuery = query.Encode()
+	req.URL = &url
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+func newBatchErrorResult(cmd string, err error) *gcputil.BatchItemResult {
+	description := cerr.ErrorDescriptionFactory.Create(err)
+	return &gcputil.BatchItemResult{
+		Cmd:    cmd,
+		Status: description.Status,
+		Error:  description,
+	}
+}
+
+// Collects responses of calls in a batch
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBatchResponseWriter() *batchResponseWriter {
+	return &batchResponseWriter{header: make(http.Header)}
+}
+
+func (c *batchResponseWriter) Header() http.Header {
+	return c.header
+}
+
+func (c *batchResponseWriter) Write(data []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	return c.body.Write(data)
+}
+
+func (c *batchResponseWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+func (c *batchResponseWriter) toResult(cmd string, correlationId string) *gcputil.BatchItemResult {
+	result := &gcputil.BatchItemResult{Cmd: cmd, Status: c.status}
+	if result.Status == 0 {
+		result.Status = http.StatusOK
+	}
+
+	body := bytes.TrimSpace(c.body.Bytes())
+
+	if result.Status >= 400 {
+		description := &cerr.ErrorDescription{}
+		if err := json.Unmarshal(body, description); err != nil || description.Code == "" && description.Message == "" {
+			description = &cerr.ErrorDescription{
+				Type:          "Application",
+				Category:      "Application",
+				Message:       string(body),
+				CorrelationId: correlationId,
+			}
+		}
+		description.Status = result.Status
+		result.Error = description
+		return result
+	}
+
+	if len(body) > 0 {
+		if json.Valid(body) {
+			result.Result = json.RawMessage(body)
+		} else {
+			result.Result, _ = json.Marshal(string(body))
+		}
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"regexp"
+
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+type CloudEventHandler struct {
+	// Type of events processed by the handler
+	Type string
+	// Regular expression to match event source or empty string to match any source
+	Source string
+	// Regular expression to match event subject or empty string to match any subject
+	Subject string
+	// Function to match other event attributes or nil to match any event
+	Filter func(event *gcputil.CloudEvent) bool
+	// Schema to validate event data
+	Schema *cvalid.Schema
+	// Handler to be executed
+	Handler func(ctx context.Context, event *gcputil.CloudEvent) error
+}
+
+// Checks if the handler accepts the given event by its type, source and subject.
+// Parameters:
+//		- event	a CloudEvent to check
+// Returns true if the event shall be processed by this handler
+func (c *CloudEventHandler) Matches(event *gcputil.CloudEvent) bool {
+	if c.Type != event.Type {
+		return false
+	}
+	if c.Source != "" {
+		if matched, _ := regexp.MatchString(c.Source, event.Source); !matched {
+			return false
+		}
+	}
+	if c.Subject != "" {
+		if matched, _ := regexp.MatchString(c.Subject, event.Subject); !matched {
+			return false
+		}
+	}
+	if c.Filter != nil && !c.Filter(event) {
+		return false
+	}
+	return true
+}
@@ -11,6 +11,9 @@ type CloudFunctionAction struct {
 	Cmd string
 	// Schema to validate action parameters
 	Schema *cvalid.Schema
+	// Schema of action parameters, e.g. ObjectSchema, to describe them in the actions catalog.
+	// It is set by RegisterActionWithSchema, and it is nil for actions registered with Schema only.
+	ParamsSchema cvalid.ISchema
 	// Action to be executed
 	Action http.HandlerFunc
 }
@@ -1,18 +1,19 @@
 package services
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"reflect"
 	"regexp"
+	"strings"
 
 	"net/http"
 
 	"github.com/gorilla/mux"
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
 	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
 	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
 	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
 	ccount "github.com/pip-services3-gox/pip-services3-components-gox/count"
@@ -31,9 +32,17 @@ type ICloudFunctionServiceOverrides interface {
 // This service is intended to work inside CloudFunction container that
 // exposes registered actions externally.
 //
+// Registered actions run in a pipeline: panic recovery, authorization,
+// validation, interceptors and then the action itself (see ApplyPipeline).
+//
 // 	Configuration parameters
 // 		- dependencies:
 //			- controller:	override for Controller dependency
+//		- options:
+//			- max_body_size:	maximum size of request bodies in bytes read to validate them, 0 to read any size (default: 32 MB)
+//			- batch:
+//				- max_items:	maximum number of calls in a batch (default: 100)
+//				- max_parallel:	maximum number of calls executed in parallel (default: 10)
 //
 // 	References
 //		- *:logger:*:*:1.0			(optional) ILogger components to pass log messages
@@ -99,10 +108,11 @@ type ICloudFunctionServiceOverrides interface {
 //		fmt.Println("The Google Function service is running")
 //
 type CloudFunctionService struct {
-	name         string
-	actions      []*CloudFunctionAction
-	interceptors []func(http.ResponseWriter, *http.Request, http.HandlerFunc)
-	opened       bool
+	name          string
+	actions       []*CloudFunctionAction
+	eventHandlers []*CloudEventHandler
+	interceptors  []func(http.ResponseWriter, *http.Request, http.HandlerFunc)
+	opened        bool
 
 	Overrides ICloudFunctionServiceOverrides
 	// The dependency resolver.
@@ -113,6 +123,10 @@ type CloudFunctionService struct {
 	Counters *ccount.CompositeCounters
 	// The tracer.
 	Tracer *ctrace.CompositeTracer
+	// The executor of batch calls.
+	Batch *BatchExecutor
+	// The maximum size of request bodies in bytes, 0 to accept any size.
+	MaxBodySize int64
 }
 
 // Creates an instance of this service.
@@ -122,12 +136,15 @@ func NewCloudFunctionService(name string) *CloudFunctionService {
 	c := CloudFunctionService{
 		name:               name,
 		actions:            make([]*CloudFunctionAction, 0),
+		eventHandlers:      make([]*CloudEventHandler, 0),
 		interceptors:       make([]func(http.ResponseWriter, *http.Request, http.HandlerFunc), 0),
 		opened:             false,
 		DependencyResolver: crefer.NewDependencyResolver(),
 		Logger:             clog.NewCompositeLogger(),
 		Counters:           ccount.NewCompositeCounters(),
 		Tracer:             ctrace.NewCompositeTracer(),
+		Batch:              NewBatchExecutor(),
+		MaxBodySize:        gcputil.DefaultMaxBodySize,
 	}
 
 	c.Overrides = &c
@@ -139,6 +156,7 @@ func InheritCloudFunctionService(overrides ICloudFunctionServiceOverrides, name
 	return &CloudFunctionService{
 		name:               name,
 		actions:            make([]*CloudFunctionAction, 0),
+		eventHandlers:      make([]*CloudEventHandler, 0),
 		interceptors:       make([]func(http.ResponseWriter, *http.Request, http.HandlerFunc), 0),
 		opened:             false,
 		Overrides:          overrides,
@@ -146,6 +164,8 @@ func InheritCloudFunctionService(overrides ICloudFunctionServiceOverrides, name
 		Logger:             clog.NewCompositeLogger(),
 		Counters:           ccount.NewCompositeCounters(),
 		Tracer:             ctrace.NewCompositeTracer(),
+		Batch:              NewBatchExecutor(),
+		MaxBodySize:        gcputil.DefaultMaxBodySize,
 	}
 }
 
@@ -161,6 +181,8 @@ func (c *CloudFunctionService) Register() {}
 //		- config *conf.ConfigParams configuration parameters to set.
 func (c *CloudFunctionService) Configure(ctx context.Context, config *cconf.ConfigParams) {
 	c.DependencyResolver.Configure(ctx, config)
+	c.Batch.Configure(config)
+	c.MaxBodySize = config.GetAsLongWithDefault("options.max_body_size", c.MaxBodySize)
 }
 
 // SetReferences sets references to dependent components.
@@ -226,6 +248,7 @@ func (c *CloudFunctionService) Close(ctx context.Context, correlationId string)
 
 	c.opened = false
 	c.actions = nil
+	c.eventHandlers = nil
 	c.interceptors = nil
 
 	return nil
@@ -237,50 +260,109 @@ func (c *CloudFunctionService) GetActions() []*CloudFunctionAction {
 	return c.actions
 }
 
-func (c *CloudFunctionService) ApplyValidation(schema *cvalid.Schema, action http.HandlerFunc) http.HandlerFunc {
-	// Create an action function
-	actionWrapper := func(w http.ResponseWriter, r *http.Request) {
+// Get all CloudEvents handlers supported by the service.
+// Returns an array with supported event handlers.
+func (c *CloudFunctionService) GetEventHandlers() []*CloudEventHandler {
+	return c.eventHandlers
+}
+
+// Wraps action to recover from panics. Recovered panics are logged
+// and reported to the caller as 500 errors.
+// Parameters:
+//		- action	an action function.
+// Returns the wrapped action.
+func (c *CloudFunctionService) ApplyRecovery(action http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rec := recover(); rec != nil {
 				err, ok := rec.(error)
 				if !ok {
-					msg := cconv.StringConverter.ToString(r)
+					msg := cconv.StringConverter.ToString(rec)
 					err = errors.New(msg)
 				}
-				c.Logger.Error(r.Context(), c.GetCorrelationId(r), err, "http handler panics with error")
+				correlationId := c.GetCorrelationId(r)
+				c.Logger.Error(r.Context(), correlationId, err, "http handler panics with error")
+				rpcserv.HttpResponseSender.SendError(w, r,
+					cerr.NewInternalError(correlationId, "ACTION_PANIC", "Action failed").WithCause(err))
 			}
 		}()
 
+		action(w, r)
+	}
+}
+
+// Wraps action with authorization interceptor.
+// The action is called only when the interceptor calls next handler.
+// Parameters:
+//		- authorize	(optional) an authorization interceptor.
+//		- action	an action function.
+// Returns the wrapped action.
+func (c *CloudFunctionService) ApplyAuthorization(authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
+	action http.HandlerFunc) http.HandlerFunc {
+	if authorize == nil {
+		return action
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		authorize(w, r, action)
+	}
+}
+
+// Checks if the schema is missing, including nil pointers to schemas
+func isNilSchema(schema cvalid.ISchema) bool {
+	if schema == nil {
+		return true
+	}
+	value := reflect.ValueOf(schema)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
+
+// Wraps action to validate request parameters against the schema.
+// Invalid requests are rejected with 400 error.
+// Parameters:
+//		- schema	(optional) a validation schema.
+//		- action	an action function.
+// Returns the wrapped action.
+func (c *CloudFunctionService) ApplyValidation(schema *cvalid.Schema, action http.HandlerFunc) http.HandlerFunc {
+	if schema == nil {
+		return action
+	}
+	return c.applyValidation(schema, action)
+}
+
+func (c *CloudFunctionService) applyValidation(schema cvalid.ISchema, action http.HandlerFunc) http.HandlerFunc {
+	if isNilSchema(schema) {
+		return action
+	}
+
+	// Create an action function
+	actionWrapper := func(w http.ResponseWriter, r *http.Request) {
 		// Validate object
-		if schema != nil {
-			var params = make(map[string]any, 0)
-			for k, v := range r.URL.Query() {
-				params[k] = v[0]
-			}
+		var params = make(map[string]any, 0)
+		for k, v := range r.URL.Query() {
+			params[k] = v[0]
+		}
 
-			for k, v := range mux.Vars(r) {
-				params[k] = v
-			}
+		for k, v := range mux.Vars(r) {
+			params[k] = v
+		}
 
-			// Make copy of request
-			bodyBuf, bodyErr := ioutil.ReadAll(r.Body)
-			if bodyErr != nil {
-				rpcserv.HttpResponseSender.SendError(w, r, bodyErr)
-				return
-			}
-			_ = r.Body.Close()
-			r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBuf))
-			//-------------------------
-			var body any
-			_ = json.Unmarshal(bodyBuf, &body)
-			params["body"] = body
-
-			correlationId := c.GetCorrelationId(r)
-			err := schema.ValidateAndReturnError(correlationId, params, false)
-			if err != nil {
-				rpcserv.HttpResponseSender.SendError(w, r, err)
-				return
-			}
+		// Read the body keeping it in the request
+		bodyBuf, bodyErr := gcputil.CloudFunctionRequestHelper.ReadBody(r, c.MaxBodySize)
+		if bodyErr != nil {
+			rpcserv.HttpResponseSender.SendError(w, r, bodyErr)
+			return
+		}
+		//-------------------------
+		var body any
+		_ = json.Unmarshal(bodyBuf, &body)
+		params["body"] = body
+
+		correlationId := c.GetCorrelationId(r)
+		err := schema.ValidateAndReturnError(correlationId, params, false)
+		if err != nil {
+			rpcserv.HttpResponseSender.SendError(w, r, err)
+			return
 		}
 
 		action(w, r)
@@ -289,6 +371,11 @@ func (c *CloudFunctionService) ApplyValidation(schema *cvalid.Schema, action htt
 	return actionWrapper
 }
 
+// Wraps action with interceptors registered by RegisterInterceptor.
+// Interceptors are called in the order they were registered.
+// Parameters:
+//		- action	an action function.
+// Returns the wrapped action.
 func (c *CloudFunctionService) ApplyInterceptors(action http.HandlerFunc) http.HandlerFunc {
 	actionWrapper := action
 
@@ -304,6 +391,37 @@ func (c *CloudFunctionService) ApplyInterceptors(action http.HandlerFunc) http.H
 	return actionWrapper
 }
 
+// Builds action pipeline. Stages are executed in the following order:
+//	1. panic recovery	- reports panics in the next stages as 500 errors
+//	2. authorization	- rejects unauthorized requests, e.g. with 401 or 403 errors
+//	3. validation		- rejects requests with invalid parameters with 400 error
+//	4. interceptors		- registered by RegisterInterceptor, in the order of registration
+//	5. action			- the action itself
+// Each stage can short-circuit the pipeline by sending a response without calling the next stage.
+// Parameters:
+//		- schema	(optional) a validation schema.
+//		- authorize	(optional) an authorization interceptor.
+//		- action	an action function.
+// Returns the action wrapped by all stages.
+func (c *CloudFunctionService) ApplyPipeline(schema *cvalid.Schema, authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
+	action http.HandlerFunc) http.HandlerFunc {
+	var params cvalid.ISchema
+	if schema != nil {
+		params = schema
+	}
+	return c.applyPipeline(params, authorize, action)
+}
+
+func (c *CloudFunctionService) applyPipeline(schema cvalid.ISchema, authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
+	action http.HandlerFunc) http.HandlerFunc {
+	actionWrapper := c.ApplyInterceptors(action)
+	actionWrapper = c.applyValidation(schema, actionWrapper)
+	actionWrapper = c.ApplyAuthorization(authorize, actionWrapper)
+	actionWrapper = c.ApplyRecovery(actionWrapper)
+
+	return actionWrapper
+}
+
 func (c *CloudFunctionService) GenerateActionCmd(name string) string {
 	cmd := name
 	if c.name != "" {
@@ -314,49 +432,194 @@ func (c *CloudFunctionService) GenerateActionCmd(name string) string {
 }
 
 // Registers a action in Google Function function.
+// The action is wrapped into the pipeline built by ApplyPipeline.
 // Parameters:
 //		- name		an action name
 //		- schema		a validation schema to validate received parameters.
 //		- action		an action function that is called when operation is invoked.
 func (c *CloudFunctionService) RegisterAction(name string, schema *cvalid.Schema, action http.HandlerFunc) {
-	actionWrapper := c.ApplyValidation(schema, action)
-	actionWrapper = c.ApplyInterceptors(actionWrapper)
+	c.RegisterActionWithAuth(name, schema, nil, action)
+}
 
+// Registers an action with authorization.
+// The action is wrapped into the pipeline built by ApplyPipeline,
+// so authorization runs before validation and interceptors.
+// Parameters:
+//		- name		an action name
+//		- schema	a validation schema to validate received parameters.
+//		- authorize		an authorization interceptor
+//		- action		an action function that is called when operation is invoked.
+func (c *CloudFunctionService) RegisterActionWithAuth(name string, schema *cvalid.Schema, authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
+	action http.HandlerFunc) {
 	registeredAction := &CloudFunctionAction{
 		Cmd:    c.GenerateActionCmd(name),
 		Schema: schema,
-		Action: actionWrapper,
+		Action: c.ApplyPipeline(schema, authorize, action),
 	}
 
 	c.actions = append(c.actions, registeredAction)
 }
 
-// Registers an action with authorization.
+// Registers an action with a schema of any kind. Unlike RegisterAction, concrete schemas,
+// like ObjectSchema, also describe the parameters in the actions catalog and OpenAPI document.
+// The action is wrapped into the pipeline built by ApplyPipeline.
 // Parameters:
 //		- name		an action name
-//		- schema	a validation schema to validate received parameters.
-//		- authorize		an authorization interceptor
-//		- action		an action function that is called when operation is invoked.
-func (c *CloudFunctionService) RegisterActionWithAuth(name string, schema *cvalid.Schema, authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
+//		- schema	(optional) a validation schema to validate received parameters.
+//		- authorize	(optional) an authorization interceptor
+//		- action	an action function that is called when operation is invoked.
+func (c *CloudFunctionService) RegisterActionWithSchema(name string, schema cvalid.ISchema, authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
 	action http.HandlerFunc) {
-	actionWrapper := c.ApplyValidation(schema, action)
+	if isNilSchema(schema) {
+		schema = nil
+	}
+
+	registeredAction := &CloudFunctionAction{
+		Cmd:          c.GenerateActionCmd(name),
+		ParamsSchema: schema,
+		Action:       c.applyPipeline(schema, authorize, action),
+	}
+	if base, ok := schema.(*cvalid.Schema); ok {
+		registeredAction.Schema = base
+	}
+
+	c.actions = append(c.actions, registeredAction)
+}
+
+// Registers an action that executes a batch of actions registered in this service.
+// The action is called with "<name>._batch" command and gcputil.BatchRequest body.
+// Calls in the batch pass through pipelines of their actions.
+// see BatchExecutor
+func (c *CloudFunctionService) RegisterBatchAction() {
+	c.RegisterAction(gcputil.BatchCmd, nil, func(w http.ResponseWriter, r *http.Request) {
+		c.Batch.Execute(w, r, func(cmd string) (http.HandlerFunc, bool) {
+			for _, action := range c.actions {
+				if action.Cmd == cmd && action.Cmd != c.GenerateActionCmd(gcputil.BatchCmd) {
+					return action.Action, true
+				}
+			}
+			return nil, false
+		})
+	})
+}
+
+// Wraps event handler to validate event data against the schema
+// and to convert handler panics into errors.
+// Parameters:
+//		- schema	a validation schema to validate event data.
+//		- handler	an event handler function.
+// Returns the wrapped event handler.
+func (c *CloudFunctionService) ApplyEventValidation(schema *cvalid.Schema,
+	handler func(ctx context.Context, event *gcputil.CloudEvent) error) func(ctx context.Context, event *gcputil.CloudEvent) error {
+
+	return func(ctx context.Context, event *gcputil.CloudEvent) (err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				recErr, ok := rec.(error)
+				if !ok {
+					recErr = errors.New(cconv.StringConverter.ToString(rec))
+				}
+				c.Logger.Error(ctx, event.CorrelationId(), recErr, "event handler panics with error")
+				err = cerr.NewInternalError(event.CorrelationId(), "EVENT_HANDLER_PANIC", "Event handler failed").
+					WithCause(recErr)
+			}
+		}()
 
-	if authorize != nil {
-		nextAction := action
-		action = func(w http.ResponseWriter, r *http.Request) {
-			authorize(w, r, nextAction)
+		validErr := gcputil.CloudFunctionRequestHelper.ValidateCloudEventData(event, schema)
+		if validErr != nil {
+			return validErr
 		}
+
+		return handler(ctx, event)
 	}
+}
 
-	actionWrapper = c.ApplyInterceptors(actionWrapper)
+// Wraps event handler to log calls, measure execution time and trace it.
+// Parameters:
+//		- name		a name used for logging, counters and traces.
+//		- handler	an event handler function.
+// Returns the wrapped event handler.
+func (c *CloudFunctionService) ApplyEventInstrumentation(name string,
+	handler func(ctx context.Context, event *gcputil.CloudEvent) error) func(ctx context.Context, event *gcputil.CloudEvent) error {
+
+	return func(ctx context.Context, event *gcputil.CloudEvent) error {
+		timing := c.Instrument(ctx, event.CorrelationId(), name)
+		err := handler(ctx, event)
+		timing.EndTiming(ctx, err)
+		return err
+	}
+}
 
-	registeredAction := &CloudFunctionAction{
-		Cmd:    c.GenerateActionCmd(name),
-		Schema: schema,
-		Action: actionWrapper,
+// Registers a CloudEvents handler in Google Function service.
+// Data of received events is validated against the schema before the handler is called.
+// Parameters:
+//		- eventType		a type of events to handle, e.g. "google.cloud.pubsub.topic.v1.messagePublished"
+//		- source		a regular expression to match event source or empty string to accept any source.
+//		- schema		a validation schema to validate event data.
+//		- handler		an event handler function that is called when a matching event is received.
+func (c *CloudFunctionService) RegisterEventHandler(eventType string, source string, schema *cvalid.Schema,
+	handler func(ctx context.Context, event *gcputil.CloudEvent) error) {
+
+	c.registerEventHandler(eventType, source, "", nil, schema, handler)
+}
+
+// Registers a handler for Cloud Storage object events.
+// Object metadata is decoded from the event data before the handler is called.
+// Parameters:
+//		- eventType		a type of storage events to handle, e.g. gcputil.StorageObjectFinalizedEventType
+//		- bucket		a bucket name or empty string to accept events from any bucket.
+//		- namePrefix	a prefix of object names or empty string.
+//		- nameSuffix	a suffix of object names or empty string.
+//		- handler		a handler function that is called when a matching event is received.
+func (c *CloudFunctionService) RegisterStorageObjectHandler(eventType string, bucket string, namePrefix string, nameSuffix string,
+	handler func(ctx context.Context, correlationId string, object *gcputil.StorageObjectData) error) {
+
+	// Source looks like "//storage.googleapis.com/projects/_/buckets/mybucket"
+	source := ""
+	if bucket != "" {
+		source = "/buckets/" + regexp.QuoteMeta(bucket) + "$"
 	}
 
-	c.actions = append(c.actions, registeredAction)
+	// Subject looks like "objects/path/to/myobject.txt".
+	// Prefix and suffix are matched separately, so they may overlap in short names.
+	subject := ""
+	var filter func(event *gcputil.CloudEvent) bool
+	if namePrefix != "" || nameSuffix != "" {
+		subject = "^objects/"
+		filter = func(event *gcputil.CloudEvent) bool {
+			name := strings.TrimPrefix(event.Subject, "objects/")
+			return strings.HasPrefix(name, namePrefix) && strings.HasSuffix(name, nameSuffix)
+		}
+	}
+
+	c.registerEventHandler(eventType, source, subject, filter, nil,
+		func(ctx context.Context, event *gcputil.CloudEvent) error {
+			object, err := gcputil.CloudFunctionRequestHelper.GetStorageObjectData(event)
+			if err != nil {
+				return err
+			}
+			return handler(ctx, event.CorrelationId(), object)
+		},
+	)
+}
+
+func (c *CloudFunctionService) registerEventHandler(eventType string, source string, subject string,
+	filter func(event *gcputil.CloudEvent) bool, schema *cvalid.Schema,
+	handler func(ctx context.Context, event *gcputil.CloudEvent) error) {
+
+	handlerWrapper := c.ApplyEventValidation(schema, handler)
+	handlerWrapper = c.ApplyEventInstrumentation(c.GenerateActionCmd(eventType), handlerWrapper)
+
+	registeredHandler := &CloudEventHandler{
+		Type:    eventType,
+		Source:  source,
+		Subject: subject,
+		Filter:  filter,
+		Schema:  schema,
+		Handler: handlerWrapper,
+	}
+
+	c.eventHandlers = append(c.eventHandlers, registeredHandler)
 }
 
 // Registers a middleware for actions in Google Function service.
@@ -73,6 +73,12 @@ func (c *CommandableCloudFunctionService) GetParameters(req *http.Request) *crun
 	return gcputil.CloudFunctionRequestHelper.GetParameters(req)
 }
 
+// Gets the command set of the controller to describe generated actions.
+// Returns nil until actions are registered.
+func (c *CommandableCloudFunctionService) GetCommandSet() *ccomand.CommandSet {
+	return c.commandSet
+}
+
 // Registers all actions in Google Function.
 func (c *CommandableCloudFunctionService) Register() {
 	resCtrl, depErr := c.DependencyResolver.GetOneRequired("controller")
@@ -0,0 +1,10 @@
+package services
+
+// An interface that allows to integrate CloudEvents handlers of Google Function services
+// into Google Function containers and connect them to event triggers.
+type ICloudEventService interface {
+
+	// Get all event handlers supported by the service.
+	// Returns an array with supported event handlers.
+	GetEventHandlers() []*CloudEventHandler
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+)
+
+// Type of CloudEvents emitted by Eventarc for messages published to Pub/Sub topics
+const PubSubMessagePublishedEventType = "google.cloud.pubsub.topic.v1.messagePublished"
+
+// Abstract service that receives messages from Google Pub/Sub push subscriptions
+// and Eventarc Pub/Sub triggers, and dispatches them to registered message handlers.
+//
+// Push subscriptions shall deliver messages to "<function url>?cmd=<name>.push".
+// Messages delivered as CloudEvents are picked up by the container automatically.
+//
+// A response with 2xx status code acknowledges the message. Successfully processed messages,
+// as well as messages that failed with permanent errors (4xx application errors,
+// like validation errors or missing handlers) are acknowledged. Permanent failures are
+// logged and counted as "<name>.message.dropped_count". All other errors
+// return 5xx or 429 status code so Pub/Sub redelivers the message.
+//
+// 	Configuration parameters
+//		- dependencies:
+//			- controller:	override for Controller dependency
+//		- options:
+//			- topic:					topic name assigned to messages from push subscriptions (default: empty)
+//			- ack_permanent_errors:		acknowledge messages that failed with permanent errors (default: true)
+//
+// 	References
+//		- *:logger:*:*:1.0			(optional) ILogger components to pass log messages
+//		- *:counters:*:*:1.0		(optional) ICounters components to pass collected measurements
+//		- *:tracer:*:*:1.0			(optional) ITracer components to record traces
+//
+// see CloudFunctionService
+//
+// 	Example:
+//		type MyPubSubCloudFunctionService struct {
+//			*services.PubSubCloudFunctionService
+//			controller IMyController
+//		}
+//
+//		func NewMyPubSubCloudFunctionService() *MyPubSubCloudFunctionService {
+//			c := MyPubSubCloudFunctionService{}
+//			c.PubSubCloudFunctionService = services.InheritPubSubCloudFunctionService(&c, "mydata")
+//			c.DependencyResolver.Put(context.Background(), "controller", refer.NewDescriptor("mygroup", "controller", "default", "*", "1.0"))
+//			return &c
+//		}
+//
+//		func (c *MyPubSubCloudFunctionService) Register() {
+//			c.RegisterTopicHandler("^mydata-created$", nil,
+//				func(ctx context.Context, message *utils.PubSubMessage) error {
+//					var data MyData
+//					if err := message.DecodeData(&data); err != nil {
+//						return errors.NewBadRequestError(message.CorrelationId(), "INVALID_DATA", "Invalid data")
+//					}
+//					_, err := c.controller.Create(ctx, message.CorrelationId(), data)
+//					return err
+//				},
+//			)
+//		}
+//
+type PubSubCloudFunctionService struct {
+	*CloudFunctionService
+
+	messageHandlers    []*PubSubMessageHandler
+	topic              string
+	ackPermanentErrors bool
+}
+
+// Creates an instance of this service.
+// Parameters:
+//		- name	a service name to generate action cmd.
+func NewPubSubCloudFunctionService(name string) *PubSubCloudFunctionService {
+	c := PubSubCloudFunctionService{
+		messageHandlers:    make([]*PubSubMessageHandler, 0),
+		ackPermanentErrors: true,
+	}
+	c.CloudFunctionService = InheritCloudFunctionService(&c, name)
+	return &c
+}
+
+// InheritPubSubCloudFunctionService creates new instance of PubSubCloudFunctionService
+func InheritPubSubCloudFunctionService(overrides ICloudFunctionServiceOverrides, name string) *PubSubCloudFunctionService {
+	return &PubSubCloudFunctionService{
+		CloudFunctionService: InheritCloudFunctionService(overrides, name),
+		messageHandlers:      make([]*PubSubMessageHandler, 0),
+		ackPermanentErrors:   true,
+	}
+}
+
+// Configure the component with specified parameters.
+//	see ConfigParams
+//	Parameters:
+//		- ctx context.Context
+//		- config *conf.ConfigParams configuration parameters to set.
+func (c *PubSubCloudFunctionService) Configure(ctx context.Context, config *cconf.ConfigParams) {
+	c.CloudFunctionService.Configure(ctx, config)
+
+	c.topic = config.GetAsStringWithDefault("options.topic", c.topic)
+	c.ackPermanentErrors = config.GetAsBooleanWithDefault("options.ack_permanent_errors", c.ackPermanentErrors)
+}
+
+// Open method are opens the component.
+// It registers message handlers, the push action and the CloudEvents handler.
+//	Parameters:
+//		- ctx context.Context
+//		- correlationId  string (optional) transaction id to trace execution through call chain.
+//	Returns: error or nil no errors occured.
+func (c *PubSubCloudFunctionService) Open(ctx context.Context, correlationId string) error {
+	err := c.CloudFunctionService.Open(ctx, correlationId)
+	if err != nil {
+		return err
+	}
+
+	c.RegisterAction("push", nil, c.push)
+
+	// Events are instrumented when messages are processed
+	c.eventHandlers = append(c.eventHandlers, &CloudEventHandler{
+		Type:    PubSubMessagePublishedEventType,
+		Handler: c.onMessagePublished,
+	})
+
+	return nil
+}
+
+// Get all message handlers registered in the service.
+// Returns an array with message handlers.
+func (c *PubSubCloudFunctionService) GetMessageHandlers() []*PubSubMessageHandler {
+	return c.messageHandlers
+}
+
+// Registers a handler for messages published to topics that match the regular expression.
+// Parameters:
+//		- topic		a regular expression to match topic name or empty string to accept any topic.
+//		- schema	a validation schema to validate message data.
+//		- handler	a message handler function.
+func (c *PubSubCloudFunctionService) RegisterTopicHandler(topic string, schema *cvalid.Schema,
+	handler func(ctx context.Context, message *gcputil.PubSubMessage) error) {
+
+	c.messageHandlers = append(c.messageHandlers, &PubSubMessageHandler{
+		Topic:   topic,
+		Schema:  schema,
+		Handler: handler,
+	})
+}
+
+// Registers a handler for messages that carry the attribute.
+// Parameters:
+//		- attribute	a name of the attribute.
+//		- value		a value of the attribute or empty string to accept any value.
+//		- schema	a validation schema to validate message data.
+//		- handler	a message handler function.
+func (c *PubSubCloudFunctionService) RegisterAttributeHandler(attribute string, value string, schema *cvalid.Schema,
+	handler func(ctx context.Context, message *gcputil.PubSubMessage) error) {
+
+	c.messageHandlers = append(c.messageHandlers, &PubSubMessageHandler{
+		Attribute: attribute,
+		Value:     value,
+		Schema:    schema,
+		Handler:   handler,
+	})
+}
+
+// Checks if processing of a message that failed with the error shall be retried.
+// Application errors with 4xx status codes except 408 and 429 are treated as permanent.
+// Parameters:
+//		- err	an error returned by message handler.
+// Returns true if the message shall be redelivered and false otherwise.
+func (c *PubSubCloudFunctionService) IsRetryableError(err error) bool {
+	var appErr *cerr.ApplicationError
+	if !errors.As(err, &appErr) {
+		return true
+	}
+
+	switch appErr.Status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	}
+	return appErr.Status < 400 || appErr.Status >= 500
+}
+
+// Processes the message by the first matching handler.
+// Permanent failures are logged and dropped when ack_permanent_errors option is set.
+// Parameters:
+//		- ctx		context.Context
+//		- message	a Pub/Sub message to process.
+// Returns error if the message shall be redelivered.
+func (c *PubSubCloudFunctionService) ProcessMessage(ctx context.Context, message *gcputil.PubSubMessage) error {
+	correlationId := message.CorrelationId()
+	name := c.GenerateActionCmd("message")
+
+	timing := c.Instrument(ctx, correlationId, name)
+	err := c.dispatchMessage(ctx, message)
+	timing.EndTiming(ctx, err)
+
+	if err != nil && c.ackPermanentErrors && !c.IsRetryableError(err) {
+		c.Logger.Warn(ctx, correlationId, "Dropped message %s: %s", message.MessageId, err.Error())
+		c.Counters.IncrementOne(ctx, name+".dropped_count")
+		return nil
+	}
+
+	return err
+}
+
+func (c *PubSubCloudFunctionService) dispatchMessage(ctx context.Context, message *gcputil.PubSubMessage) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			recErr, ok := rec.(error)
+			if !ok {
+				recErr = errors.New(cconv.StringConverter.ToString(rec))
+			}
+			c.Logger.Error(ctx, message.CorrelationId(), recErr, "message handler panics with error")
+			err = cerr.NewInternalError(message.CorrelationId(), "MESSAGE_HANDLER_PANIC", "Message handler failed").
+				WithCause(recErr)
+		}
+	}()
+
+	for _, handler := range c.messageHandlers {
+		if !handler.Matches(message) {
+			continue
+		}
+
+		err = gcputil.CloudFunctionRequestHelper.ValidatePubSubMessageData(message, handler.Schema)
+		if err != nil {
+			return err
+		}
+
+		return handler.Handler(ctx, message)
+	}
+
+	return cerr.NewBadRequestError(
+		message.CorrelationId(),
+		"NO_MESSAGE_HANDLER",
+		"Handler for message "+message.MessageId+" was not found",
+	).WithDetails("topic", message.Topic).WithDetails("subscription", message.Subscription)
+}
+
+func (c *PubSubCloudFunctionService) push(res http.ResponseWriter, req *http.Request) {
+	message, err := gcputil.CloudFunctionRequestHelper.GetPubSubMessage(req)
+	if err != nil {
+		err := cerr.NewBadRequestError(
+			c.GetCorrelationId(req),
+			"INVALID_MESSAGE",
+			"Invalid Pub/Sub push message",
+		).WithCause(err)
+
+		rpcserv.HttpResponseSender.SendError(res, req, err)
+		return
+	}
+
+	if message.Topic == "" {
+		message.Topic = c.topic
+	}
+
+	err = c.ProcessMessage(req.Context(), message)
+	rpcserv.HttpResponseSender.SendEmptyResult(res, req, err)
+}
+
+func (c *PubSubCloudFunctionService) onMessagePublished(ctx context.Context, event *gcputil.CloudEvent) error {
+	message, err := gcputil.CloudFunctionRequestHelper.GetPubSubMessageFromEvent(event)
+	if err != nil {
+		return cerr.NewBadRequestError(
+			event.CorrelationId(),
+			"INVALID_MESSAGE",
+			"Invalid Pub/Sub message in event "+event.Id,
+		).WithCause(err)
+	}
+
+	return c.ProcessMessage(ctx, message)
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"regexp"
+
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+type PubSubMessageHandler struct {
+	// Regular expression to match message topic or empty string to match any topic
+	Topic string
+	// Name of the attribute the message must have or empty string to skip the check
+	Attribute string
+	// Value of the attribute or empty string to accept any value
+	Value string
+	// Schema to validate message data
+	Schema *cvalid.Schema
+	// Handler to be executed
+	Handler func(ctx context.Context, message *gcputil.PubSubMessage) error
+}
+
+// Checks if the handler accepts the given message by its topic and attributes.
+// Parameters:
+//		- message	a Pub/Sub message to check
+// Returns true if the message shall be processed by this handler
+func (c *PubSubMessageHandler) Matches(message *gcputil.PubSubMessage) bool {
+	if c.Attribute != "" {
+		value, ok := message.Attributes[c.Attribute]
+		if !ok || (c.Value != "" && c.Value != value) {
+			return false
+		}
+	}
+	if c.Topic == "" {
+		return true
+	}
+	matched, _ := regexp.MatchString(c.Topic, message.Topic)
+	return matched
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+)
+
+// Registers an action that receives typed requests and returns typed results.
+// The whole JSON request body, e.g. {"cmd": "dummies.get_dummy_by_id", "dummy_id": "1"}, is decoded into TReq
+// after it is validated against the schema by the action pipeline. Empty bodies, including chunked bodies
+// without content, are decoded as zero TReq values. The call is instrumented and the result is sent as JSON,
+// or as 204 status code for nil results. Returned errors are sent as ErrorDescription.
+// Go methods cannot have type parameters, so typed actions are registered by functions.
+// Parameters:
+//		- service	a service to register the action.
+//		- name		an action name.
+//		- schema	(optional) a validation schema to validate received parameters. The schema receives
+//					the same body in "body" property, as in RegisterAction, while TReq is decoded from the body itself.
+//		- action	an action function that is called with the decoded request.
+//
+//	Example:
+//		type GetDummyRequest struct {
+//			DummyId string `json:"dummy_id"`
+//		}
+//
+//		func (c *MyCloudFunctionService) Register() {
+//			services.RegisterTypedAction(c.CloudFunctionService, "get_dummy_by_id",
+//				validate.NewObjectSchema().WithRequiredProperty("body",
+//					validate.NewObjectSchema().WithRequiredProperty("dummy_id", convert.String)).Schema,
+//				func(ctx context.Context, correlationId string, req GetDummyRequest) (*Dummy, error) {
+//					return c.controller.GetOneById(ctx, correlationId, req.DummyId)
+//				},
+//			)
+//		}
+func RegisterTypedAction[TReq any, TRes any](service *CloudFunctionService, name string, schema *cvalid.Schema,
+	action func(ctx context.Context, correlationId string, req TReq) (TRes, error)) {
+	RegisterTypedActionWithAuth(service, name, schema, nil, action)
+}
+
+// Registers an action with authorization that receives typed requests and returns typed results.
+// see RegisterTypedAction
+// Parameters:
+//		- service	a service to register the action.
+//		- name		an action name.
+//		- schema	(optional) a validation schema to validate received parameters.
+//		- authorize	an authorization interceptor.
+//		- action	an action function that is called with the decoded request.
+func RegisterTypedActionWithAuth[TReq any, TRes any](service *CloudFunctionService, name string, schema *cvalid.Schema,
+	authorize func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc),
+	action func(ctx context.Context, correlationId string, req TReq) (TRes, error)) {
+	cmd := service.GenerateActionCmd(name)
+
+	service.RegisterActionWithAuth(name, schema, authorize, func(w http.ResponseWriter, r *http.Request) {
+		correlationId := service.GetCorrelationId(r)
+
+		var request TReq
+		if err := decodeTypedRequest(r, &request, service.MaxBodySize); err != nil {
+			err := cerr.NewBadRequestError(correlationId, "JSON_CNV_ERR", "Failed to decode request body").
+				WithDetails("cmd", cmd).
+				WithCause(err)
+			rpcserv.HttpResponseSender.SendError(w, r, err)
+			return
+		}
+
+		timing := service.Instrument(r.Context(), correlationId, cmd)
+		result, err := action(r.Context(), correlationId, request)
+		timing.EndTiming(r.Context(), err)
+
+		if err != nil || isNilResult(result) {
+			rpcserv.HttpResponseSender.SendResult(w, r, nil, err)
+			return
+		}
+		rpcserv.HttpResponseSender.SendResult(w, r, result, nil)
+	})
+}
+
+func decodeTypedRequest(r *http.Request, target any, maxSize int64) error {
+	// Chunked requests have unknown length, so the body is read to check if it is empty
+	body, err := gcputil.CloudFunctionRequestHelper.ReadBody(r, maxSize)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, target)
+}
+
+// Checks if the result is a nil pointer, map, slice or interface wrapped into any
+func isNilResult(result any) bool {
+	if result == nil {
+		return true
+	}
+
+	value := reflect.ValueOf(result)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Chan, reflect.Func:
+		return value.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+	rpcauth "github.com/pip-services3-gox/pip-services3-rpc-gox/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+const testAudience = "https://us-east1-test.cloudfunctions.net/dummies"
+
+type testTokenIssuer struct {
+	key    *rsa.PrivateKey
+	server *httptest.Server
+	calls  int32
+}
+
+func newTestTokenIssuer(t *testing.T) *testTokenIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	c := &testTokenIssuer{key: key}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&c.calls, 1)
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": "key1",
+				"kty": "RSA",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			}},
+		})
+	}))
+	return c
+}
+
+func (c *testTokenIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, _ := rsa.SignPKCS1v15(rand.Reader, c.key, crypto.SHA256, hash[:])
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (c *testTokenIssuer) newToken(audience string, expiry time.Time, roles ...string) string {
+	return c.sign(map[string]any{
+		"iss":   "https://accounts.google.com",
+		"aud":   audience,
+		"sub":   "1234567890",
+		"email": "tester@test.iam.gserviceaccount.com",
+		"iat":   time.Now().Unix(),
+		"exp":   expiry.Unix(),
+		"roles": roles,
+	})
+}
+
+func invokeInterceptors(req *http.Request,
+	interceptors ...func(res http.ResponseWriter, req *http.Request, next http.HandlerFunc)) (*httptest.ResponseRecorder, *gcpauth.Principal) {
+
+	var principal *gcpauth.Principal
+	action := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = gcpauth.PrincipalFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for index := len(interceptors) - 1; index >= 0; index-- {
+		interceptor := interceptors[index]
+		next := action
+		action = func(w http.ResponseWriter, r *http.Request) {
+			interceptor(w, r, next)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	action(rr, req)
+	return rr, principal
+}
+
+func TestIdTokenAuthManager(t *testing.T) {
+	issuer := newTestTokenIssuer(t)
+	defer issuer.server.Close()
+
+	authManager := gcpauth.NewIdTokenAuthManager()
+	authManager.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"auth.audience", testAudience,
+		"auth.jwks_uri", issuer.server.URL,
+	))
+	roles := &gcpauth.RoleAuthManager{}
+
+	sendRequest := func(token string, interceptors ...func(http.ResponseWriter, *http.Request, http.HandlerFunc)) (int, *gcpauth.Principal) {
+		req := httptest.NewRequest("POST", "/", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr, principal := invokeInterceptors(req, append([]func(http.ResponseWriter, *http.Request, http.HandlerFunc){authManager.Authenticate()}, interceptors...)...)
+		return rr.Code, principal
+	}
+
+	// Valid token
+	code, principal := sendRequest(issuer.newToken(testAudience, time.Now().Add(time.Hour), "admin"))
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotNil(t, principal)
+	assert.Equal(t, "1234567890", principal.Id)
+	assert.Equal(t, "tester@test.iam.gserviceaccount.com", principal.Email)
+	assert.Equal(t, []string{"admin"}, principal.Roles)
+
+	// Missing, expired and foreign tokens
+	code, _ = sendRequest("")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = sendRequest(issuer.newToken(testAudience, time.Now().Add(-time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = sendRequest(issuer.newToken("https://other.example.com", time.Now().Add(time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// Role and claim checks
+	code, _ = sendRequest(issuer.newToken(testAudience, time.Now().Add(time.Hour), "admin"), roles.Admin())
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = sendRequest(issuer.newToken(testAudience, time.Now().Add(time.Hour), "reader"), roles.Admin())
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = sendRequest(issuer.newToken(testAudience, time.Now().Add(time.Hour)),
+		roles.ClaimIn("email", "tester@test.iam.gserviceaccount.com"))
+	assert.Equal(t, http.StatusOK, code)
+
+	// Public keys are cached
+	assert.Equal(t, int32(1), atomic.LoadInt32(&issuer.calls))
+}
+
+func TestApiKeyAuthManager(t *testing.T) {
+	authManager := gcpauth.NewApiKeyAuthManager()
+	authManager.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"api_keys.billing", "key123",
+		"api_key_roles.billing", "admin, reader",
+	))
+	roles := &gcpauth.RoleAuthManager{}
+
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-API-Key", "key123")
+	rr, principal := invokeInterceptors(req, authManager.Authenticate(), roles.UserInRole("reader"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "billing", principal.Id)
+	assert.Equal(t, "api_key", principal.AuthType)
+
+	// Principal is compatible with Pip.Services RPC authorizers
+	req = httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-API-Key", "key123")
+	rr, _ = invokeInterceptors(req, authManager.Authenticate(), (&rpcauth.RoleAuthManager{}).Admin())
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Authorization errors keep correlation id of the request
+	req = httptest.NewRequest("POST", "/?correlation_id=123", nil)
+	req.Header.Set("X-API-Key", "key123")
+	rr, _ = invokeInterceptors(req, authManager.Authenticate(), roles.UserInRole("writer"))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	var appErr cerr.ApplicationError
+	err := json.Unmarshal(rr.Body.Bytes(), &appErr)
+	assert.Nil(t, err)
+	assert.Equal(t, "NOT_IN_ROLE", appErr.Code)
+	assert.Equal(t, "123", appErr.CorrelationId)
+
+	// Keys in query parameters are accepted only when configured
+	req = httptest.NewRequest("POST", "/?api_key=key123", nil)
+	rr, _ = invokeInterceptors(req, authManager.Authenticate())
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	authManager.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"auth.api_key_param", "api_key",
+	))
+	req = httptest.NewRequest("POST", "/?api_key=key123", nil)
+	rr, _ = invokeInterceptors(req, authManager.Authenticate())
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("X-API-Key", "wrong")
+	rr, _ = invokeInterceptors(req, authManager.Authenticate())
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Unauthenticated requests are rejected by role checks
+	req = httptest.NewRequest("POST", "/", nil)
+	rr, _ = invokeInterceptors(req, roles.Signed())
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,145 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeIdToken(audience string, expiry time.Time) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	claims, _ := json.Marshal(map[string]any{"aud": audience, "exp": expiry.Unix()})
+	return header + "." + base64.RawURLEncoding.EncodeToString(claims) + ".signature"
+}
+
+func newServiceAccountKey(t *testing.T, tokenUri string) ([]byte, *rsa.PrivateKey) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.Nil(t, err)
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	keyJson, err := json.Marshal(map[string]string{
+		"type":           "service_account",
+		"project_id":     "test",
+		"private_key_id": "key1",
+		"private_key":    string(keyPem),
+		"client_email":   "tester@test.iam.gserviceaccount.com",
+		"token_uri":      tokenUri,
+	})
+	assert.Nil(t, err)
+
+	return keyJson, privateKey
+}
+
+func TestServiceAccountIdTokenSource(t *testing.T) {
+	audience := "https://us-east1-test.cloudfunctions.net/dummies"
+	var publicKey *rsa.PublicKey
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		assert.Nil(t, r.ParseForm())
+		assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.Form.Get("grant_type"))
+
+		// Verify the self-signed assertion
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		assert.Len(t, parts, 3)
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		assert.Nil(t, rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature))
+
+		var claims map[string]any
+		payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		assert.Nil(t, json.Unmarshal(payload, &claims))
+		assert.Equal(t, audience, claims["target_audience"])
+		assert.Equal(t, "tester@test.iam.gserviceaccount.com", claims["iss"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"id_token": newFakeIdToken(audience, time.Now().Add(time.Hour)),
+		})
+	}))
+	defer server.Close()
+
+	keyJson, privateKey := newServiceAccountKey(t, server.URL)
+	publicKey = &privateKey.PublicKey
+
+	source, err := gcpauth.NewServiceAccountIdTokenSource(keyJson, audience)
+	assert.Nil(t, err)
+
+	cached := gcpauth.NewCachedIdTokenSource(source, gcpauth.DefaultRefreshLeeway)
+
+	token, err := cached.GetIdToken(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token.Value)
+	assert.True(t, token.Expiry.After(time.Now().Add(50*time.Minute)))
+
+	// Second call is served from cache
+	token2, err := cached.GetIdToken(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.Equal(t, token.Value, token2.Value)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestServiceAccountIdTokenSourceRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+	}))
+	defer server.Close()
+
+	keyJson, _ := newServiceAccountKey(t, server.URL)
+	source, err := gcpauth.NewServiceAccountIdTokenSource(keyJson, "audience")
+	assert.Nil(t, err)
+
+	_, err = source.GetIdToken(context.Background(), "123")
+	assert.NotNil(t, err)
+}
+
+func TestMetadataIdTokenSource(t *testing.T) {
+	audience := "https://dummies-abc123-ue.a.run.app"
+	var calls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		assert.Equal(t, "/computeMetadata/v1/instance/service-accounts/default/identity", r.URL.Path)
+		assert.Equal(t, audience, r.URL.Query().Get("audience"))
+
+		// The token expires within refresh leeway
+		_, _ = w.Write([]byte(newFakeIdToken(audience, time.Now().Add(time.Minute))))
+	}))
+	defer server.Close()
+
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	source := gcpauth.NewCachedIdTokenSource(gcpauth.NewMetadataIdTokenSource(audience), gcpauth.DefaultRefreshLeeway)
+
+	token, err := source.GetIdToken(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.NotEmpty(t, token.Value)
+
+	// Tokens that are about to expire are refreshed
+	_, err = source.GetIdToken(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,320 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	cauth "github.com/pip-services3-gox/pip-services3-components-gox/auth"
+	gcpauth "github.com/pip-services3-gox/pip-services3-gcp-gox/auth"
+	gcpconn "github.com/pip-services3-gox/pip-services3-gcp-gox/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fake Secret Manager that keeps secret versions in memory
+type fakeSecretManager struct {
+	server   *httptest.Server
+	secrets  map[string][]string
+	accesses map[string]int
+	status   int
+	corrupt  bool
+	mtx      sync.Mutex
+}
+
+func newFakeSecretManager(t *testing.T) *fakeSecretManager {
+	c := &fakeSecretManager{
+		secrets:  make(map[string][]string),
+		accesses: make(map[string]int),
+	}
+
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret-token", r.Header.Get("Authorization"))
+
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		if c.status != 0 {
+			w.WriteHeader(c.status)
+			return
+		}
+
+		path := strings.TrimPrefix(r.URL.Path, "/v1/")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(path, ":access"):
+			name := strings.TrimSuffix(path, ":access")
+			c.accesses[name]++
+
+			pos := strings.Index(name, "/versions/")
+			versions := c.secrets[name[:pos]]
+			version := name[pos+len("/versions/"):]
+			index := len(versions) - 1
+			if version != "latest" {
+				index, _ = strconv.Atoi(version)
+				index--
+			}
+			if index < 0 || index >= len(versions) {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			data := []byte(versions[index])
+			checksum := crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+			if c.corrupt {
+				checksum++
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"name": name,
+				"payload": map[string]any{
+					"data":       base64.StdEncoding.EncodeToString(data),
+					"dataCrc32c": strconv.FormatUint(uint64(checksum), 10),
+				},
+			})
+		case r.Method == http.MethodPost && strings.HasSuffix(path, ":addVersion"):
+			secret := strings.TrimSuffix(path, ":addVersion")
+			var body struct {
+				Payload struct {
+					Data string `json:"data"`
+				} `json:"payload"`
+			}
+			assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+			data, err := base64.StdEncoding.DecodeString(body.Payload.Data)
+			assert.Nil(t, err)
+			c.secrets[secret] = append(c.secrets[secret], string(data))
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	return c
+}
+
+func (c *fakeSecretManager) addVersion(secret string, data string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.secrets[secret] = append(c.secrets[secret], data)
+}
+
+func (c *fakeSecretManager) accessCount(name string) int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.accesses[name]
+}
+
+func (c *fakeSecretManager) setStatus(status int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.status = status
+}
+
+func (c *fakeSecretManager) setCorrupt(corrupt bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.corrupt = corrupt
+}
+
+func newSecretManagerStore(server *fakeSecretManager, options ...any) *gcpauth.SecretManagerCredentialStore {
+	store := gcpauth.NewSecretManagerCredentialStore()
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.server.URL,
+		"credential.access_token", "secret-token",
+		"options.project_id", "test",
+	)
+	store.Configure(context.Background(), config.Override(cconf.NewConfigParamsFromTuples(options...)))
+	return store
+}
+
+func TestSecretManagerCredentialStoreLookup(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeSecretManager(t)
+	defer server.server.Close()
+
+	server.addVersion("projects/test/secrets/token", " abc\n")
+	server.addVersion("projects/test/secrets/creds", `{"auth_token": "def", "audience": "dummies", "retries": 3}`)
+	server.addVersion("projects/test/secrets/key", `{"type": "service_account", "client_email": "tester@test"}`)
+
+	store := newSecretManagerStore(server, "secrets.dummies", "projects/test/secrets/creds")
+
+	assert.Equal(t, "projects/test/secrets/token/versions/latest", store.GetSecretName("token"))
+	assert.Equal(t, "projects/test/secrets/token/versions/2", store.GetSecretName("token/versions/2"))
+	assert.Equal(t, "projects/other/secrets/token/versions/latest", store.GetSecretName("projects/other/secrets/token"))
+	assert.Equal(t, "projects/test/secrets/creds/versions/latest", store.GetSecretName("dummies"))
+
+	// Plain secrets are returned as tokens
+	credential, err := store.Lookup(ctx, "123", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", credential.GetAsString("auth_token"))
+
+	// JSON secrets are returned as credential parameters
+	credential, err = store.Lookup(ctx, "123", "dummies")
+	assert.Nil(t, err)
+	assert.Equal(t, "def", credential.GetAsString("auth_token"))
+	assert.Equal(t, "dummies", credential.GetAsString("audience"))
+	assert.Equal(t, 3, credential.GetAsInteger("retries"))
+
+	// Service account keys are returned as keys
+	credential, err = store.Lookup(ctx, "123", "key")
+	assert.Nil(t, err)
+	assert.Contains(t, credential.GetAsString("key"), "service_account")
+
+	_, err = store.Lookup(ctx, "123", "unknown")
+	assert.NotNil(t, err)
+	assert.Equal(t, "MISSING_CREDENTIALS", err.(*cerr.ApplicationError).Code)
+
+	// Short names need a project
+	store = newSecretManagerStore(server, "options.project_id", "")
+	_, err = store.Lookup(ctx, "123", "token")
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_PROJECT_ID", err.(*cerr.ApplicationError).Code)
+}
+
+func TestSecretManagerCredentialStoreCache(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeSecretManager(t)
+	defer server.server.Close()
+
+	server.addVersion("projects/test/secrets/token", "v1")
+
+	store := newSecretManagerStore(server, "options.cache_timeout", 50)
+
+	for i := 0; i < 3; i++ {
+		credential, err := store.Lookup(ctx, "123", "token")
+		assert.Nil(t, err)
+		assert.Equal(t, "v1", credential.GetAsString("auth_token"))
+
+		credential, err = store.Lookup(ctx, "123", "token/versions/1")
+		assert.Nil(t, err)
+		assert.Equal(t, "v1", credential.GetAsString("auth_token"))
+	}
+	assert.Equal(t, 1, server.accessCount("projects/test/secrets/token/versions/latest"))
+	assert.Equal(t, 1, server.accessCount("projects/test/secrets/token/versions/1"))
+
+	// Aliases are refreshed when the cache expires, pinned versions are kept
+	server.addVersion("projects/test/secrets/token", "v2")
+	time.Sleep(60 * time.Millisecond)
+
+	credential, err := store.Lookup(ctx, "123", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", credential.GetAsString("auth_token"))
+	credential, err = store.Lookup(ctx, "123", "token/versions/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", credential.GetAsString("auth_token"))
+	assert.Equal(t, 1, server.accessCount("projects/test/secrets/token/versions/1"))
+
+	// Cached credentials are used while Secret Manager is not available
+	server.setStatus(http.StatusServiceUnavailable)
+	time.Sleep(60 * time.Millisecond)
+
+	credential, err = store.Lookup(ctx, "123", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "v2", credential.GetAsString("auth_token"))
+
+	// Other failures are returned even when the secret is cached
+	server.setStatus(http.StatusForbidden)
+	_, err = store.Lookup(ctx, "123", "token")
+	assert.NotNil(t, err)
+	assert.Equal(t, "ACCESS_DENIED", err.(*cerr.ApplicationError).Code)
+
+	server.setStatus(http.StatusBadRequest)
+	_, err = store.Lookup(ctx, "123", "token")
+	assert.NotNil(t, err)
+	assert.Equal(t, "CANNOT_ACCESS_SECRET", err.(*cerr.ApplicationError).Code)
+
+	store.ClearCache()
+	_, err = store.Lookup(ctx, "123", "token")
+	assert.NotNil(t, err)
+}
+
+func TestSecretManagerCredentialStoreChecksum(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeSecretManager(t)
+	defer server.server.Close()
+
+	server.addVersion("projects/test/secrets/token", "v1")
+
+	store := newSecretManagerStore(server, "options.cache_timeout", 50)
+
+	credential, err := store.Lookup(ctx, "123", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", credential.GetAsString("auth_token"))
+
+	// Corrupted payloads are not replaced with cached secrets
+	server.setCorrupt(true)
+	time.Sleep(60 * time.Millisecond)
+
+	_, err = store.Lookup(ctx, "123", "token")
+	assert.NotNil(t, err)
+	assert.Equal(t, "CORRUPTED_SECRET", err.(*cerr.ApplicationError).Code)
+
+	store.ClearCache()
+	_, err = store.Lookup(ctx, "123", "token")
+	assert.NotNil(t, err)
+	assert.Equal(t, "CORRUPTED_SECRET", err.(*cerr.ApplicationError).Code)
+
+	server.setCorrupt(false)
+	credential, err = store.Lookup(ctx, "123", "token")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", credential.GetAsString("auth_token"))
+}
+
+func TestSecretManagerCredentialStoreStore(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeSecretManager(t)
+	defer server.server.Close()
+
+	server.addVersion("projects/test/secrets/creds", `{"auth_token": "abc"}`)
+
+	store := newSecretManagerStore(server)
+
+	credential, err := store.Lookup(ctx, "123", "creds")
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", credential.GetAsString("auth_token"))
+
+	err = store.Store(ctx, "123", "creds", cauth.NewCredentialParamsFromTuples("auth_token", "def"))
+	assert.Nil(t, err)
+
+	// New versions are visible at once
+	credential, err = store.Lookup(ctx, "123", "creds")
+	assert.Nil(t, err)
+	assert.Equal(t, "def", credential.GetAsString("auth_token"))
+
+	err = store.Store(ctx, "123", "creds", nil)
+	assert.NotNil(t, err)
+}
+
+func TestSecretManagerCredentialStoreResolver(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeSecretManager(t)
+	defer server.server.Close()
+
+	server.addVersion("projects/test/secrets/dummies-token", "abc")
+
+	store := newSecretManagerStore(server)
+	references := crefer.NewReferencesFromTuples(ctx,
+		crefer.NewDescriptor("pip-services", "credential-store", "secretmanager", "default", "1.0"), store,
+	)
+
+	resolver := gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", "https://us-east1-test.cloudfunctions.net/dummies",
+		"credential.store_key", "dummies-token",
+	))
+	resolver.SetReferences(ctx, references)
+
+	connection, err := resolver.Resolve("123")
+	assert.Nil(t, err)
+	token, ok := connection.AuthToken()
+	assert.True(t, ok)
+	assert.Equal(t, "abc", token)
+}
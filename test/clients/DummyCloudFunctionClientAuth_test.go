@@ -0,0 +1,38 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionClientAuthorization(t *testing.T) {
+	var authorization string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"credential.auth_token", "token123",
+	))
+
+	err := client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer token123", authorization)
+}
@@ -0,0 +1,62 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	gcpcont "github.com/pip-services3-gox/pip-services3-gcp-gox/containers"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionClientCallBatch(t *testing.T) {
+	ctx := context.Background()
+
+	function := gcpcont.NewCloudFunctionWithParams("batch", "Batch function")
+	function.RegisterAction("echo", nil, func(w http.ResponseWriter, r *http.Request) {
+		params := gcputil.CloudFunctionRequestHelper.GetParameters(r)
+		rpcserv.HttpResponseSender.SendResult(w, r, params.GetAsString("value"), nil)
+	})
+	err := function.Open(ctx, "")
+	assert.Nil(t, err)
+	defer function.Close(ctx, "")
+
+	server := httptest.NewServer(function.GetHandler())
+	defer server.Close()
+
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+	err = client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	results, err := client.CallBatch(ctx, "123", &gcputil.BatchRequest{
+		Parallel: true,
+		Batch: []*gcputil.BatchItem{
+			{Cmd: "echo", Args: map[string]any{"value": "abc"}},
+			{Cmd: "unknown"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Len(t, results, 2)
+
+	var value string
+	err = results[0].DecodeResult(&value)
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", value)
+
+	err = results[1].GetError()
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusBadRequest, results[1].Status)
+
+	// Empty batches are rejected
+	_, err = client.CallBatch(ctx, "123", &gcputil.BatchRequest{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,108 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionClientCircuitBreaker(t *testing.T) {
+	var failing int32 = 1
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.retries", 1,
+		"options.circuit_breaker.enabled", true,
+		"options.circuit_breaker.min_calls", 2,
+		"options.circuit_breaker.cool_down", 100,
+	))
+	err := client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	circuit := client.GetCircuitBreaker(client.Uri)
+	assert.NotNil(t, circuit)
+	assert.Equal(t, gcpclient.CircuitClosed, circuit.State())
+
+	// Failures open the circuit
+	for index := 0; index < 2; index++ {
+		_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+		assert.Equal(t, http.StatusInternalServerError, err.(*cerr.ApplicationError).Status)
+	}
+	assert.Equal(t, gcpclient.CircuitOpen, circuit.State())
+
+	// Calls are rejected without reaching the function
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	appErr := err.(*cerr.ApplicationError)
+	assert.Equal(t, "CIRCUIT_OPEN", appErr.Code)
+	assert.Equal(t, http.StatusServiceUnavailable, appErr.Status)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+
+	// A failed trial call opens the circuit again
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, gcpclient.CircuitHalfOpen, circuit.State())
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Equal(t, http.StatusInternalServerError, err.(*cerr.ApplicationError).Status)
+	assert.Equal(t, gcpclient.CircuitOpen, circuit.State())
+
+	// A successful trial call closes the circuit
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(150 * time.Millisecond)
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, gcpclient.CircuitClosed, circuit.State())
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	ctx := context.Background()
+
+	circuit := gcpclient.NewCircuitBreaker()
+	circuit.MinCalls = 4
+	circuit.FailureRate = 0.5
+
+	// Failures below the rate keep the circuit closed
+	for index := 0; index < 3; index++ {
+		assert.True(t, circuit.Allow(ctx))
+		circuit.RecordSuccess(ctx)
+	}
+	assert.True(t, circuit.Allow(ctx))
+	circuit.RecordFailure(ctx)
+	assert.True(t, circuit.Allow(ctx))
+	circuit.RecordFailure(ctx)
+	assert.Equal(t, gcpclient.CircuitClosed, circuit.State())
+
+	assert.True(t, circuit.Allow(ctx))
+	circuit.RecordFailure(ctx)
+	assert.Equal(t, gcpclient.CircuitOpen, circuit.State())
+	assert.False(t, circuit.Allow(ctx))
+	assert.Greater(t, circuit.RetryAfter(), time.Duration(0))
+}
+
+func TestCloudFunctionClientCircuitBreakerDisabled(t *testing.T) {
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"connection.uri", "http://localhost:3000",
+	))
+	assert.Nil(t, client.GetCircuitBreaker("http://localhost:3000"))
+}
@@ -0,0 +1,64 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	gcpcont "github.com/pip-services3-gox/pip-services3-gcp-gox/containers"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionClientCompression(t *testing.T) {
+	ctx := context.Background()
+
+	encodings := make([]string, 0)
+	function := gcpcont.NewCloudFunctionWithParams("compression", "Compression function")
+	function.CompressionThreshold = 100
+	function.RegisterAction("echo", nil, func(w http.ResponseWriter, r *http.Request) {
+		params := gcputil.CloudFunctionRequestHelper.GetParameters(r)
+		rpcserv.HttpResponseSender.SendResult(w, r, params.GetAsString("value"), nil)
+	})
+	err := function.Open(ctx, "")
+	assert.Nil(t, err)
+	defer function.Close(ctx, "")
+
+	handler := function.GetHandler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encodings = append(encodings, r.Header.Get("Content-Encoding"))
+		handler(w, r)
+	}))
+	defer server.Close()
+
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+		"options.compression.enabled", true,
+		"options.compression.threshold", 100,
+	))
+	err = client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	// Small requests are sent as is
+	result, err := gcpclient.CallTyped[string](ctx, client, "echo", "123",
+		cdata.NewAnyValueMapFromTuples("value", "abc"))
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", result)
+
+	// Large requests and responses are compressed
+	value := strings.Repeat("abc", 1000)
+	result, err = gcpclient.CallTyped[string](ctx, client, "echo", "123",
+		cdata.NewAnyValueMapFromTuples("value", value))
+	assert.Nil(t, err)
+	assert.Equal(t, value, result)
+
+	assert.Equal(t, []string{"", "gzip"}, encodings)
+}
@@ -0,0 +1,295 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	"github.com/stretchr/testify/assert"
+)
+
+// Fake function deployed to a region that fails with the status or responds after the delay
+type regionServer struct {
+	server *httptest.Server
+	calls  int32
+	status int32
+	delay  time.Duration
+}
+
+func newRegionServer(delay time.Duration) *regionServer {
+	c := &regionServer{delay: delay}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&c.calls, 1)
+		time.Sleep(c.delay)
+		if status := atomic.LoadInt32(&c.status); status != 0 {
+			w.WriteHeader(int(status))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return c
+}
+
+func (c *regionServer) callCount() int32 {
+	return atomic.SwapInt32(&c.calls, 0)
+}
+
+func newRegionsClient(t *testing.T, uris []string, options ...any) *gcpclient.CloudFunctionClient {
+	config := cconf.NewConfigParamsFromTuples(
+		"options.retries", 1,
+		"options.retry.idempotent_commands", "dummies.get_*",
+	)
+	for index, uri := range uris {
+		config.Put("connections."+strconv.Itoa(index)+".uri", uri)
+	}
+	config = config.Override(cconf.NewConfigParamsFromTuples(options...))
+
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(context.Background(), config)
+	err := client.Open(context.Background(), "")
+	assert.Nil(t, err)
+	return client
+}
+
+func TestCloudFunctionClientFailover(t *testing.T) {
+	primary := newRegionServer(0)
+	defer primary.server.Close()
+	secondary := newRegionServer(0)
+	defer secondary.server.Close()
+
+	client := newRegionsClient(t, []string{primary.server.URL, secondary.server.URL})
+	defer client.Close(context.Background(), "")
+	assert.Equal(t, primary.server.URL, client.Uri)
+	assert.Equal(t, []string{primary.server.URL, secondary.server.URL}, client.Uris)
+
+	// The primary serves calls while it works
+	ctx, endpoint := gcpclient.WithCallEndpoint(context.Background())
+	_, err := client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, primary.server.URL, endpoint.Uri())
+	assert.Equal(t, 0, endpoint.Failovers())
+	assert.Equal(t, int32(1), primary.callCount())
+
+	// 5xx responses fail over to the secondary
+	atomic.StoreInt32(&primary.status, http.StatusInternalServerError)
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, secondary.server.URL, endpoint.Uri())
+	assert.Equal(t, 1, endpoint.Failovers())
+	assert.Equal(t, int32(1), primary.callCount())
+	assert.Equal(t, int32(1), secondary.callCount())
+
+	// Client errors do not fail over
+	atomic.StoreInt32(&primary.status, http.StatusBadRequest)
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Equal(t, http.StatusBadRequest, err.(*cerr.ApplicationError).Status)
+	assert.Equal(t, primary.server.URL, endpoint.Uri())
+	assert.Equal(t, int32(0), secondary.callCount())
+
+	// Connection errors fail over to the secondary
+	primary.server.Close()
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, secondary.server.URL, endpoint.Uri())
+
+	// The last failure is returned when all endpoints fail
+	atomic.StoreInt32(&secondary.status, http.StatusServiceUnavailable)
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*cerr.ApplicationError).Status)
+	assert.Equal(t, secondary.server.URL, endpoint.Uri())
+}
+
+func TestCloudFunctionClientFailoverNonIdempotent(t *testing.T) {
+	primary := newRegionServer(0)
+	defer primary.server.Close()
+	secondary := newRegionServer(0)
+	defer secondary.server.Close()
+
+	client := newRegionsClient(t, []string{primary.server.URL, secondary.server.URL})
+	defer client.Close(context.Background(), "")
+
+	// Commands that could be executed by the endpoint do not fail over
+	atomic.StoreInt32(&primary.status, http.StatusInternalServerError)
+	_, err := client.Call(context.Background(), "dummies.create_dummy", "123", nil)
+	assert.Equal(t, http.StatusInternalServerError, err.(*cerr.ApplicationError).Status)
+	assert.Equal(t, int32(1), primary.callCount())
+	assert.Equal(t, int32(0), secondary.callCount())
+
+	// Unavailable endpoints do not execute commands, so they fail over
+	atomic.StoreInt32(&primary.status, http.StatusServiceUnavailable)
+	_, err = client.Call(context.Background(), "dummies.create_dummy", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), primary.callCount())
+	assert.Equal(t, int32(1), secondary.callCount())
+
+	// Endpoints that refuse connections fail over
+	dead := newRegionServer(0)
+	dead.server.Close()
+	client = newRegionsClient(t, []string{dead.server.URL, secondary.server.URL})
+	defer client.Close(context.Background(), "")
+
+	_, err = client.Call(context.Background(), "dummies.create_dummy", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), secondary.callCount())
+}
+
+func TestCloudFunctionClientFailoverDisabled(t *testing.T) {
+	primary := newRegionServer(0)
+	defer primary.server.Close()
+	secondary := newRegionServer(0)
+	defer secondary.server.Close()
+
+	client := newRegionsClient(t, []string{primary.server.URL, secondary.server.URL},
+		"options.load_balancing.failover", false,
+	)
+	defer client.Close(context.Background(), "")
+
+	atomic.StoreInt32(&primary.status, http.StatusInternalServerError)
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Equal(t, http.StatusInternalServerError, err.(*cerr.ApplicationError).Status)
+	assert.Equal(t, int32(0), secondary.callCount())
+}
+
+func TestCloudFunctionClientRoundRobin(t *testing.T) {
+	first := newRegionServer(0)
+	defer first.server.Close()
+	second := newRegionServer(0)
+	defer second.server.Close()
+
+	client := newRegionsClient(t, []string{first.server.URL, second.server.URL},
+		"options.load_balancing.strategy", gcpclient.LoadBalancingRoundRobin,
+	)
+	defer client.Close(context.Background(), "")
+
+	for index := 0; index < 4; index++ {
+		_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(2), first.callCount())
+	assert.Equal(t, int32(2), second.callCount())
+}
+
+func TestCloudFunctionClientLowestLatency(t *testing.T) {
+	slow := newRegionServer(50 * time.Millisecond)
+	defer slow.server.Close()
+	fast := newRegionServer(0)
+	defer fast.server.Close()
+
+	client := newRegionsClient(t, []string{slow.server.URL, fast.server.URL},
+		"options.load_balancing.strategy", gcpclient.LoadBalancingLowestLatency,
+	)
+	defer client.Close(context.Background(), "")
+
+	// Each endpoint is measured first, then the fastest one serves calls
+	for index := 0; index < 5; index++ {
+		_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(1), slow.callCount())
+	assert.Equal(t, int32(4), fast.callCount())
+}
+
+func TestCloudFunctionClientFailoverCircuitBreaker(t *testing.T) {
+	primary := newRegionServer(0)
+	defer primary.server.Close()
+	secondary := newRegionServer(0)
+	defer secondary.server.Close()
+
+	client := newRegionsClient(t, []string{primary.server.URL, secondary.server.URL},
+		"options.circuit_breaker.enabled", true,
+		"options.circuit_breaker.min_calls", 1,
+	)
+	defer client.Close(context.Background(), "")
+
+	// Endpoints with open circuits are skipped
+	atomic.StoreInt32(&primary.status, http.StatusInternalServerError)
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, gcpclient.CircuitOpen, client.GetCircuitBreaker(primary.server.URL).State())
+
+	_, err = client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), primary.callCount())
+	assert.Equal(t, int32(2), secondary.callCount())
+
+	// Calls are rejected when all circuits are open
+	atomic.StoreInt32(&secondary.status, http.StatusInternalServerError)
+	for index := 0; index < 2; index++ {
+		_, err = client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+		assert.Equal(t, http.StatusInternalServerError, err.(*cerr.ApplicationError).Status)
+	}
+	_, err = client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Equal(t, "CIRCUIT_OPEN", err.(*cerr.ApplicationError).Code)
+}
+
+func TestCloudFunctionClientWrongLoadBalancing(t *testing.T) {
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"connection.uri", "http://localhost:3000",
+		"options.load_balancing.strategy", "random",
+	))
+	err := client.Open(context.Background(), "")
+	assert.NotNil(t, err)
+	assert.Equal(t, "WRONG_LOAD_BALANCING", err.(*cerr.ApplicationError).Code)
+}
+
+func TestCloudFunctionClientCallTimeout(t *testing.T) {
+	primary := newRegionServer(200 * time.Millisecond)
+	defer primary.server.Close()
+	secondary := newRegionServer(200 * time.Millisecond)
+	defer secondary.server.Close()
+
+	client := newRegionsClient(t, []string{primary.server.URL, secondary.server.URL},
+		"options.retries", 3,
+		"options.retry.base_delay", 1,
+		"options.timeout", 100,
+		"options.call_timeout", 150,
+	)
+	defer client.Close(context.Background(), "")
+
+	// All attempts and failovers are limited by the call timeout
+	start := time.Now()
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "CALL_TIMEOUT", err.(*cerr.ApplicationError).Code)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestCloudFunctionClientLowestLatencyFailures(t *testing.T) {
+	slow := newRegionServer(20 * time.Millisecond)
+	defer slow.server.Close()
+	fast := newRegionServer(0)
+	defer fast.server.Close()
+
+	client := newRegionsClient(t, []string{slow.server.URL, fast.server.URL},
+		"options.load_balancing.strategy", gcpclient.LoadBalancingLowestLatency,
+	)
+	defer client.Close(context.Background(), "")
+
+	for index := 0; index < 2; index++ {
+		_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+		assert.Nil(t, err)
+	}
+	slow.callCount()
+	fast.callCount()
+
+	// Failed endpoints are tried after the others
+	atomic.StoreInt32(&fast.status, http.StatusInternalServerError)
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	atomic.StoreInt32(&fast.status, 0)
+
+	ctx, endpoint := gcpclient.WithCallEndpoint(context.Background())
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, slow.server.URL, endpoint.Uri())
+	assert.Equal(t, int32(1), fast.callCount())
+	assert.Equal(t, int32(2), slow.callCount())
+}
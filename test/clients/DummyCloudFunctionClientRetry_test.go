@@ -0,0 +1,167 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRetryServer(failures int32, status int, retryAfter string) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return server, &attempts
+}
+
+func newRetryClient(t *testing.T, uri string, options ...any) *gcpclient.CloudFunctionClient {
+	client := gcpclient.NewCloudFunctionClient()
+	config := cconf.NewConfigParamsFromTuples(
+		"connection.uri", uri,
+		"options.retry.base_delay", 1,
+	)
+	config = config.Override(cconf.NewConfigParamsFromTuples(options...))
+	client.Configure(context.Background(), config)
+
+	err := client.Open(context.Background(), "")
+	assert.Nil(t, err)
+	return client
+}
+
+func TestCloudFunctionClientRetries(t *testing.T) {
+	server, attempts := newRetryServer(2, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL)
+	defer client.Close(context.Background(), "")
+
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(attempts))
+
+	// Attempts are limited
+	atomic.StoreInt32(attempts, -10)
+	_, err = client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*cerr.ApplicationError).Status)
+	assert.Equal(t, int32(-7), atomic.LoadInt32(attempts))
+
+	// Retries of the client limit attempts of the policy
+	client.Retries = 1
+	atomic.StoreInt32(attempts, 0)
+	_, err = client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+}
+
+func TestCloudFunctionClientRetryStatusCodes(t *testing.T) {
+	server, attempts := newRetryServer(1, http.StatusBadRequest, "")
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL)
+	defer client.Close(context.Background(), "")
+
+	// Client errors are not retried
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+}
+
+func TestCloudFunctionClientRetryIdempotentOnly(t *testing.T) {
+	server, attempts := newRetryServer(1, http.StatusBadGateway, "")
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL,
+		"options.retry.idempotent_only", true,
+		"options.retry.idempotent_commands", "*.get_*",
+	)
+	defer client.Close(context.Background(), "")
+
+	_, err := client.Call(context.Background(), "dummies.create_dummy", "123", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+
+	atomic.StoreInt32(attempts, 0)
+	_, err = client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+}
+
+func TestCloudFunctionClientRetryAfter(t *testing.T) {
+	server, attempts := newRetryServer(1, http.StatusTooManyRequests, "1")
+	defer server.Close()
+
+	// Retry-After is honored within the max delay
+	client := newRetryClient(t, server.URL, "options.retry.max_delay", 100)
+	defer client.Close(context.Background(), "")
+
+	start := time.Now()
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestCloudFunctionClientRetryCancel(t *testing.T) {
+	server, _ := newRetryServer(1, http.StatusServiceUnavailable, "")
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL, "options.retry.base_delay", 10000)
+	defer client.Close(context.Background(), "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, "CONTEXT_CANCELLED", err.(*cerr.ApplicationError).Code)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicyDelays(t *testing.T) {
+	policy := gcpclient.NewRetryPolicy()
+	policy.MaxAttempts = 10
+	policy.BaseDelay = 100
+	policy.MaxDelay = 1000
+	policy.Jitter = 0.5
+
+	response := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+
+	delay, ok := policy.GetRetryDelay(1, "cmd", response, nil)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, delay, 50*time.Millisecond)
+	assert.LessOrEqual(t, delay, 100*time.Millisecond)
+
+	delay, ok = policy.GetRetryDelay(3, "cmd", response, nil)
+	assert.True(t, ok)
+	assert.GreaterOrEqual(t, delay, 200*time.Millisecond)
+	assert.LessOrEqual(t, delay, 400*time.Millisecond)
+
+	// Delays are capped
+	delay, ok = policy.GetRetryDelay(9, "cmd", response, nil)
+	assert.True(t, ok)
+	assert.LessOrEqual(t, delay, time.Second)
+
+	_, ok = policy.GetRetryDelay(10, "cmd", response, nil)
+	assert.False(t, ok)
+
+	_, ok = policy.GetRetryDelay(1, "cmd", &http.Response{StatusCode: http.StatusInternalServerError}, nil)
+	assert.False(t, ok)
+}
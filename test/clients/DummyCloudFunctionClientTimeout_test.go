@@ -0,0 +1,129 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSlowServer(delay time.Duration) (*httptest.Server, *int32) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	}))
+	return server, &attempts
+}
+
+func TestCloudFunctionClientTimeoutOptions(t *testing.T) {
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"connection.uri", "http://localhost:8080",
+		"options.connect_timeout", 2000,
+		"options.timeout", 3000,
+		"options.keep_alive", 4000,
+		"options.max_idle_conns_per_host", 5,
+	))
+
+	assert.Equal(t, 2000, client.ConnectTimeout)
+	assert.Equal(t, 3000, client.Timeout)
+	assert.Equal(t, 2000, client.TlsHandshakeTimeout)
+	assert.Equal(t, 4000, client.KeepAlive)
+	assert.Equal(t, gcpclient.DefaultIdleTimeout, client.IdleTimeout)
+	assert.Equal(t, gcpclient.DefaultMaxIdleConns, client.MaxIdleConns)
+	assert.Equal(t, 5, client.MaxIdleConnsPerHost)
+
+	// Older configurations are still supported
+	client.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"connection.uri", "http://localhost:8080",
+		"options.connectTimeout", 1500,
+	))
+	assert.Equal(t, 1500, client.ConnectTimeout)
+	assert.Equal(t, gcpclient.DefaultTimeout, client.Timeout)
+}
+
+func TestCloudFunctionClientTimeout(t *testing.T) {
+	server, attempts := newSlowServer(500 * time.Millisecond)
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL,
+		"options.timeout", 50,
+		"options.retry.max_attempts", 2,
+	)
+	defer client.Close(context.Background(), "")
+
+	start := time.Now()
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(attempts))
+
+	appErr, ok := err.(*cerr.ApplicationError)
+	assert.True(t, ok)
+	assert.Equal(t, "CALL_TIMEOUT", appErr.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, appErr.Status)
+}
+
+func TestCloudFunctionClientCallTimeoutOverride(t *testing.T) {
+	server, _ := newSlowServer(100 * time.Millisecond)
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL,
+		"options.timeout", 20,
+		"options.retry.max_attempts", 1,
+	)
+	defer client.Close(context.Background(), "")
+
+	_, err := client.Call(context.Background(), "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+
+	// The response body stays readable after the call returns
+	ctx := gcpclient.WithCallTimeout(context.Background(), 2*time.Second)
+	result, err := gcpclient.CallTyped[map[string]any](ctx, client, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", result["id"])
+}
+
+func TestCloudFunctionClientContextDeadline(t *testing.T) {
+	server, attempts := newSlowServer(500 * time.Millisecond)
+	defer server.Close()
+
+	client := newRetryClient(t, server.URL,
+		"options.timeout", 0,
+		"options.retry.max_attempts", 5,
+	)
+	defer client.Close(context.Background(), "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	// Calls are not retried after the deadline
+	assert.Equal(t, int32(1), atomic.LoadInt32(attempts))
+
+	// Canceled calls are stopped immediately
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	appErr, ok := err.(*cerr.ApplicationError)
+	assert.True(t, ok)
+	assert.Equal(t, "CONTEXT_CANCELLED", appErr.Code)
+}
@@ -0,0 +1,124 @@
+package clients_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	ctrace "github.com/pip-services3-gox/pip-services3-components-gox/trace"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionClientTracePropagation(t *testing.T) {
+	var headers http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client := gcpclient.NewCloudFunctionClient()
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	// Without trace context a new trace is started
+	_, err = client.Call(ctx, "dummies.get_dummies", "123", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "123", headers.Get("correlation_id"))
+	span, ok := gcputil.ParseTraceParent(headers.Get("traceparent"))
+	assert.True(t, ok)
+	assert.True(t, span.Sampled)
+
+	// Trace context is continued in a child span
+	parent, _ := gcputil.ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	traceCtx := gcputil.ContextWithTraceContext(ctx, parent)
+
+	_, err = client.Call(traceCtx, "dummies.get_dummies", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, parent.TraceId, headers.Get("correlation_id"))
+
+	span, ok = gcputil.ParseTraceParent(headers.Get("traceparent"))
+	assert.True(t, ok)
+	assert.Equal(t, parent.TraceId, span.TraceId)
+	assert.NotEqual(t, parent.SpanId, span.SpanId)
+	assert.True(t, span.Sampled)
+
+	cloudSpan, ok := gcputil.ParseCloudTraceContext(headers.Get("X-Cloud-Trace-Context"))
+	assert.True(t, ok)
+	assert.Equal(t, span.TraceId, cloudSpan.TraceId)
+	assert.Equal(t, span.SpanId, cloudSpan.SpanId)
+}
+
+// Tracer that records trace contexts of traced operations
+type spansTracerStub struct {
+	mtx   sync.Mutex
+	spans map[string]*gcputil.TraceContext
+}
+
+func (c *spansTracerStub) Trace(ctx context.Context, correlationId string, component string, operation string, duration int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.spans[component], _ = gcputil.TraceContextFromContext(ctx)
+}
+
+func (c *spansTracerStub) Failure(ctx context.Context, correlationId string, component string, operation string, err error, duration int64) {
+	c.Trace(ctx, correlationId, component, operation, duration)
+}
+
+func (c *spansTracerStub) BeginTrace(ctx context.Context, correlationId string, component string, operation string) *ctrace.TraceTiming {
+	return ctrace.NewTraceTiming(correlationId, component, operation, c)
+}
+
+func TestCloudFunctionClientTraceSpans(t *testing.T) {
+	var headers http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	tracer := &spansTracerStub{spans: make(map[string]*gcputil.TraceContext)}
+
+	client := gcpclient.NewCommandableCloudFunctionClient("dummies")
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+	client.SetReferences(ctx, crefer.NewReferencesFromTuples(ctx,
+		crefer.NewDescriptor("pip-services", "tracer", "stub", "default", "1.0"), tracer,
+	))
+
+	err := client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	// Client spans of calls are children of the method spans
+	_, err = client.CallCommand(ctx, "get_dummies", "123", nil)
+	assert.Nil(t, err)
+
+	method := tracer.spans["dummies.get_dummies"]
+	call := tracer.spans["get_dummies"]
+	assert.NotNil(t, method)
+	assert.NotNil(t, call)
+	assert.Equal(t, method.TraceId, call.TraceId)
+	assert.Equal(t, method.SpanId, call.ParentSpanId)
+
+	span, ok := gcputil.ParseTraceParent(headers.Get("traceparent"))
+	assert.True(t, ok)
+	assert.Equal(t, call.SpanId, span.SpanId)
+}
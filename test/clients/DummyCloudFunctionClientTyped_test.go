@@ -0,0 +1,78 @@
+package clients_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpclient "github.com/pip-services3-gox/pip-services3-gcp-gox/clients"
+	tdata "github.com/pip-services3-gox/pip-services3-gcp-gox/test/data"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+	"github.com/stretchr/testify/assert"
+)
+
+type typedDummyArgs struct {
+	Dummy tdata.Dummy `json:"dummy"`
+}
+
+func TestCloudFunctionClientTypedCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch body["cmd"] {
+		case "dummies.create_dummy":
+			rpcserv.HttpResponseSender.SendResult(w, r, body["dummy"], nil)
+		case "dummies.get_dummy_by_id":
+			rpcserv.HttpResponseSender.SendResult(w, r, nil, nil)
+		case "dummies.delete_dummy":
+			w.WriteHeader(http.StatusOK)
+		default:
+			rpcserv.HttpResponseSender.SendError(w, r, cerr.NewNotFoundError("", "NOT_FOUND", "Dummy was not found"))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	client := gcpclient.NewCommandableCloudFunctionClient("dummies")
+	client.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", server.URL,
+	))
+
+	err := client.Open(ctx, "")
+	assert.Nil(t, err)
+	defer client.Close(ctx, "")
+
+	// Struct arguments are sent by their JSON tags
+	dummy := tdata.NewDummy("1", "key 1", "content 1")
+	result, err := gcpclient.CallTyped[tdata.Dummy](ctx, client.CloudFunctionClient, "dummies.create_dummy", "123",
+		typedDummyArgs{Dummy: *dummy})
+	assert.Nil(t, err)
+	assert.Equal(t, *dummy, result)
+
+	// 204 responses and empty bodies are decoded into zero values
+	result, err = gcpclient.CallCommandTyped[tdata.Dummy](ctx, client, "dummies.get_dummy_by_id", "123",
+		map[string]any{"dummy_id": "1"})
+	assert.Nil(t, err)
+	assert.Equal(t, tdata.Dummy{}, result)
+
+	pointer, err := gcpclient.CallCommandTyped[*tdata.Dummy](ctx, client, "dummies.delete_dummy", "123", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, pointer)
+
+	// Errors are returned as application errors
+	_, err = gcpclient.CallCommandTyped[tdata.Dummy](ctx, client, "dummies.update_dummy", "123", nil)
+	assert.NotNil(t, err)
+	appErr, ok := err.(*cerr.ApplicationError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, appErr.Status)
+
+	// Arguments must be JSON objects
+	_, err = gcpclient.CallTyped[tdata.Dummy](ctx, client.CloudFunctionClient, "dummies.create_dummy", "123", "invalid")
+	assert.NotNil(t, err)
+}
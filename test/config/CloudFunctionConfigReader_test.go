@@ -0,0 +1,274 @@
+package config_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpconf "github.com/pip-services3-gox/pip-services3-gcp-gox/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// Secret resolver that keeps secrets in memory and counts calls
+type fakeSecretResolver struct {
+	secrets map[string]string
+	calls   int
+}
+
+func (c *fakeSecretResolver) GetSecret(ctx context.Context, correlationId string, name string) (string, error) {
+	c.calls++
+	if secret, ok := c.secrets[name]; ok {
+		return secret, nil
+	}
+	return "", cerr.NewConfigError(correlationId, "MISSING_CREDENTIALS", "Secret "+name+" was not found")
+}
+
+func writeConfig(t *testing.T, name string, text string) string {
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(text), 0644)
+	assert.Nil(t, err)
+	return path
+}
+
+func TestCloudFunctionConfigReaderYaml(t *testing.T) {
+	t.Setenv("TEST_APP_HOST", "localhost")
+	t.Setenv("OTHER_PORT", "8080")
+
+	path := writeConfig(t, "config.yml", `
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "{{#if TEST_APP_LEVEL}}{{TEST_APP_LEVEL}}{{/if}}{{^TEST_APP_LEVEL}}info{{/TEST_APP_LEVEL}}"
+- descriptor: "pip-services-dummies:persistence:memory:default:1.0"
+  connection:
+    host: "{{TEST_APP_HOST}}"
+    port: "{{{test_app_port}}}"
+  credential:
+    username: "${secret:projects/test/secrets/username}"
+    password: "${secret:projects/test/secrets/password}"
+    uri: "user:${secret:projects/test/secrets/password}@{{TEST_APP_HOST}}"
+`)
+
+	resolver := &fakeSecretResolver{secrets: map[string]string{
+		"projects/test/secrets/username": "admin",
+		"projects/test/secrets/password": "pa$$word\n",
+	}}
+
+	reader := gcpconf.NewCloudFunctionConfigReader(path)
+	reader.SetEnvPrefix("TEST_APP_")
+	reader.SetParameters(cconf.NewConfigParamsFromTuples("TEST_APP_PORT", 3000))
+	reader.SetSecretResolver(resolver)
+
+	parameters := reader.GetParameters()
+	assert.Equal(t, "localhost", parameters.GetAsString("TEST_APP_HOST"))
+	assert.Equal(t, "3000", parameters.GetAsString("TEST_APP_PORT"))
+	_, ok := parameters.Get("OTHER_PORT")
+	assert.False(t, ok)
+
+	config, err := reader.ReadConfig(context.Background(), "123")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "info", config.GetAsString("0.level"))
+	assert.Equal(t, "localhost", config.GetAsString("1.connection.host"))
+	assert.Equal(t, "3000", config.GetAsString("1.connection.port"))
+	assert.Equal(t, "admin", config.GetAsString("1.credential.username"))
+	assert.Equal(t, "pa$$word", config.GetAsString("1.credential.password"))
+	assert.Equal(t, "user:pa$$word@localhost", config.GetAsString("1.credential.uri"))
+
+	// Each secret is resolved once
+	assert.Equal(t, 2, resolver.calls)
+}
+
+func TestCloudFunctionConfigReaderJson(t *testing.T) {
+	path := writeConfig(t, "config.json", `[
+		{"descriptor": "pip-services:logger:console:default:1.0", "level": "{{TEST_APP_LEVEL}}"}
+	]`)
+
+	t.Setenv("CONFIG_PATH", path)
+	t.Setenv("CONFIG_ENV_PREFIX", "TEST_APP_")
+	t.Setenv("TEST_APP_LEVEL", "error")
+
+	reader := gcpconf.NewCloudFunctionConfigReader("./missing.yml")
+	assert.Equal(t, path, reader.GetPath())
+	assert.Equal(t, "TEST_APP_", reader.GetEnvPrefix())
+
+	config, err := reader.ReadConfig(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "error", config.GetAsString("0.level"))
+}
+
+func TestCloudFunctionConfigReaderErrors(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("OTHER_HOST", "localhost")
+
+	// Missing files
+	reader := gcpconf.NewCloudFunctionConfigReader(filepath.Join(t.TempDir(), "config.yml"))
+	_, err := reader.ReadConfig(ctx, "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "READ_FAILED", err.(*cerr.ApplicationError).Code)
+
+	// Variables outside of the prefix are not passed to templates
+	path := writeConfig(t, "config.yml", `
+- descriptor: "pip-services-dummies:persistence:memory:default:1.0"
+  connection:
+    host: "{{OTHER_HOST}}"
+`)
+	reader = gcpconf.NewCloudFunctionConfigReader(path)
+	reader.SetEnvPrefix("TEST_APP_")
+	_, err = reader.ReadConfig(ctx, "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "MISSING_CONFIG_PARAM", err.(*cerr.ApplicationError).Code)
+	assert.Equal(t, "OTHER_HOST", err.(*cerr.ApplicationError).Details["key"])
+
+	// Unresolved secrets name the config key
+	path = writeConfig(t, "config.yml", `
+- descriptor: "pip-services-dummies:persistence:memory:default:1.0"
+  credential:
+    password: "${secret:projects/test/secrets/unknown}"
+`)
+	reader = gcpconf.NewCloudFunctionConfigReader(path)
+	reader.SetSecretResolver(&fakeSecretResolver{})
+	_, err = reader.ReadConfig(ctx, "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "CANNOT_RESOLVE_SECRET", err.(*cerr.ApplicationError).Code)
+	assert.Equal(t, "0.credential.password", err.(*cerr.ApplicationError).Details["key"])
+	assert.Equal(t, "projects/test/secrets/unknown", err.(*cerr.ApplicationError).Details["secret"])
+}
+
+// Fake Cloud Storage that serves a single object
+type fakeStorage struct {
+	server    *httptest.Server
+	data      string
+	version   int
+	downloads int
+	failing   bool
+	mtx       sync.Mutex
+}
+
+func newFakeStorage() *fakeStorage {
+	c := &fakeStorage{}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.mtx.Lock()
+		defer c.mtx.Unlock()
+
+		if c.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.EscapedPath() != "/storage/v1/b/configs/o/dummies%2Fconfig.yml" || r.URL.Query().Get("alt") != "media" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		etag := `"` + strconv.Itoa(c.version) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		c.downloads++
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(c.data))
+	}))
+	return c
+}
+
+func (c *fakeStorage) setData(data string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.data = data
+	c.version++
+}
+
+func (c *fakeStorage) setFailing(failing bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.failing = failing
+}
+
+func (c *fakeStorage) downloadCount() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.downloads
+}
+
+func TestCloudFunctionConfigReaderStorage(t *testing.T) {
+	ctx := context.Background()
+	storage := newFakeStorage()
+	defer storage.server.Close()
+
+	t.Setenv("STORAGE_EMULATOR_HOST", storage.server.URL)
+	storage.setData(`
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "info"
+`)
+
+	reader := gcpconf.NewCloudFunctionConfigReader("gs://configs/dummies/config.yml")
+
+	// Cached config is used between reads
+	for i := 0; i < 3; i++ {
+		config, err := reader.ReadConfig(ctx, "123")
+		assert.Nil(t, err)
+		assert.Equal(t, "info", config.GetAsString("0.level"))
+	}
+	assert.Equal(t, 1, storage.downloadCount())
+
+	// Refresh downloads only changed config
+	config, err := reader.RefreshConfig(ctx, "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "info", config.GetAsString("0.level"))
+	assert.Equal(t, 1, storage.downloadCount())
+
+	storage.setData(`
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "error"
+`)
+	config, err = reader.ReadConfig(ctx, "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "info", config.GetAsString("0.level"))
+
+	config, err = reader.RefreshConfig(ctx, "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "error", config.GetAsString("0.level"))
+	assert.Equal(t, 2, storage.downloadCount())
+
+	// Cached config is used while Cloud Storage is not available
+	storage.setFailing(true)
+	config, err = reader.RefreshConfig(ctx, "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "error", config.GetAsString("0.level"))
+
+	storage.setFailing(false)
+	reader = gcpconf.NewCloudFunctionConfigReader("gs://configs/unknown.yml")
+	_, err = reader.ReadConfig(ctx, "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "READ_FAILED", err.(*cerr.ApplicationError).Code)
+}
+
+func TestCloudFunctionConfigReaderFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"config/config.yml": &fstest.MapFile{Data: []byte(`
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "{{TEST_APP_LEVEL}}"
+`)},
+	}
+
+	reader := gcpconf.NewCloudFunctionConfigReader(gcpconf.DefaultConfigPath)
+	reader.SetFS(fsys)
+	reader.SetParameters(cconf.NewConfigParamsFromTuples("TEST_APP_LEVEL", "warn"))
+
+	config, err := reader.ReadConfig(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.Equal(t, "warn", config.GetAsString("0.level"))
+
+	reader.SetPath("./config/unknown.yml")
+	_, err = reader.ReadConfig(context.Background(), "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "READ_FAILED", err.(*cerr.ApplicationError).Code)
+}
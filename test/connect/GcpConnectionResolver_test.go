@@ -0,0 +1,102 @@
+package connect_test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	cauth "github.com/pip-services3-gox/pip-services3-components-gox/auth"
+	gcpconn "github.com/pip-services3-gox/pip-services3-gcp-gox/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGcpConnectionResolverResolveAll(t *testing.T) {
+	resolver := gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"connections.0.uri", "https://us-central1-my-project.cloudfunctions.net/myfunction",
+		"connections.1.uri", "https://europe-west1-my-project.cloudfunctions.net/myfunction",
+		"credential.auth_token", "token",
+	))
+
+	connections, err := resolver.ResolveAll("123")
+	assert.Nil(t, err)
+	assert.Len(t, connections, 2)
+
+	region, _ := connections[0].Region()
+	assert.Equal(t, "us-central1", region)
+	region, _ = connections[1].Region()
+	assert.Equal(t, "europe-west1", region)
+	token, _ := connections[1].AuthToken()
+	assert.Equal(t, "token", token)
+
+	// Missing connections are reported
+	resolver = gcpconn.NewGcpConnectionResolver()
+	_, err = resolver.ResolveAll("123")
+	assert.NotNil(t, err)
+}
+
+func TestGcpConnectionResolverConnectionsOrder(t *testing.T) {
+	resolver := gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"connections.backup.uri", "https://asia-east1-my-project.cloudfunctions.net/myfunction",
+		"connections.10.uri", "https://us-east1-my-project.cloudfunctions.net/myfunction",
+		"connections.2.uri", "https://europe-west1-my-project.cloudfunctions.net/myfunction",
+		"connections.archive.uri", "https://asia-south1-my-project.cloudfunctions.net/myfunction",
+		"connections.0.uri", "https://us-central1-my-project.cloudfunctions.net/myfunction",
+	))
+
+	// Numbered connections go first, then named ones
+	connections, err := resolver.ResolveAll("123")
+	assert.Nil(t, err)
+	regions := make([]string, 0)
+	for _, connection := range connections {
+		region, _ := connection.Region()
+		regions = append(regions, region)
+	}
+	assert.Equal(t, []string{"us-central1", "europe-west1", "us-east1", "asia-south1", "asia-east1"}, regions)
+}
+
+// Credential store that is not available
+type failingCredentialStore struct{}
+
+func (c *failingCredentialStore) Store(ctx context.Context, correlationId string, key string,
+	credential *cauth.CredentialParams) error {
+	return cerr.NewConnectionError(correlationId, "STORE_FAILED", "Store is not available")
+}
+
+func (c *failingCredentialStore) Lookup(ctx context.Context, correlationId string,
+	key string) (*cauth.CredentialParams, error) {
+	return nil, cerr.NewConnectionError(correlationId, "STORE_FAILED", "Store is not available")
+}
+
+func TestGcpConnectionResolverCredentialStores(t *testing.T) {
+	ctx := context.Background()
+	memory := cauth.NewEmptyMemoryCredentialStore()
+	_ = memory.Store(ctx, "123", "dummies-token", cauth.NewCredentialParamsFromTuples("auth_token", "abc"))
+
+	resolver := gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", "https://us-east1-test.cloudfunctions.net/dummies",
+		"credential.store_key", "dummies-token",
+	))
+
+	// Other stores resolve the credentials when one of them fails
+	resolver.SetReferences(ctx, crefer.NewReferencesFromTuples(ctx,
+		crefer.NewDescriptor("pip-services", "credential-store", "failing", "default", "1.0"), &failingCredentialStore{},
+		crefer.NewDescriptor("pip-services", "credential-store", "memory", "default", "1.0"), memory,
+	))
+	connection, err := resolver.Resolve("123")
+	assert.Nil(t, err)
+	token, _ := connection.AuthToken()
+	assert.Equal(t, "abc", token)
+
+	// The error is returned when no store resolves the credentials
+	resolver.SetReferences(ctx, crefer.NewReferencesFromTuples(ctx,
+		crefer.NewDescriptor("pip-services", "credential-store", "failing", "default", "1.0"), &failingCredentialStore{},
+	))
+	_, err = resolver.Resolve("123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "STORE_FAILED", err.(*cerr.ApplicationError).Code)
+}
@@ -0,0 +1,190 @@
+package connect_test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpconn "github.com/pip-services3-gox/pip-services3-gcp-gox/connect"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUri(t *testing.T) {
+	cases := []struct {
+		uri      string
+		expected map[string]string
+	}{
+		{
+			uri: "https://us-central1-my-project-123.cloudfunctions.net/myfunction",
+			expected: map[string]string{
+				"protocol":   "https",
+				"platform":   gcpconn.PlatformCloudFunctions,
+				"region":     "us-central1",
+				"project_id": "my-project-123",
+				"function":   "myfunction",
+			},
+		},
+		{
+			uri: "https://northamerica-northeast1-myproject.cloudfunctions.net/myfunction/dummies?cmd=get",
+			expected: map[string]string{
+				"platform":   gcpconn.PlatformCloudFunctions,
+				"region":     "northamerica-northeast1",
+				"project_id": "myproject",
+				"function":   "myfunction",
+			},
+		},
+		{
+			uri: "http://east-my_test_project.cloudfunctions.net/myfunction",
+			expected: map[string]string{
+				"protocol":   "http",
+				"region":     "east",
+				"project_id": "my_test_project",
+				"function":   "myfunction",
+			},
+		},
+		{
+			uri: "https://my-function-123456789012.europe-west3.run.app",
+			expected: map[string]string{
+				"platform":       gcpconn.PlatformCloudRun,
+				"service":        "my-function",
+				"function":       "my-function",
+				"project_number": "123456789012",
+				"region":         "europe-west3",
+			},
+		},
+		{
+			uri: "https://my-function-a1b2c3d4e5-uc.a.run.app/dummies",
+			expected: map[string]string{
+				"platform":    gcpconn.PlatformCloudRun,
+				"service":     "my-function",
+				"function":    "my-function",
+				"region_code": "uc",
+			},
+		},
+		{
+			uri: "https://my-gateway-8x7yzabc.uc.gateway.dev/dummies",
+			expected: map[string]string{
+				"platform":    gcpconn.PlatformApiGateway,
+				"gateway":     "my-gateway",
+				"region_code": "uc",
+				"function":    "dummies",
+			},
+		},
+		{
+			uri: "https://api.example.com:8443/dummies/v1",
+			expected: map[string]string{
+				"platform": gcpconn.PlatformCustom,
+				"function": "dummies",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		connection, err := gcpconn.GcpUriParser.Parse("123", tc.uri)
+		assert.Nil(t, err, tc.uri)
+
+		uri, _ := connection.Uri()
+		assert.Equal(t, tc.uri, uri)
+		for key, value := range tc.expected {
+			assert.Equal(t, value, connection.GetAsString(key), tc.uri+" "+key)
+		}
+	}
+
+	// Parts that are not in the uri are not set
+	connection, _ := gcpconn.GcpUriParser.Parse("123", "https://api.example.com")
+	_, ok := connection.Function()
+	assert.False(t, ok)
+	_, ok = connection.Region()
+	assert.False(t, ok)
+
+	_, err := gcpconn.GcpUriParser.Parse("123", "ftp://us-central1-myproject.cloudfunctions.net/myfunction")
+	assert.NotNil(t, err)
+	assert.Equal(t, "WRONG_PROTOCOL", err.(*cerr.ApplicationError).Code)
+
+	_, err = gcpconn.GcpUriParser.Parse("123", "/myfunction")
+	assert.NotNil(t, err)
+	assert.Equal(t, "INVALID_CONNECTION_URI", err.(*cerr.ApplicationError).Code)
+}
+
+func TestComposeUri(t *testing.T) {
+	uri, err := gcpconn.GcpUriParser.Compose("123", gcpconn.NewGcpConnectionParamsFromTuples(
+		"connection.region", "us-central1",
+		"connection.project_id", "my-project",
+		"connection.function", "myfunction",
+	))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://us-central1-my-project.cloudfunctions.net/myfunction", uri)
+
+	uri, err = gcpconn.GcpUriParser.Compose("123", gcpconn.NewGcpConnectionParamsFromTuples(
+		"connection.region", "europe-west3",
+		"connection.project_number", "123456789012",
+		"connection.function", "MyFunction",
+	))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://myfunction-123456789012.europe-west3.run.app", uri)
+
+	_, err = gcpconn.GcpUriParser.Compose("123", gcpconn.NewGcpConnectionParamsFromTuples(
+		"connection.platform", gcpconn.PlatformCloudRun,
+		"connection.region", "europe-west3",
+		"connection.project_id", "my-project",
+		"connection.service", "dummies",
+	))
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_CONNECTION_URI", err.(*cerr.ApplicationError).Code)
+
+	_, err = gcpconn.GcpUriParser.Compose("123", gcpconn.NewGcpConnectionParamsFromTuples(
+		"connection.platform", gcpconn.PlatformApiGateway,
+		"connection.gateway", "my-gateway",
+	))
+	assert.NotNil(t, err)
+}
+
+func TestResolveCloudRunConnection(t *testing.T) {
+	ctx := context.Background()
+
+	resolver := gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.region", "us-east1",
+		"connection.project_number", "123456789012",
+		"connection.service", "dummies",
+	))
+	connection, err := resolver.Resolve("123")
+	assert.Nil(t, err)
+
+	uri, _ := connection.Uri()
+	assert.Equal(t, "https://dummies-123456789012.us-east1.run.app", uri)
+	platform, _ := connection.Platform()
+	assert.Equal(t, gcpconn.PlatformCloudRun, platform)
+	function, _ := connection.Function()
+	assert.Equal(t, "dummies", function)
+
+	// Configured parameters take precedence over parsed ones
+	resolver = gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", "https://dummies.example.com/v1",
+		"connection.region", "us-central1",
+		"connection.project_id", "my-project",
+	))
+	connection, err = resolver.Resolve("123")
+	assert.Nil(t, err)
+
+	region, _ := connection.Region()
+	assert.Equal(t, "us-central1", region)
+	projectId, _ := connection.ProjectId()
+	assert.Equal(t, "my-project", projectId)
+	function, _ = connection.Function()
+	assert.Equal(t, "v1", function)
+	platform, _ = connection.Platform()
+	assert.Equal(t, gcpconn.PlatformCustom, platform)
+
+	// Incomplete connections are rejected
+	resolver = gcpconn.NewGcpConnectionResolver()
+	resolver.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.region", "us-east1",
+		"connection.service", "dummies",
+	))
+	_, err = resolver.Resolve("123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "NO_CONNECTION_URI", err.(*cerr.ApplicationError).Code)
+}
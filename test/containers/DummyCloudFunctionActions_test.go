@@ -0,0 +1,154 @@
+package containers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+const dummyCatalogConfig = `
+- descriptor: "pip-services:context-info:default:default:1.0"
+  name: "dummies"
+  options:
+    actions_catalog: true
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "error"
+- descriptor: "pip-services-dummies:controller:default:default:1.0"
+`
+
+func TestCloudFunctionActionsCatalogDisabled(t *testing.T) {
+	function := newRunnerFunction(t)
+	handler := function.GetHandler()
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "_actions"}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var appErr cerr.ApplicationError
+	err := json.Unmarshal(rr.Body.Bytes(), &appErr)
+	assert.Nil(t, err)
+	assert.Equal(t, "NO_ACTION", appErr.Code)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionActionsCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, []byte(dummyCatalogConfig), 0644)
+	assert.Nil(t, err)
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+	handler := function.GetHandler()
+
+	// Concrete schemas describe action parameters
+	err = function.TryRegisterAction("find_dummy",
+		cvalid.NewObjectSchema().WithRequiredProperty("body", cvalid.NewObjectSchema().WithRequiredProperty("key", cconv.String)),
+		func(res http.ResponseWriter, req *http.Request) { res.WriteHeader(http.StatusNoContent) },
+	)
+	assert.Nil(t, err)
+
+	for _, cmd := range []string{"_actions", "describe"} {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "`+cmd+`"}`))
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var catalog []map[string]any
+		err := json.Unmarshal(rr.Body.Bytes(), &catalog)
+		assert.Nil(t, err)
+		assert.Len(t, catalog, 6)
+		assert.Equal(t, "create_dummy", catalog[0]["cmd"])
+		assert.Equal(t, "find_dummy", catalog[2]["cmd"])
+
+		schema := catalog[2]["schema"].(map[string]any)
+		assert.Equal(t, "object", schema["type"])
+		assert.Equal(t, []any{"body"}, schema["required"])
+	}
+	assert.Equal(t, "dummies", function.Info().Name)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionRegisterAction(t *testing.T) {
+	function := NewDummyCloudFunction()
+	action := func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNoContent)
+	}
+
+	err := function.TryRegisterAction("ping", nil, action)
+	assert.Nil(t, err)
+
+	// Duplicates are rejected with errors, or with panics by RegisterAction
+	assert.Panics(t, func() { function.RegisterAction("ping", nil, action) })
+	err = function.TryRegisterAction("ping", nil, action)
+	var appErr *cerr.ApplicationError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, "DUPLICATED_ACTION", appErr.Code)
+
+	err = function.TryRegisterAction("", nil, action)
+	assert.NotNil(t, err)
+	err = function.TryRegisterAction("pong", nil, nil)
+	assert.NotNil(t, err)
+
+	// Registered actions override built-in commands
+	err = function.TryRegisterAction("describe", cvalid.NewObjectSchema().WithRequiredProperty("name", cconv.String).Schema, action)
+	assert.Nil(t, err)
+
+	actions := function.Actions.List()
+	assert.Len(t, actions, 2)
+	assert.Equal(t, "describe", actions[0].Cmd)
+	assert.Equal(t, "ping", actions[1].Cmd)
+
+	assert.True(t, function.UnregisterAction("ping"))
+	assert.False(t, function.UnregisterAction("ping"))
+	_, ok := function.Actions.Get("ping")
+	assert.False(t, ok)
+	assert.Equal(t, 1, function.Actions.Len())
+}
+
+func TestCloudFunctionActionsConcurrency(t *testing.T) {
+	function := newRunnerFunction(t)
+	handler := function.GetHandler()
+
+	action := func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusNoContent)
+	}
+
+	var wg sync.WaitGroup
+	for index := 0; index < 10; index++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "get_dummies"}`))
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			assert.Equal(t, http.StatusOK, rr.Code)
+		}()
+		go func(index int) {
+			defer wg.Done()
+			cmd := "action_" + cconv.StringConverter.ToString(index)
+			assert.Nil(t, function.TryRegisterAction(cmd, nil, action))
+			assert.True(t, function.UnregisterAction(cmd))
+		}(index)
+	}
+	wg.Wait()
+
+	err := function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
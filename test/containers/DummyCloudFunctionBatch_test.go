@@ -0,0 +1,129 @@
+package containers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tdata "github.com/pip-services3-gox/pip-services3-gcp-gox/test/data"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func executeBatch(t *testing.T, handler http.HandlerFunc, body string) (int, []*gcputil.BatchItemResult) {
+	req := httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	var results []*gcputil.BatchItemResult
+	if rr.Code == http.StatusOK {
+		err := json.Unmarshal(rr.Body.Bytes(), &results)
+		assert.Nil(t, err)
+	}
+	return rr.Code, results
+}
+
+func TestCloudFunctionBatch(t *testing.T) {
+	function := newRunnerFunction(t)
+	handler := function.GetHandler()
+
+	code, results := executeBatch(t, handler, `{"cmd": "_batch", "batch": [
+		{"cmd": "create_dummy", "args": {"dummy": {"key": "key 1", "content": "content 1"}}},
+		{"cmd": "get_dummy_by_id"},
+		{"cmd": "unknown"},
+		{"cmd": "_batch"},
+		{"cmd": "get_dummies"}
+	]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, results, 5)
+
+	var dummy tdata.Dummy
+	assert.Equal(t, http.StatusCreated, results[0].Status)
+	err := results[0].DecodeResult(&dummy)
+	assert.Nil(t, err)
+	assert.Equal(t, "key 1", dummy.Key)
+
+	// Failed calls do not fail the whole batch
+	assert.Equal(t, http.StatusBadRequest, results[1].Status)
+	assert.Equal(t, "INVALID_DATA", results[1].Error.Code)
+	assert.NotNil(t, results[1].DecodeResult(&dummy))
+	assert.Equal(t, "NO_ACTION", results[2].Error.Code)
+	assert.Equal(t, "NESTED_BATCH", results[3].Error.Code)
+
+	var page map[string]any
+	err = results[4].DecodeResult(&page)
+	assert.Nil(t, err)
+	assert.Len(t, page["data"], 1)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionBatchParallel(t *testing.T) {
+	function := newRunnerFunction(t)
+	function.Batch.MaxParallel = 3
+	handler := function.GetHandler()
+
+	items := make([]string, 0)
+	for index := 0; index < 10; index++ {
+		items = append(items, `{"cmd": "create_dummy", "args": {"dummy": {"key": "key", "content": "content"}}}`)
+	}
+
+	code, results := executeBatch(t, handler, `{"cmd": "_batch", "parallel": true, "batch": [`+strings.Join(items, ",")+`]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, results, 10)
+	for _, result := range results {
+		assert.Equal(t, "create_dummy", result.Cmd)
+		assert.Nil(t, result.GetError())
+	}
+
+	// Batches are limited
+	function.Batch.MaxItems = 5
+	code, _ = executeBatch(t, handler, `{"cmd": "_batch", "parallel": true, "batch": [`+strings.Join(items, ",")+`]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, _ = executeBatch(t, handler, `{"cmd": "_batch", "batch": []}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	err := function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+const dummyBatchConfig = `
+- descriptor: "pip-services:context-info:default:default:1.0"
+  name: "dummies"
+  options:
+    batch:
+      max_items: 2
+      max_parallel: 4
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "error"
+- descriptor: "pip-services-dummies:controller:default:default:1.0"
+`
+
+func TestCloudFunctionBatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, []byte(dummyBatchConfig), 0644)
+	assert.Nil(t, err)
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+	handler := function.GetHandler()
+
+	// Batch settings are read from the function options
+	code, results := executeBatch(t, handler, `{"cmd": "_batch", "batch": [{"cmd": "get_dummies"}, {"cmd": "get_dummies"}]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, results, 2)
+	assert.Equal(t, 4, function.Batch.MaxParallel)
+
+	code, _ = executeBatch(t, handler, `{"cmd": "_batch", "batch": [{"cmd": "get_dummies"}, {"cmd": "get_dummies"}, {"cmd": "get_dummies"}]}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,181 @@
+package containers_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipBody(t *testing.T, body string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(body))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestCloudFunctionCompressedRequest(t *testing.T) {
+	function := newRunnerFunction(t)
+	handler := function.GetHandler()
+
+	body := gzipBody(t, `{"cmd": "create_dummy", "dummy": {"key": "key 1", "content": "content 1"}}`)
+	req := httptest.NewRequest("POST", "/?correlation_id=123", bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var dummy map[string]any
+	err := json.Unmarshal(rr.Body.Bytes(), &dummy)
+	assert.Nil(t, err)
+	assert.Equal(t, "key 1", dummy["key"])
+
+	// Unsupported encodings are rejected
+	req = httptest.NewRequest("POST", "/?correlation_id=123", bytes.NewReader(body))
+	req.Header.Set("Content-Encoding", "br")
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionBodySizeLimit(t *testing.T) {
+	function := newRunnerFunction(t)
+	function.MaxBodySize = 256
+	handler := function.GetHandler()
+
+	content := strings.Repeat("x", 1000)
+	body := `{"cmd": "create_dummy", "dummy": {"key": "key 1", "content": "` + content + `"}}`
+
+	req := httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	var appErr cerr.ApplicationError
+	err := json.Unmarshal(rr.Body.Bytes(), &appErr)
+	assert.Nil(t, err)
+	assert.Equal(t, "PAYLOAD_TOO_LARGE", appErr.Code)
+
+	// Compressed bodies are limited after decompression
+	req = httptest.NewRequest("POST", "/?correlation_id=123", bytes.NewReader(gzipBody(t, body)))
+	req.Header.Set("Content-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionCompressedResponse(t *testing.T) {
+	function := newRunnerFunction(t)
+	function.CompressionThreshold = 100
+	handler := function.GetHandler()
+
+	content := strings.Repeat("x", 1000)
+	req := httptest.NewRequest("POST", "/?correlation_id=123",
+		strings.NewReader(`{"cmd": "create_dummy", "dummy": {"key": "key 1", "content": "`+content+`"}}`))
+	req.Header.Set("Accept-Encoding", "gzip, deflate")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	assert.Less(t, rr.Body.Len(), len(content))
+
+	reader, err := gzip.NewReader(rr.Body)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+
+	var dummy map[string]any
+	err = json.Unmarshal(data, &dummy)
+	assert.Nil(t, err)
+	assert.Equal(t, content, dummy["content"])
+
+	// Small responses and requests that do not accept gzip are not compressed
+	function.CompressionThreshold = 100000
+	req = httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(`{"cmd": "get_dummies"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", rr.Header().Get("Content-Encoding"))
+
+	req = httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(`{"cmd": "get_dummies"}`))
+	req.Header.Set("Accept-Encoding", "gzip;q=0")
+	function.CompressionThreshold = 1
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", rr.Header().Get("Content-Encoding"))
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionCompressionDisabledByDefault(t *testing.T) {
+	function := newRunnerFunction(t)
+	handler := function.GetHandler()
+
+	content := strings.Repeat("x", 10000)
+	req := httptest.NewRequest("POST", "/?correlation_id=123",
+		strings.NewReader(`{"cmd": "create_dummy", "dummy": {"key": "key 1", "content": "`+content+`"}}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "", rr.Header().Get("Content-Encoding"))
+
+	err := function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionCompressedResponsePanic(t *testing.T) {
+	function := newRunnerFunction(t)
+	function.CompressionThreshold = 1
+	handler := function.GetHandler()
+
+	// Open the function before registering the action
+	req := httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(`{"cmd": "get_dummies"}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	err := function.TryRegisterAction("panic", nil, func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		_, _ = res.Write([]byte("partial"))
+		panic("action failed")
+	})
+	assert.Nil(t, err)
+
+	// Buffered partial responses are not sent on panics
+	req = httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(`{"cmd": "panic"}`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "", rr.Header().Get("Content-Encoding"))
+
+	var appErr cerr.ApplicationError
+	err = json.Unmarshal(rr.Body.Bytes(), &appErr)
+	assert.Nil(t, err)
+	assert.Equal(t, "ACTION_PANIC", appErr.Code)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,224 @@
+package containers_test
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	clog "github.com/pip-services3-gox/pip-services3-components-gox/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionLazyOpen(t *testing.T) {
+	function := newRunnerFunction(t)
+	assert.False(t, function.IsOpen())
+
+	handler := function.GetHandler()
+
+	// Concurrent cold start requests open the function once
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for index := range codes {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "get_dummies"}`))
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			codes[index] = rr.Code
+		}(index)
+	}
+	wg.Wait()
+
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.True(t, function.IsOpen())
+
+	err := function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionLazyOpenError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+	handler := function.GetHandler()
+
+	// Startup errors are returned to callers
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "get_dummies"}`))
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Contains(t, rr.Body.String(), "FUNCTION_NOT_STARTED")
+	assert.False(t, function.IsOpen())
+
+	// The next request tries to start the function again
+	err := os.WriteFile(path, []byte(dummyRunnerConfig), 0644)
+	assert.Nil(t, err)
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "get_dummies"}`))
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, function.IsOpen())
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+// File system that panics when configuration is read
+type panicFS struct{}
+
+func (c panicFS) Open(name string) (fs.File, error) {
+	panic("file system failed")
+}
+
+func TestCloudFunctionLazyOpenPanic(t *testing.T) {
+	function := NewDummyCloudFunction()
+	function.SetConfigPath("config.yml")
+	function.SetConfigFS(panicFS{})
+
+	// Panics while opening are returned as errors
+	err := function.OpenOnce(context.Background(), "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "OPEN_PANIC", err.(*cerr.ApplicationError).Code)
+	assert.Equal(t, "file system failed", err.(*cerr.ApplicationError).Cause)
+	assert.False(t, function.IsOpen())
+}
+
+func TestCloudFunctionMissingConfigParam(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, []byte(dummyRunnerConfig+`  options:
+    max_page_size: {{DUMMY_MAX_PAGE_SIZE}}
+`), 0644)
+	assert.Nil(t, err)
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+	function.SetEnvPrefix("DUMMY_")
+
+	// Missing parameters are returned as errors that name them
+	err = function.OpenOnce(context.Background(), "123")
+	assert.NotNil(t, err)
+	assert.Equal(t, "MISSING_CONFIG_PARAM", err.(*cerr.ApplicationError).Code)
+	assert.Equal(t, "DUMMY_MAX_PAGE_SIZE", err.(*cerr.ApplicationError).Details["key"])
+	assert.False(t, function.IsOpen())
+
+	t.Setenv("DUMMY_MAX_PAGE_SIZE", "100")
+	err = function.OpenOnce(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.True(t, function.IsOpen())
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, []byte(dummyRunnerConfig), 0644)
+	assert.Nil(t, err)
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+
+	err = function.OpenOnce(context.Background(), "123")
+	assert.Nil(t, err)
+
+	logger := function.References.GetOneOptional(
+		crefer.NewDescriptor("pip-services", "logger", "console", "default", "1.0")).(*clog.ConsoleLogger)
+	assert.Equal(t, clog.LevelError, logger.Level())
+
+	// Changed configuration is passed to opened components
+	err = os.WriteFile(path, []byte(strings.Replace(dummyRunnerConfig, `"error"`, `"debug"`, 1)), 0644)
+	assert.Nil(t, err)
+
+	err = function.ReloadConfig(context.Background(), "123")
+	assert.Nil(t, err)
+	assert.Equal(t, clog.LevelDebug, logger.Level())
+
+	// Periodic reloading stops when the function is closed
+	function.SetConfigReloadInterval(10)
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+
+	err = function.OpenOnce(context.Background(), "123")
+	assert.Nil(t, err)
+	time.Sleep(30 * time.Millisecond)
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionConfigReloadConcurrency(t *testing.T) {
+	config := func(level string, catalog bool) []byte {
+		return []byte(strings.Replace(dummyRunnerConfig, `"error"`, `"`+level+`"`, 1) + `- descriptor: "pip-services:context-info:default:default:1.0"
+  name: "dummies"
+  options:
+    actions_catalog: ` + strconv.FormatBool(catalog) + `
+    batch:
+      max_items: 10
+`)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, config("error", true), 0644)
+	assert.Nil(t, err)
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+	handler := function.GetHandler()
+
+	err = function.OpenOnce(context.Background(), "123")
+	assert.Nil(t, err)
+
+	// Configuration is reloaded while requests are served
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for index := 0; index < 4; index++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				for _, cmd := range []string{"get_dummies", "_actions"} {
+					req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd": "`+cmd+`"}`))
+					rr := httptest.NewRecorder()
+					handler(rr, req)
+					assert.Contains(t, []int{http.StatusOK, http.StatusBadRequest}, rr.Code)
+				}
+			}
+		}()
+	}
+
+	for index := 0; index < 10; index++ {
+		err = os.WriteFile(path, config([]string{"error", "debug"}[index%2], index%2 == 1), 0644)
+		assert.Nil(t, err)
+
+		err = function.ReloadConfig(context.Background(), "123")
+		assert.Nil(t, err)
+		assert.Equal(t, index%2 == 1, function.ActionsCatalog)
+	}
+
+	close(done)
+	wg.Wait()
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,102 @@
+package containers_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFunctionOpenApi(t *testing.T) {
+	function := newRunnerFunction(t)
+	function.SetOpenApiRoute("/openapi.json")
+	handler := function.GetHandler()
+
+	err := function.TryRegisterAction("find_dummy",
+		cvalid.NewObjectSchema().WithRequiredProperty("body", cvalid.NewObjectSchema().WithRequiredProperty("dummy_id", cconv.String)),
+		func(res http.ResponseWriter, req *http.Request) { res.WriteHeader(http.StatusNoContent) },
+	)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc map[string]any
+	err = json.Unmarshal(rr.Body.Bytes(), &doc)
+	assert.Nil(t, err)
+	assert.Equal(t, "3.0.3", doc["openapi"])
+
+	operation := doc["paths"].(map[string]any)["/"].(map[string]any)["post"].(map[string]any)
+	content := operation["requestBody"].(map[string]any)["content"].(map[string]any)
+	schema := content["application/json"].(map[string]any)["schema"].(map[string]any)
+	assert.Len(t, schema["oneOf"], 6)
+
+	discriminator := schema["discriminator"].(map[string]any)
+	assert.Equal(t, "cmd", discriminator["propertyName"])
+	assert.Equal(t, "#/components/schemas/find_dummy", discriminator["mapping"].(map[string]any)["find_dummy"])
+
+	responses := operation["responses"].(map[string]any)
+	assert.NotNil(t, responses["200"])
+	assert.NotNil(t, responses["400"])
+
+	// Request body schemas are taken from registered schemas
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	assert.NotNil(t, schemas["ErrorDescription"])
+
+	action := schemas["find_dummy"].(map[string]any)
+	assert.Equal(t, []any{"cmd", "dummy_id"}, action["required"])
+	properties := action["properties"].(map[string]any)
+	assert.Equal(t, []any{"find_dummy"}, properties["cmd"].(map[string]any)["enum"])
+	assert.Equal(t, "string", properties["dummy_id"].(map[string]any)["type"])
+
+	err = function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionOpenApiDisabled(t *testing.T) {
+	function := newRunnerFunction(t)
+	handler := function.GetHandler()
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	err := function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func TestCloudFunctionOpenApiRoute(t *testing.T) {
+	function := newRunnerFunction(t)
+	function.SetOpenApiRoute("/openapi.json")
+	handler := function.GetHandler()
+
+	// Only the exact route serves the document
+	req := httptest.NewRequest("GET", "/foo/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The function name is trimmed from the path
+	t.Setenv("K_SERVICE", "dummies")
+	req = httptest.NewRequest("GET", "/dummies/openapi.json", nil)
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req = httptest.NewRequest("GET", "/other/openapi.json", nil)
+	rr = httptest.NewRecorder()
+	handler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	err := function.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
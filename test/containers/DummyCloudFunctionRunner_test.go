@@ -0,0 +1,120 @@
+package containers_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gcpcont "github.com/pip-services3-gox/pip-services3-gcp-gox/containers"
+	"github.com/stretchr/testify/assert"
+)
+
+const dummyRunnerConfig = `
+- descriptor: "pip-services:logger:console:default:1.0"
+  level: "error"
+- descriptor: "pip-services-dummies:controller:default:default:1.0"
+`
+
+func newRunnerFunction(t *testing.T) *DummyCloudFunction {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	err := os.WriteFile(path, []byte(dummyRunnerConfig), 0644)
+	assert.Nil(t, err)
+
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(path)
+	return function
+}
+
+func TestCloudFunctionRunnerHandler(t *testing.T) {
+	ctx := context.Background()
+
+	function := NewDummyCloudFunction()
+	err := function.Open(ctx, "")
+	assert.Nil(t, err)
+	defer function.Close(ctx, "")
+
+	runner := gcpcont.NewCloudFunctionRunner()
+	runner.AddFunction("/dummies", function.CloudFunction)
+	handler := runner.GetHandler()
+
+	// Liveness does not depend on functions
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// Runner is not ready until it is started
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	// Calls are routed to the mounted function
+	fixture := NewDummyCloudFunctionFixture(func(res http.ResponseWriter, req *http.Request) {
+		req.URL.Path = "/dummies"
+		handler.ServeHTTP(res, req)
+	})
+	t.Run("CRUD Operations", fixture.TestCrudOperations)
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("POST", "/unknown", strings.NewReader(`{"cmd": "get_dummies"}`)))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCloudFunctionRunnerRun(t *testing.T) {
+	function := newRunnerFunction(t)
+
+	runner := gcpcont.NewCloudFunctionRunner()
+	runner.Port = 0
+	runner.AddFunction("/", function.CloudFunction)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runner.Run(ctx)
+	}()
+
+	assert.Eventually(t, runner.IsReady, 5*time.Second, 10*time.Millisecond)
+	assert.True(t, function.IsOpen())
+
+	// Spare keep-alive connections delay graceful shutdown
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	res, err := client.Post("http://"+runner.Addr()+"/", "application/json",
+		strings.NewReader(`{"cmd": "get_dummies"}`))
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = client.Get("http://" + runner.Addr() + "/readyz")
+	assert.Nil(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Canceled context stops the runner gracefully
+	cancel()
+	select {
+	case err = <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Runner was not stopped")
+	}
+	assert.False(t, runner.IsReady())
+	assert.False(t, function.IsOpen())
+}
+
+func TestCloudFunctionRunnerConfigError(t *testing.T) {
+	function := NewDummyCloudFunction()
+	function.SetConfigPath(filepath.Join(t.TempDir(), "missing.yml"))
+
+	runner := gcpcont.NewCloudFunctionRunner()
+	runner.Port = 0
+	runner.AddFunction("/", function.CloudFunction)
+
+	err := runner.Run(context.Background())
+	assert.NotNil(t, err)
+	assert.False(t, function.IsOpen())
+}
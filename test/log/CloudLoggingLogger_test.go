@@ -0,0 +1,101 @@
+package log_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcplog "github.com/pip-services3-gox/pip-services3-gcp-gox/log"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestLogger(buffer *bytes.Buffer) *gcplog.CloudLoggingLogger {
+	logger := gcplog.NewCloudLoggingLogger()
+	logger.Writer = buffer
+	logger.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"level", "debug",
+		"source", "dummies",
+		"project_id", "test-project",
+		"labels.env", "test",
+	))
+	return logger
+}
+
+func readEntries(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	entries := make([]map[string]any, 0)
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var entry map[string]any
+		assert.Nil(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestCloudLoggingLoggerEntries(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := newTestLogger(buffer)
+
+	logger.Info(context.Background(), "123", "Hello %s", "world")
+	logger.Trace(context.Background(), "123", "Filtered out")
+	logger.Warn(context.Background(), "", "Attention")
+
+	entries := readEntries(t, buffer)
+	assert.Len(t, entries, 2)
+
+	assert.Equal(t, "INFO", entries[0]["severity"])
+	assert.Equal(t, "Hello world", entries[0]["message"])
+	labels := entries[0]["logging.googleapis.com/labels"].(map[string]any)
+	assert.Equal(t, "123", labels["correlation_id"])
+	assert.Equal(t, "dummies", labels["source"])
+	assert.Equal(t, "test", labels["env"])
+
+	assert.Equal(t, "WARNING", entries[1]["severity"])
+	assert.NotContains(t, entries[1], "logging.googleapis.com/trace")
+}
+
+func TestCloudLoggingLoggerTrace(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := newTestLogger(buffer)
+
+	trace, ok := gcputil.ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	assert.True(t, ok)
+	ctx := gcputil.ContextWithTraceContext(context.Background(), trace)
+
+	logger.Debug(ctx, "123", "Traced")
+
+	entries := readEntries(t, buffer)
+	assert.Equal(t, "DEBUG", entries[0]["severity"])
+	assert.Equal(t, "projects/test-project/traces/0af7651916cd43dd8448eb211c80319c", entries[0]["logging.googleapis.com/trace"])
+	assert.Equal(t, "b7ad6b7169203331", entries[0]["logging.googleapis.com/spanId"])
+	assert.Equal(t, true, entries[0]["logging.googleapis.com/trace_sampled"])
+}
+
+func TestCloudLoggingLoggerErrors(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := newTestLogger(buffer)
+
+	appErr := cerr.NewBadRequestError("123", "INVALID_DATA", "Invalid data")
+	appErr.StackTrace = "main.go:10"
+	logger.Error(context.Background(), "123", appErr, "Failed")
+	logger.Fatal(context.Background(), "123", errors.New("crash"), "")
+
+	entries := readEntries(t, buffer)
+	assert.Len(t, entries, 2)
+
+	assert.Equal(t, "ERROR", entries[0]["severity"])
+	assert.True(t, strings.HasPrefix(entries[0]["message"].(string), "Failed: Invalid data"))
+	errorInfo := entries[0]["error"].(map[string]any)
+	assert.Equal(t, "INVALID_DATA", errorInfo["code"])
+	assert.Equal(t, "main.go:10", entries[0]["stack_trace"])
+
+	assert.Equal(t, "CRITICAL", entries[1]["severity"])
+	assert.True(t, strings.HasPrefix(entries[1]["message"].(string), "Error: crash"))
+	// Stack traces of the logger itself are not reported
+	assert.Nil(t, entries[1]["stack_trace"])
+}
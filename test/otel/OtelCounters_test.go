@@ -0,0 +1,101 @@
+package otel_test
+
+import (
+	"context"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	gcpotel "github.com/pip-services3-gox/pip-services3-gcp-gox/otel"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOtelCounters(t *testing.T) {
+	collector := newCollectorStub()
+	defer collector.server.Close()
+
+	ctx := context.Background()
+
+	counters := gcpotel.NewOtelCounters()
+	counters.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"source", "dummies",
+		"connection.uri", collector.server.URL,
+	))
+	err := counters.Open(ctx, "")
+	assert.Nil(t, err)
+
+	counters.Increment(ctx, "dummies.call_count", 2)
+	counters.Last(ctx, "dummies.last", 5)
+	counters.EndTiming(ctx, "dummies.exec_time", 10)
+	counters.EndTiming(ctx, "dummies.exec_time", 30)
+
+	err = counters.Close(ctx, "")
+	assert.Nil(t, err)
+
+	requests := collector.get("/v1/metrics")
+	assert.Len(t, requests, 1)
+
+	metrics := make(map[string]any)
+	for _, metric := range getPath(requests[0], "resourceMetrics", 0, "scopeMetrics", 0, "metrics").([]any) {
+		metrics[getPath(metric, "name").(string)] = metric
+	}
+	assert.Len(t, metrics, 3)
+
+	sum := metrics["dummies.call_count"]
+	assert.Equal(t, true, getPath(sum, "sum", "isMonotonic"))
+	assert.Equal(t, "2", getPath(sum, "sum", "dataPoints", 0, "asInt"))
+
+	gauge := metrics["dummies.last"]
+	assert.Equal(t, float64(5), getPath(gauge, "gauge", "dataPoints", 0, "asDouble"))
+
+	summary := metrics["dummies.exec_time"]
+	assert.Equal(t, "ms", getPath(summary, "unit"))
+	assert.Equal(t, "2", getPath(summary, "summary", "dataPoints", 0, "count"))
+	assert.Equal(t, float64(40), getPath(summary, "summary", "dataPoints", 0, "sum"))
+	assert.Equal(t, float64(10), getPath(summary, "summary", "dataPoints", 0, "quantileValues", 0, "value"))
+	assert.Equal(t, float64(30), getPath(summary, "summary", "dataPoints", 0, "quantileValues", 1, "value"))
+}
+
+func TestOtelCountersReset(t *testing.T) {
+	collector := newCollectorStub()
+	defer collector.server.Close()
+
+	ctx := context.Background()
+
+	counters := gcpotel.NewOtelCounters()
+	counters.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", collector.server.URL,
+	))
+	err := counters.Open(ctx, "")
+	assert.Nil(t, err)
+	defer counters.Close(ctx, "")
+
+	getSum := func(index int, name string) any {
+		requests := collector.get("/v1/metrics")
+		assert.Len(t, requests, index+1)
+		for _, metric := range getPath(requests[index], "resourceMetrics", 0, "scopeMetrics", 0, "metrics").([]any) {
+			if getPath(metric, "name") == name {
+				return getPath(metric, "sum", "dataPoints", 0)
+			}
+		}
+		return nil
+	}
+
+	counters.Increment(ctx, "dummies.call_count", 5)
+	counters.Increment(ctx, "dummies.error_count", 1)
+	err = counters.Dump(ctx)
+	assert.Nil(t, err)
+	first := getSum(0, "dummies.call_count")
+	firstErrors := getSum(0, "dummies.error_count")
+
+	// Counters that were reset start after the previous export
+	counters.Clear(ctx, "dummies.call_count")
+	counters.Increment(ctx, "dummies.call_count", 2)
+	err = counters.Dump(ctx)
+	assert.Nil(t, err)
+	second := getSum(1, "dummies.call_count")
+	secondErrors := getSum(1, "dummies.error_count")
+
+	assert.Equal(t, "2", getPath(second, "asInt"))
+	assert.Equal(t, getPath(first, "timeUnixNano"), getPath(second, "startTimeUnixNano"))
+	assert.Equal(t, getPath(firstErrors, "startTimeUnixNano"), getPath(secondErrors, "startTimeUnixNano"))
+}
@@ -0,0 +1,249 @@
+package otel_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	gcpotel "github.com/pip-services3-gox/pip-services3-gcp-gox/otel"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// collectorStub records OTLP/HTTP export requests
+type collectorStub struct {
+	server   *httptest.Server
+	mtx      sync.Mutex
+	requests map[string][]map[string]any
+	headers  http.Header
+	status   int32
+}
+
+func newCollectorStub() *collectorStub {
+	c := &collectorStub{
+		requests: make(map[string][]map[string]any),
+	}
+	c.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status := atomic.LoadInt32(&c.status); status != 0 {
+			w.WriteHeader(int(status))
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		_ = json.Unmarshal(body, &payload)
+
+		c.mtx.Lock()
+		c.requests[r.URL.Path] = append(c.requests[r.URL.Path], payload)
+		c.headers = r.Header.Clone()
+		c.mtx.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	return c
+}
+
+func (c *collectorStub) get(path string) []map[string]any {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.requests[path]
+}
+
+func getPath(value any, path ...any) any {
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			value = value.(map[string]any)[k]
+		case int:
+			value = value.([]any)[k]
+		}
+	}
+	return value
+}
+
+func getAttributes(span any) map[string]any {
+	result := make(map[string]any)
+	for _, attribute := range getPath(span, "attributes").([]any) {
+		item := attribute.(map[string]any)
+		for _, value := range item["value"].(map[string]any) {
+			result[item["key"].(string)] = value
+		}
+	}
+	return result
+}
+
+func TestOtelTracer(t *testing.T) {
+	collector := newCollectorStub()
+	defer collector.server.Close()
+
+	ctx := context.Background()
+
+	tracer := gcpotel.NewOtelTracer()
+	tracer.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"source", "dummies",
+		"connection.uri", collector.server.URL,
+		"headers.x-api-key", "key123",
+	))
+	err := tracer.Open(ctx, "")
+	assert.Nil(t, err)
+
+	parent, _ := gcputil.ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	serverCtx := gcputil.ContextWithTraceContext(ctx, parent.NewChildSpan(gcputil.SpanKindServer))
+	server, _ := gcputil.TraceContextFromContext(serverCtx)
+	clientCtx := gcputil.CloudFunctionRequestHelper.StartSpan(serverCtx, gcputil.SpanKindClient)
+
+	tracer.Trace(clientCtx, "123", "dummies.get_dummies", "", 10)
+	tracer.Failure(serverCtx, "123", "dummies.create_dummy", "",
+		cerr.NewBadRequestError("123", "INVALID_DATA", "Invalid data"), 20)
+
+	assert.Len(t, collector.get("/v1/traces"), 0)
+
+	err = tracer.Close(ctx, "")
+	assert.Nil(t, err)
+
+	requests := collector.get("/v1/traces")
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "key123", collector.headers.Get("x-api-key"))
+
+	resource := getAttributes(getPath(requests[0], "resourceSpans", 0, "resource"))
+	assert.Equal(t, "dummies", resource["service.name"])
+
+	spans := getPath(requests[0], "resourceSpans", 0, "scopeSpans", 0, "spans").([]any)
+	assert.Len(t, spans, 2)
+
+	clientSpan := spans[0].(map[string]any)
+	assert.Equal(t, "dummies.get_dummies", clientSpan["name"])
+	assert.Equal(t, float64(3), clientSpan["kind"])
+	assert.Equal(t, parent.TraceId, clientSpan["traceId"])
+	assert.Equal(t, server.SpanId, clientSpan["parentSpanId"])
+	assert.Equal(t, float64(1), getPath(clientSpan, "status", "code"))
+	attributes := getAttributes(clientSpan)
+	assert.Equal(t, "dummies.get_dummies", attributes["cmd"])
+	assert.Equal(t, "123", attributes["correlation_id"])
+
+	serverSpan := spans[1].(map[string]any)
+	assert.Equal(t, float64(2), serverSpan["kind"])
+	assert.Equal(t, server.SpanId, serverSpan["spanId"])
+	assert.Equal(t, parent.SpanId, serverSpan["parentSpanId"])
+	assert.Equal(t, float64(2), getPath(serverSpan, "status", "code"))
+	attributes = getAttributes(serverSpan)
+	assert.Equal(t, "400", attributes["http.response.status_code"])
+	assert.Equal(t, "INVALID_DATA", attributes["error.type"])
+}
+
+func TestOtelTracerBatch(t *testing.T) {
+	collector := newCollectorStub()
+	defer collector.server.Close()
+
+	ctx := context.Background()
+
+	tracer := gcpotel.NewOtelTracer()
+	tracer.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", collector.server.URL,
+		"options.batch_size", 1,
+	))
+	err := tracer.Open(ctx, "")
+	assert.Nil(t, err)
+	defer tracer.Close(ctx, "")
+
+	// Spans without trace context start new traces
+	timing := tracer.BeginTrace(ctx, "", "dummies", "get_dummies")
+	timing.EndTrace(ctx)
+
+	// Full batches are exported in background
+	assert.Eventually(t, func() bool {
+		return len(collector.get("/v1/traces")) == 1
+	}, time.Second, 5*time.Millisecond)
+	requests := collector.get("/v1/traces")
+	span := getPath(requests[0], "resourceSpans", 0, "scopeSpans", 0, "spans", 0)
+	assert.Equal(t, "dummies.get_dummies", getPath(span, "name"))
+	assert.Equal(t, float64(1), getPath(span, "kind"))
+	assert.Len(t, getPath(span, "traceId"), 32)
+	assert.Nil(t, getPath(span, "parentSpanId"))
+}
+
+func TestOtelTracerSpanIds(t *testing.T) {
+	collector := newCollectorStub()
+	defer collector.server.Close()
+
+	ctx := context.Background()
+
+	tracer := gcpotel.NewOtelTracer()
+	tracer.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", collector.server.URL,
+	))
+	err := tracer.Open(ctx, "")
+	assert.Nil(t, err)
+
+	parent, _ := gcputil.ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	remoteCtx := gcputil.ContextWithTraceContext(ctx, parent)
+	serverCtx := gcputil.CloudFunctionRequestHelper.StartSpan(remoteCtx, gcputil.SpanKindServer)
+	server, _ := gcputil.TraceContextFromContext(serverCtx)
+
+	// Each operation traced in the same context gets its own span
+	tracer.Trace(serverCtx, "123", "dummies.get_dummies", "", 10)
+	tracer.Trace(serverCtx, "123", "dummies.get_dummy_by_id", "", 10)
+	// Remote spans are not recorded again
+	tracer.Trace(remoteCtx, "123", "dummies.create_dummy", "", 10)
+
+	err = tracer.Close(ctx, "")
+	assert.Nil(t, err)
+
+	requests := collector.get("/v1/traces")
+	assert.Len(t, requests, 1)
+	spans := getPath(requests[0], "resourceSpans", 0, "scopeSpans", 0, "spans").([]any)
+	assert.Len(t, spans, 3)
+
+	assert.Equal(t, server.SpanId, getPath(spans[0], "spanId"))
+	assert.Equal(t, parent.SpanId, getPath(spans[0], "parentSpanId"))
+
+	assert.NotEqual(t, server.SpanId, getPath(spans[1], "spanId"))
+	assert.Equal(t, server.SpanId, getPath(spans[1], "parentSpanId"))
+	assert.Equal(t, float64(1), getPath(spans[1], "kind"))
+
+	assert.NotEqual(t, parent.SpanId, getPath(spans[2], "spanId"))
+	assert.Equal(t, parent.SpanId, getPath(spans[2], "parentSpanId"))
+}
+
+func TestOtelTracerExportFailure(t *testing.T) {
+	collector := newCollectorStub()
+	defer collector.server.Close()
+	atomic.StoreInt32(&collector.status, http.StatusServiceUnavailable)
+
+	ctx := context.Background()
+
+	tracer := gcpotel.NewOtelTracer()
+	tracer.Configure(ctx, cconf.NewConfigParamsFromTuples(
+		"connection.uri", collector.server.URL,
+		"options.max_queue_size", 2,
+	))
+	err := tracer.Open(ctx, "")
+	assert.Nil(t, err)
+
+	tracer.Trace(ctx, "123", "dummies.get_dummies", "", 10)
+	err = tracer.Flush(ctx)
+	assert.NotNil(t, err)
+
+	// Spans that failed to export are kept within the buffer size
+	tracer.Trace(ctx, "123", "dummies.get_dummy_by_id", "", 10)
+	tracer.Trace(ctx, "123", "dummies.create_dummy", "", 10)
+
+	atomic.StoreInt32(&collector.status, 0)
+	err = tracer.Close(ctx, "")
+	assert.Nil(t, err)
+
+	requests := collector.get("/v1/traces")
+	assert.Len(t, requests, 1)
+	spans := getPath(requests[0], "resourceSpans", 0, "scopeSpans", 0, "spans").([]any)
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "dummies.get_dummy_by_id", getPath(spans[0], "name"))
+	assert.Equal(t, "dummies.create_dummy", getPath(spans[1], "name"))
+}
@@ -0,0 +1,38 @@
+package services_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	tdata "github.com/pip-services3-gox/pip-services3-gcp-gox/test/data"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceBatchAction(t *testing.T) {
+	service := newTypedActionService()
+	service.RegisterBatchAction()
+	assert.Equal(t, "typed._batch", service.GetActions()[1].Cmd)
+
+	rr := invokeTypedAction(service, 1, `{"cmd": "typed._batch", "parallel": true, "batch": [
+		{"cmd": "typed.get_dummy_by_id", "args": {"dummy_id": "1"}},
+		{"cmd": "typed.get_dummy_by_id", "args": {"dummy_id": "failed"}},
+		{"cmd": "typed._batch"}
+	]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var results []*gcputil.BatchItemResult
+	err := json.Unmarshal(rr.Body.Bytes(), &results)
+	assert.Nil(t, err)
+	assert.Len(t, results, 3)
+
+	// Calls keep correlation id of the batch
+	var dummy tdata.Dummy
+	err = results[0].DecodeResult(&dummy)
+	assert.Nil(t, err)
+	assert.Equal(t, "123", dummy.Content)
+
+	assert.Equal(t, http.StatusNotFound, results[1].Status)
+	assert.Equal(t, "NESTED_BATCH", results[2].Error.Code)
+}
@@ -0,0 +1,187 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cdata "github.com/pip-services3-gox/pip-services3-commons-gox/data"
+	tdata "github.com/pip-services3-gox/pip-services3-gcp-gox/test/data"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCloudEventsFunction(t *testing.T) *DummyCloudFunction {
+	config := cconf.NewConfigParamsFromTuples(
+		"logger.descriptor", "pip-services:logger:console:default:1.0",
+		"service.descriptor", "pip-services-dummies:service:cloudfunc:default:1.0",
+	)
+
+	ctx := context.Background()
+
+	funcContainer := NewDummyCloudFunction()
+	funcContainer.Configure(ctx, config)
+	err := funcContainer.Open(ctx, "")
+	assert.Nil(t, err)
+
+	return funcContainer
+}
+
+func getDummiesCount(t *testing.T, handler http.HandlerFunc) int {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd":"dummies.get_dummies"}`))
+	req.Header.Add("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	var page cdata.DataPage[tdata.Dummy]
+	err := json.Unmarshal(rr.Body.Bytes(), &page)
+	assert.Nil(t, err)
+	return len(page.Data)
+}
+
+func TestCloudEventsStructuredMode(t *testing.T) {
+	funcContainer := newCloudEventsFunction(t)
+	defer funcContainer.Close(context.Background(), "")
+	handler := funcContainer.GetHandler()
+
+	body := `{
+		"specversion": "1.0",
+		"id": "1",
+		"source": "//example.com/dummies",
+		"type": "com.example.dummy.v1.created",
+		"datacontenttype": "application/json",
+		"data": {"key": "key 1", "content": "content 1"}
+	}`
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/cloudevents+json")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+}
+
+func TestCloudEventsBinaryMode(t *testing.T) {
+	funcContainer := newCloudEventsFunction(t)
+	defer funcContainer.Close(context.Background(), "")
+	handler := funcContainer.GetHandler()
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"key": "key 2", "content": "content 2"}`))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("ce-specversion", "1.0")
+	req.Header.Add("ce-id", "2")
+	req.Header.Add("ce-source", "//example.com/dummies")
+	req.Header.Add("ce-type", "com.example.dummy.v1.created")
+	req.Header.Add("ce-correlationid", "123")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+}
+
+func TestCloudEventsErrors(t *testing.T) {
+	funcContainer := newCloudEventsFunction(t)
+	defer funcContainer.Close(context.Background(), "")
+	handler := funcContainer.GetHandler()
+
+	sendEvent := func(eventType string, id string, data string) map[string]any {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(data))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("ce-specversion", "1.0")
+		req.Header.Add("ce-id", id)
+		req.Header.Add("ce-source", "//example.com/dummies")
+		req.Header.Add("ce-type", eventType)
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+		var body map[string]any
+		err := json.Unmarshal(rr.Body.Bytes(), &body)
+		assert.Nil(t, err)
+		return body
+	}
+
+	// Event without id
+	body := sendEvent("com.example.dummy.v1.created", "", `{"key": "key 1"}`)
+	assert.Equal(t, "INVALID_EVENT", body["code"])
+
+	// Event without registered handler
+	body = sendEvent("com.example.dummy.v1.deleted", "1", `{"key": "key 1"}`)
+	assert.Equal(t, "NO_EVENT_HANDLER", body["code"])
+
+	// Event data that fails validation
+	body = sendEvent("com.example.dummy.v1.created", "1", `{"content": "content 1"}`)
+	assert.Equal(t, "INVALID_DATA", body["code"])
+
+	assert.Equal(t, 0, getDummiesCount(t, handler))
+}
+
+func TestStorageObjectEvents(t *testing.T) {
+	funcContainer := newCloudEventsFunction(t)
+	defer funcContainer.Close(context.Background(), "")
+	handler := funcContainer.GetHandler()
+
+	sendEvent := func(bucket string, name string) int {
+		body := `{
+			"specversion": "1.0",
+			"id": "1",
+			"source": "//storage.googleapis.com/projects/_/buckets/` + bucket + `",
+			"type": "google.cloud.storage.object.v1.finalized",
+			"subject": "objects/` + name + `",
+			"datacontenttype": "application/json",
+			"data": {
+				"kind": "storage#object",
+				"name": "` + name + `",
+				"bucket": "` + bucket + `",
+				"generation": "1700000000000000",
+				"size": "128",
+				"contentType": "application/json"
+			}
+		}`
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Add("Content-Type", "application/cloudevents+json")
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusNoContent, sendEvent("dummies", "uploads/dummy1.json"))
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+
+	// Objects that don't match bucket or name filters are not handled
+	assert.Equal(t, http.StatusBadRequest, sendEvent("dummies", "uploads/dummy2.txt"))
+	assert.Equal(t, http.StatusBadRequest, sendEvent("others", "uploads/dummy3.json"))
+	assert.Equal(t, http.StatusBadRequest, sendEvent("dummies", "dummy4.json"))
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+}
+
+func TestStorageObjectNameFilters(t *testing.T) {
+	service := NewDummyCloudFunctionService()
+	service.RegisterStorageObjectHandler(gcputil.StorageObjectFinalizedEventType, "dummies", "a.json", ".json",
+		func(ctx context.Context, correlationId string, object *gcputil.StorageObjectData) error { return nil })
+	handlers := service.GetEventHandlers()
+	handler := handlers[len(handlers)-1]
+
+	matches := func(name string) bool {
+		return handler.Matches(&gcputil.CloudEvent{
+			Type:    gcputil.StorageObjectFinalizedEventType,
+			Source:  "//storage.googleapis.com/projects/_/buckets/dummies",
+			Subject: "objects/" + name,
+		})
+	}
+
+	// Prefix and suffix may overlap in the object name
+	assert.True(t, matches("a.json"))
+	assert.True(t, matches("a.json/b.json"))
+	assert.False(t, matches("a.jso"))
+	assert.False(t, matches("b/a.json"))
+	assert.False(t, matches("a.json.txt"))
+}
@@ -0,0 +1,127 @@
+package services_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcpserv "github.com/pip-services3-gox/pip-services3-gcp-gox/services"
+	rpcserv "github.com/pip-services3-gox/pip-services3-rpc-gox/services"
+	"github.com/stretchr/testify/assert"
+)
+
+type pipelineTest struct {
+	stages  []string
+	service *gcpserv.CloudFunctionService
+	action  http.HandlerFunc
+}
+
+func newPipelineTest(panics bool) *pipelineTest {
+	c := &pipelineTest{stages: make([]string, 0)}
+	c.service = gcpserv.NewCloudFunctionService("pipeline")
+
+	c.service.RegisterInterceptor("", func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c.stages = append(c.stages, "interceptor")
+		if r.Header.Get("X-Block") != "" {
+			rpcserv.HttpResponseSender.SendError(w, r, cerr.NewUnauthorizedError("", "BLOCKED", "Blocked by interceptor").WithStatus(403))
+			return
+		}
+		next(w, r)
+	})
+
+	authorize := func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		c.stages = append(c.stages, "auth")
+		if r.Header.Get("Authorization") == "" {
+			rpcserv.HttpResponseSender.SendError(w, r, cerr.NewUnauthorizedError("", "NOT_SIGNED", "Not signed"))
+			return
+		}
+		next(w, r)
+	}
+
+	c.service.RegisterActionWithAuth(
+		"action",
+		cvalid.NewObjectSchema().WithRequiredProperty("body",
+			cvalid.NewObjectSchema().WithRequiredProperty("value", cconv.String)).Schema,
+		authorize,
+		func(w http.ResponseWriter, r *http.Request) {
+			c.stages = append(c.stages, "action")
+			if panics {
+				panic("action failed")
+			}
+			rpcserv.HttpResponseSender.SendResult(w, r, "OK", nil)
+		},
+	)
+
+	c.action = c.service.GetActions()[0].Action
+	return c
+}
+
+func (c *pipelineTest) invoke(body string, headers map[string]string) int {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rr := httptest.NewRecorder()
+	c.action(rr, req)
+	return rr.Code
+}
+
+func TestActionPipelineOrder(t *testing.T) {
+	c := newPipelineTest(false)
+
+	code := c.invoke(`{"cmd":"pipeline.action","value":"abc"}`, map[string]string{"Authorization": "Bearer 123"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"auth", "interceptor", "action"}, c.stages)
+}
+
+func TestActionPipelineAuthorization(t *testing.T) {
+	c := newPipelineTest(false)
+
+	code := c.invoke(`{"cmd":"pipeline.action","value":"abc"}`, nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Equal(t, []string{"auth"}, c.stages)
+}
+
+func TestActionPipelineValidation(t *testing.T) {
+	c := newPipelineTest(false)
+
+	// Validation runs after authorization, but before interceptors
+	code := c.invoke(`{"cmd":"pipeline.action"}`, map[string]string{"Authorization": "Bearer 123"})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []string{"auth"}, c.stages)
+}
+
+func TestActionPipelineBodySize(t *testing.T) {
+	c := newPipelineTest(false)
+	c.service.Configure(context.Background(), cconf.NewConfigParamsFromTuples(
+		"options.max_body_size", 32,
+	))
+
+	code := c.invoke(`{"cmd":"pipeline.action","value":"`+strings.Repeat("x", 100)+`"}`, map[string]string{"Authorization": "Bearer 123"})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+	assert.Equal(t, []string{"auth"}, c.stages)
+}
+
+func TestActionPipelineInterceptors(t *testing.T) {
+	c := newPipelineTest(false)
+
+	code := c.invoke(`{"cmd":"pipeline.action","value":"abc"}`, map[string]string{"Authorization": "Bearer 123", "X-Block": "true"})
+	assert.Equal(t, http.StatusForbidden, code)
+	assert.Equal(t, []string{"auth", "interceptor"}, c.stages)
+}
+
+func TestActionPipelineRecovery(t *testing.T) {
+	c := newPipelineTest(true)
+
+	code := c.invoke(`{"cmd":"pipeline.action","value":"abc"}`, map[string]string{"Authorization": "Bearer 123"})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, []string{"auth", "interceptor", "action"}, c.stages)
+}
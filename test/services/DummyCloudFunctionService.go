@@ -208,4 +208,41 @@ func (c *DummyCloudFunctionService) Register() {
 		cvalid.NewObjectSchema().WithRequiredProperty("body", cvalid.NewObjectSchema().WithRequiredProperty("dummy_id", cconv.String)).Schema,
 		c.deleteById,
 	)
+
+	c.RegisterEventHandler(
+		"com.example.dummy.v1.created",
+		"",
+		tdata.NewDummySchema().Schema,
+		c.onDummyCreated,
+	)
+
+	c.RegisterStorageObjectHandler(
+		gcputil.StorageObjectFinalizedEventType,
+		"dummies",
+		"uploads/",
+		".json",
+		c.onDummyUploaded,
+	)
+}
+
+func (c *DummyCloudFunctionService) onDummyUploaded(ctx context.Context, correlationId string, object *gcputil.StorageObjectData) error {
+	dummy := tdata.Dummy{
+		Key:     object.Name,
+		Content: object.ContentType,
+	}
+
+	_, err := c.controller.Create(ctx, correlationId, dummy)
+	return err
+}
+
+func (c *DummyCloudFunctionService) onDummyCreated(ctx context.Context, event *gcputil.CloudEvent) error {
+	var dummy tdata.Dummy
+
+	err := event.DecodeData(&dummy)
+	if err != nil {
+		return cerr.NewBadRequestError(event.CorrelationId(), "JSON_CNV_ERR", "Cant convert from JSON to Dummy").WithCause(err)
+	}
+
+	_, err = c.controller.Create(ctx, event.CorrelationId(), dummy)
+	return err
 }
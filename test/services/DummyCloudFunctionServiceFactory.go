@@ -11,6 +11,7 @@ type DummyCloudFunctionServiceFactory struct {
 	ControllerDescriptor      *cref.Descriptor
 	CloudServiceDescriptor    *cref.Descriptor
 	CmdCloudServiceDescriptor *cref.Descriptor
+	PubSubServiceDescriptor   *cref.Descriptor
 }
 
 func NewDummyCloudFunctionServiceFactory() *DummyCloudFunctionServiceFactory {
@@ -20,9 +21,11 @@ func NewDummyCloudFunctionServiceFactory() *DummyCloudFunctionServiceFactory {
 		Descriptor:                cref.NewDescriptor("pip-services-dummies", "factory", "default", "default", "1.0"),
 		CloudServiceDescriptor:    cref.NewDescriptor("pip-services-dummies", "service", "cloudfunc", "*", "1.0"),
 		CmdCloudServiceDescriptor: cref.NewDescriptor("pip-services-dummies", "service", "commandable-cloudfunc", "*", "1.0"),
+		PubSubServiceDescriptor:   cref.NewDescriptor("pip-services-dummies", "service", "pubsub-cloudfunc", "*", "1.0"),
 	}
 
 	c.RegisterType(c.CloudServiceDescriptor, NewDummyCloudFunctionService)
 	c.RegisterType(c.CmdCloudServiceDescriptor, NewDummyCommandableCloudFunctionService)
+	c.RegisterType(c.PubSubServiceDescriptor, NewDummyPubSubCloudFunctionService)
 	return &c
 }
@@ -2,6 +2,9 @@ package services_test
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
@@ -48,3 +51,26 @@ func TestCrudOperationsCloudService(t *testing.T) {
 	t.Run("CRUD Operations", c.fixture.TestCrudOperations)
 	c.teardown(t)
 }
+
+func TestCloudServiceBodySizeLimit(t *testing.T) {
+	ctx := context.Background()
+	config := cconf.NewConfigParamsFromTuples(
+		"logger.descriptor", "pip-services:logger:console:default:1.0",
+		"service.descriptor", "pip-services-dummies:service:cloudfunc:default:1.0",
+		"service.options.max_body_size", 64,
+	)
+
+	funcContainer := NewDummyCloudFunction()
+	funcContainer.Configure(ctx, config)
+	err := funcContainer.Open(ctx, "")
+	assert.Nil(t, err)
+	defer funcContainer.Close(ctx, "")
+
+	// Bodies read by the container within its limit are still limited by the service
+	body := `{"cmd": "dummies.create_dummy", "dummy": {"key": "key 1", "content": "` + strings.Repeat("x", 100) + `"}}`
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	funcContainer.GetHandler()(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), "PAYLOAD_TOO_LARGE")
+}
@@ -0,0 +1,141 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcpserv "github.com/pip-services3-gox/pip-services3-gcp-gox/services"
+	tdata "github.com/pip-services3-gox/pip-services3-gcp-gox/test/data"
+	"github.com/stretchr/testify/assert"
+)
+
+type typedDummyRequest struct {
+	DummyId string `json:"dummy_id"`
+}
+
+func newTypedActionService() *gcpserv.CloudFunctionService {
+	service := gcpserv.NewCloudFunctionService("typed")
+
+	gcpserv.RegisterTypedAction(service, "get_dummy_by_id",
+		cvalid.NewObjectSchema().WithRequiredProperty("body",
+			cvalid.NewObjectSchema().WithRequiredProperty("dummy_id", cconv.String)).Schema,
+		func(ctx context.Context, correlationId string, req typedDummyRequest) (*tdata.Dummy, error) {
+			switch req.DummyId {
+			case "missing":
+				return nil, nil
+			case "failed":
+				return nil, cerr.NewNotFoundError(correlationId, "NOT_FOUND", "Dummy was not found")
+			}
+			return tdata.NewDummy(req.DummyId, "key", correlationId), nil
+		},
+	)
+
+	return service
+}
+
+func invokeTypedAction(service *gcpserv.CloudFunctionService, index int, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/?correlation_id=123", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	service.GetActions()[index].Action(rr, req)
+	return rr
+}
+
+func TestTypedAction(t *testing.T) {
+	service := newTypedActionService()
+	assert.Equal(t, "typed.get_dummy_by_id", service.GetActions()[0].Cmd)
+
+	rr := invokeTypedAction(service, 0, `{"cmd":"typed.get_dummy_by_id","dummy_id":"1"}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var dummy tdata.Dummy
+	err := json.Unmarshal(rr.Body.Bytes(), &dummy)
+	assert.Nil(t, err)
+	assert.Equal(t, "1", dummy.Id)
+	assert.Equal(t, "123", dummy.Content)
+
+	// Nil results are sent as empty responses
+	rr = invokeTypedAction(service, 0, `{"cmd":"typed.get_dummy_by_id","dummy_id":"missing"}`)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestTypedActionErrors(t *testing.T) {
+	service := newTypedActionService()
+
+	// Errors returned by actions
+	rr := invokeTypedAction(service, 0, `{"cmd":"typed.get_dummy_by_id","dummy_id":"failed"}`)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Body.String(), "NOT_FOUND")
+
+	// Validation errors
+	rr = invokeTypedAction(service, 0, `{"cmd":"typed.get_dummy_by_id"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Bodies that cannot be decoded into requests
+	gcpserv.RegisterTypedAction(service, "echo", nil,
+		func(ctx context.Context, correlationId string, req typedDummyRequest) (string, error) {
+			return req.DummyId, nil
+		},
+	)
+	rr = invokeTypedAction(service, 1, `{"cmd":"typed.echo","dummy_id":123}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "JSON_CNV_ERR")
+}
+
+func TestTypedActionBodies(t *testing.T) {
+	service := gcpserv.NewCloudFunctionService("typed")
+	gcpserv.RegisterTypedAction(service, "echo", nil,
+		func(ctx context.Context, correlationId string, req typedDummyRequest) ([]string, error) {
+			if req.DummyId == "" {
+				return nil, nil
+			}
+			return []string{req.DummyId}, nil
+		},
+	)
+
+	// Bodies are decoded regardless of the declared length, e.g. chunked or decompressed bodies
+	for _, length := range []int64{-1, 0} {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"cmd":"typed.echo","dummy_id":"1"}`))
+		req.ContentLength = length
+		rr := httptest.NewRecorder()
+		service.GetActions()[0].Action(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `["1"]`, rr.Body.String())
+	}
+
+	// Chunked bodies sent without length
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"chunked"}, r.TransferEncoding)
+		assert.Equal(t, int64(-1), r.ContentLength)
+		service.GetActions()[0].Action(w, r)
+	}))
+	defer server.Close()
+
+	reader, writer := io.Pipe()
+	go func() {
+		_, _ = writer.Write([]byte(`{"cmd":"typed.echo",`))
+		_, _ = writer.Write([]byte(`"dummy_id":"2"}`))
+		_ = writer.Close()
+	}()
+	res, err := http.Post(server.URL, "application/json", reader)
+	assert.Nil(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	result, err := io.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `["2"]`, string(result))
+
+	// Empty bodies are decoded as empty requests, and nil slices are sent as empty responses
+	req := httptest.NewRequest("POST", "/", strings.NewReader(""))
+	rr := httptest.NewRecorder()
+	service.GetActions()[0].Action(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
@@ -48,3 +48,18 @@ func TestCrudOperationsCommandableService(t *testing.T) {
 	t.Run("CRUD Operations", c.fixture.TestCrudOperations)
 	c.teardown(t)
 }
+
+func TestOpenApiCommandableService(t *testing.T) {
+	c := newDummyCommandableCloudFunctionServiceTest()
+	c.setup(t)
+
+	// Command schemas describe actions generated by commandable services
+	doc := c.funcContainer.GetOpenApiDocument().ToMap()
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+
+	action := schemas["dummies.get_dummy_by_id"].(map[string]any)
+	assert.Equal(t, []string{"cmd", "dummy_id"}, action["required"])
+	assert.Equal(t, "pip-services-dummies:service:commandable-cloudfunc:default:1.0", action["x-service"])
+
+	c.teardown(t)
+}
@@ -0,0 +1,59 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+	crefer "github.com/pip-services3-gox/pip-services3-commons-gox/refer"
+	gcpserv "github.com/pip-services3-gox/pip-services3-gcp-gox/services"
+	tdata "github.com/pip-services3-gox/pip-services3-gcp-gox/test/data"
+	tlogic "github.com/pip-services3-gox/pip-services3-gcp-gox/test/logic"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+)
+
+type DummyPubSubCloudFunctionService struct {
+	*gcpserv.PubSubCloudFunctionService
+
+	controller tlogic.IDummyController
+}
+
+func NewDummyPubSubCloudFunctionService() *DummyPubSubCloudFunctionService {
+	c := DummyPubSubCloudFunctionService{}
+
+	c.PubSubCloudFunctionService = gcpserv.InheritPubSubCloudFunctionService(&c, "dummy_messages")
+	c.DependencyResolver.Put(context.Background(), "controller", crefer.NewDescriptor("pip-services-dummies", "controller", "default", "*", "*"))
+
+	return &c
+}
+
+func (c *DummyPubSubCloudFunctionService) SetReferences(ctx context.Context, references crefer.IReferences) {
+	c.PubSubCloudFunctionService.SetReferences(ctx, references)
+
+	depRes, depErr := c.DependencyResolver.GetOneRequired("controller")
+	if depErr == nil && depRes != nil {
+		c.controller = depRes.(tlogic.IDummyController)
+	}
+}
+
+func (c *DummyPubSubCloudFunctionService) createDummy(ctx context.Context, message *gcputil.PubSubMessage) error {
+	var dummy tdata.Dummy
+
+	err := message.DecodeData(&dummy)
+	if err != nil {
+		return cerr.NewBadRequestError(message.CorrelationId(), "JSON_CNV_ERR", "Cant convert from JSON to Dummy").WithCause(err)
+	}
+
+	_, err = c.controller.Create(ctx, message.CorrelationId(), dummy)
+	return err
+}
+
+func (c *DummyPubSubCloudFunctionService) Register() {
+	c.RegisterAttributeHandler("action", "fail", nil,
+		func(ctx context.Context, message *gcputil.PubSubMessage) error {
+			return errors.New("temporary failure")
+		},
+	)
+
+	c.RegisterTopicHandler("^dummies$", tdata.NewDummySchema().Schema, c.createDummy)
+}
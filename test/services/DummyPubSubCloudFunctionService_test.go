@@ -0,0 +1,116 @@
+package services_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cconf "github.com/pip-services3-gox/pip-services3-commons-gox/config"
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	"github.com/stretchr/testify/assert"
+)
+
+type DummyPubSubCloudFunctionServiceTest struct {
+	funcContainer *DummyCloudFunction
+}
+
+func newDummyPubSubCloudFunctionServiceTest() *DummyPubSubCloudFunctionServiceTest {
+	return &DummyPubSubCloudFunctionServiceTest{}
+}
+
+func (c *DummyPubSubCloudFunctionServiceTest) setup(t *testing.T) {
+	config := cconf.NewConfigParamsFromTuples(
+		"logger.descriptor", "pip-services:logger:console:default:1.0",
+		"service.descriptor", "pip-services-dummies:service:cloudfunc:default:1.0",
+		"pubsub.descriptor", "pip-services-dummies:service:pubsub-cloudfunc:default:1.0",
+		"pubsub.options.topic", "dummies",
+	)
+
+	ctx := context.Background()
+
+	c.funcContainer = NewDummyCloudFunction()
+	c.funcContainer.Configure(ctx, config)
+	err := c.funcContainer.Open(ctx, "")
+	assert.Nil(t, err)
+}
+
+func (c *DummyPubSubCloudFunctionServiceTest) teardown(t *testing.T) {
+	err := c.funcContainer.Close(context.Background(), "")
+	assert.Nil(t, err)
+}
+
+func (c *DummyPubSubCloudFunctionServiceTest) pushMessage(t *testing.T, data string, attributes map[string]string) int {
+	envelope := map[string]any{
+		"message": map[string]any{
+			"data":        base64.StdEncoding.EncodeToString([]byte(data)),
+			"attributes":  attributes,
+			"messageId":   "1",
+			"publishTime": "2023-01-01T00:00:00Z",
+		},
+		"subscription": "projects/test/subscriptions/dummies-push",
+	}
+	body, err := cconv.JsonConverter.ToJson(envelope)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest("POST", "/?cmd=dummy_messages.push", strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	c.funcContainer.GetHandler()(rr, req)
+
+	return rr.Code
+}
+
+func (c *DummyPubSubCloudFunctionServiceTest) testPushMessages(t *testing.T) {
+	handler := c.funcContainer.GetHandler()
+
+	// Processed message is acknowledged
+	code := c.pushMessage(t, `{"key": "key 1", "content": "content 1"}`, nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+
+	// Invalid message is dropped and acknowledged
+	code = c.pushMessage(t, `{"content": "content 2"}`, nil)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+
+	// Failed message is not acknowledged to be redelivered
+	code = c.pushMessage(t, `{"key": "key 3"}`, map[string]string{"action": "fail"})
+	assert.Equal(t, http.StatusInternalServerError, code)
+	assert.Equal(t, 1, getDummiesCount(t, handler))
+}
+
+func (c *DummyPubSubCloudFunctionServiceTest) testPublishedEvents(t *testing.T) {
+	handler := c.funcContainer.GetHandler()
+
+	body := `{
+		"message": {
+			"data": "` + base64.StdEncoding.EncodeToString([]byte(`{"key": "key 4"}`)) + `",
+			"message_id": "4"
+		},
+		"subscription": "projects/test/subscriptions/eventarc-dummies"
+	}`
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("ce-specversion", "1.0")
+	req.Header.Add("ce-id", "4")
+	req.Header.Add("ce-source", "//pubsub.googleapis.com/projects/test/topics/dummies")
+	req.Header.Add("ce-type", "google.cloud.pubsub.topic.v1.messagePublished")
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, 2, getDummiesCount(t, handler))
+}
+
+func TestPubSubCloudFunctionService(t *testing.T) {
+	c := newDummyPubSubCloudFunctionServiceTest()
+
+	c.setup(t)
+	t.Run("Push Messages", c.testPushMessages)
+	t.Run("Published Events", c.testPublishedEvents)
+	c.teardown(t)
+}
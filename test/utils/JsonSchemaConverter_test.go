@@ -0,0 +1,41 @@
+package utils_test
+
+import (
+	"testing"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonSchemaConverterObject(t *testing.T) {
+	schema := cvalid.NewObjectSchema().
+		WithRequiredProperty("id", cconv.String).
+		WithOptionalProperty("count", cconv.Integer).
+		WithOptionalProperty("tags", cvalid.NewArraySchema(cconv.String)).
+		WithOptionalProperty("filter", cvalid.NewFilterParamsSchema())
+
+	result := gcputil.JsonSchemaConverter.ToJsonSchema(schema)
+	assert.Equal(t, "object", result["type"])
+	assert.Equal(t, []string{"id"}, result["required"])
+
+	properties := result["properties"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "string"}, properties["id"])
+	assert.Equal(t, map[string]any{"type": "integer", "format": "int32"}, properties["count"])
+	assert.Equal(t, map[string]any{"type": "array", "items": map[string]any{"type": "string"}}, properties["tags"])
+	assert.Equal(t, "object", properties["filter"].(map[string]any)["type"])
+
+	// Base schemas do not expose concrete schemas, so they accept any values
+	assert.Equal(t, map[string]any{}, gcputil.JsonSchemaConverter.ToJsonSchema(schema.Schema))
+}
+
+func TestJsonSchemaConverterEmpty(t *testing.T) {
+	assert.Nil(t, gcputil.JsonSchemaConverter.ToJsonSchema(nil))
+
+	var schema *cvalid.Schema
+	assert.Nil(t, gcputil.JsonSchemaConverter.ToJsonSchema(schema))
+
+	assert.Equal(t, map[string]any{"type": "string", "format": "date-time"},
+		gcputil.JsonSchemaConverter.ToJsonSchema(cconv.DateTime))
+}
@@ -0,0 +1,85 @@
+package utils_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	gcputil "github.com/pip-services3-gox/pip-services3-gcp-gox/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	trace, ok := gcputil.ParseTraceParent("00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	assert.True(t, ok)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", trace.TraceId)
+	assert.Equal(t, "b7ad6b7169203331", trace.SpanId)
+	assert.True(t, trace.Sampled)
+	assert.Equal(t, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01", trace.TraceParent())
+
+	_, ok = gcputil.ParseTraceParent("00-00000000000000000000000000000000-b7ad6b7169203331-01")
+	assert.False(t, ok)
+	_, ok = gcputil.ParseTraceParent("invalid")
+	assert.False(t, ok)
+}
+
+func TestParseCloudTraceContext(t *testing.T) {
+	trace, ok := gcputil.ParseCloudTraceContext("105445aa7843bc8bf206b12000100000/1;o=1")
+	assert.True(t, ok)
+	assert.Equal(t, "105445aa7843bc8bf206b12000100000", trace.TraceId)
+	assert.Equal(t, "0000000000000001", trace.SpanId)
+	assert.True(t, trace.Sampled)
+	assert.Equal(t, "105445aa7843bc8bf206b12000100000/1;o=1", trace.CloudTraceContext())
+
+	trace, ok = gcputil.ParseCloudTraceContext("105445aa7843bc8bf206b12000100000")
+	assert.True(t, ok)
+	assert.Equal(t, "", trace.SpanId)
+	assert.False(t, trace.Sampled)
+}
+
+func TestGetTraceContext(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	_, ok := gcputil.CloudFunctionRequestHelper.GetTraceContext(req)
+	assert.False(t, ok)
+
+	req.Header.Set("X-Cloud-Trace-Context", "105445aa7843bc8bf206b12000100000/1;o=1")
+	trace, ok := gcputil.CloudFunctionRequestHelper.GetTraceContext(req)
+	assert.True(t, ok)
+	assert.Equal(t, "105445aa7843bc8bf206b12000100000", trace.TraceId)
+
+	// traceparent takes precedence
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	trace, ok = gcputil.CloudFunctionRequestHelper.GetTraceContext(req)
+	assert.True(t, ok)
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", trace.TraceId)
+
+	ctx := gcputil.ContextWithTraceContext(context.Background(), trace)
+	fromCtx, ok := gcputil.TraceContextFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, trace, fromCtx)
+}
+
+func TestNewTraceContext(t *testing.T) {
+	trace := gcputil.NewTraceContext(true)
+	_, ok := gcputil.ParseTraceParent(trace.TraceParent())
+	assert.True(t, ok)
+
+	child := trace.NewChildSpan(gcputil.SpanKindClient)
+	assert.Equal(t, trace.TraceId, child.TraceId)
+	assert.NotEqual(t, trace.SpanId, child.SpanId)
+	assert.Equal(t, trace.SpanId, child.ParentSpanId)
+	assert.Equal(t, gcputil.SpanKindClient, child.Kind)
+	assert.Equal(t, trace.Sampled, child.Sampled)
+}
+
+func TestGetCorrelationIdFromTrace(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	assert.Equal(t, "", gcputil.CloudFunctionRequestHelper.GetCorrelationId(req))
+
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", gcputil.CloudFunctionRequestHelper.GetCorrelationId(req))
+
+	// Explicit correlation id takes precedence
+	req.Header.Set("correlation_id", "123")
+	assert.Equal(t, "123", gcputil.CloudFunctionRequestHelper.GetCorrelationId(req))
+}
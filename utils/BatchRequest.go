@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"encoding/json"
+
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
+)
+
+// Command to execute a batch of actions in a single function invocation
+const BatchCmd = "_batch"
+
+// BatchItem is an action call in a batch.
+type BatchItem struct {
+	// The command of the action
+	Cmd string `json:"cmd"`
+	// The action arguments sent in the request body
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// BatchRequest is a body of the request that executes a batch of actions.
+// The request is sent with "_batch" command, e.g.
+//	{"cmd": "_batch", "parallel": true, "batch": [{"cmd": "get_dummy_by_id", "args": {"dummy_id": "1"}}]}
+type BatchRequest struct {
+	// The action calls
+	Batch []*BatchItem `json:"batch"`
+	// True to execute the calls in parallel, or false to execute them in order
+	Parallel bool `json:"parallel,omitempty"`
+}
+
+// BatchItemResult is a result of an action call in a batch.
+// Results are returned in the same order as the calls.
+type BatchItemResult struct {
+	// The command of the action
+	Cmd string `json:"cmd"`
+	// The HTTP status code returned by the action
+	Status int `json:"status"`
+	// The JSON result of the action, or empty when the action failed or returned no result
+	Result json.RawMessage `json:"result,omitempty"`
+	// The error returned by the action, or nil when the action succeeded
+	Error *cerr.ErrorDescription `json:"error,omitempty"`
+}
+
+// Gets the error returned by the action.
+// Returns ApplicationError or nil when the action succeeded.
+func (c *BatchItemResult) GetError() error {
+	if c.Error == nil {
+		return nil
+	}
+	return cerr.ApplicationErrorFactory.Create(c.Error)
+}
+
+// Decodes the action result from JSON into the target value.
+// Parameters:
+//		- target	the target value
+// Returns the action error, or error when the result cannot be decoded
+func (c *BatchItemResult) DecodeResult(target any) error {
+	if err := c.GetError(); err != nil {
+		return err
+	}
+	if len(c.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(c.Result, target)
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// CloudEvent is a CloudEvents v1.0 event delivered to a Google Function
+// by Eventarc, Pub/Sub, Cloud Storage and other event sources.
+//
+// Both structured (application/cloudevents+json) and binary (ce-* headers)
+// content modes are decoded into this struct.
+//
+// see CloudFunctionRequestHelper.GetCloudEvent
+type CloudEvent struct {
+	// The version of the CloudEvents specification
+	SpecVersion string `json:"specversion"`
+	// The unique event identifier
+	Id string `json:"id"`
+	// The event source, e.g. "//storage.googleapis.com/projects/_/buckets/mybucket"
+	Source string `json:"source"`
+	// The event type, e.g. "google.cloud.storage.object.v1.finalized"
+	Type string `json:"type"`
+	// The subject of the event in the context of the source
+	Subject string `json:"subject,omitempty"`
+	// The time when the event occurred in RFC3339 format
+	Time string `json:"time,omitempty"`
+	// The content type of the event data
+	DataContentType string `json:"datacontenttype,omitempty"`
+	// The schema the event data adheres to
+	DataSchema string `json:"dataschema,omitempty"`
+	// The raw event data
+	Data []byte `json:"-"`
+	// The extension attributes of the event
+	Extensions map[string]string `json:"-"`
+}
+
+// Name of the CloudEvents extension attribute that carries correlation id
+const CloudEventCorrelationIdExtension = "correlationid"
+
+// Gets correlation id of the event.
+// It is taken from the "correlationid" extension attribute and falls back to the event id.
+// Returns correlation id string
+func (c *CloudEvent) CorrelationId() string {
+	if c.Extensions != nil {
+		if correlationId, ok := c.Extensions[CloudEventCorrelationIdExtension]; ok && correlationId != "" {
+			return correlationId
+		}
+	}
+	return c.Id
+}
+
+// Decodes the event data from JSON into the target value.
+// Parameters:
+//		- target	the target instance to which the data will be written
+// Returns error
+func (c *CloudEvent) DecodeData(target any) error {
+	if len(c.Data) == 0 {
+		return errors.New("event data is empty")
+	}
+	return json.Unmarshal(c.Data, target)
+}
+
+// Validates required context attributes of the event.
+// Returns error if the event misses specversion, id, source or type.
+func (c *CloudEvent) Validate() error {
+	if c.SpecVersion == "" {
+		return errors.New("missing specversion attribute")
+	}
+	if c.Id == "" {
+		return errors.New("missing id attribute")
+	}
+	if c.Source == "" {
+		return errors.New("missing source attribute")
+	}
+	if c.Type == "" {
+		return errors.New("missing type attribute")
+	}
+	return nil
+}
@@ -2,20 +2,34 @@ package utils
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
+	cerr "github.com/pip-services3-gox/pip-services3-commons-gox/errors"
 	crun "github.com/pip-services3-gox/pip-services3-commons-gox/run"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
 )
 
+// Default maximum size of request bodies in bytes, the limit of HTTP functions in Google Cloud
+const DefaultMaxBodySize int64 = 32 * 1024 * 1024
+
 // Helper struct that allow prepare of requests data
 var CloudFunctionRequestHelper = _TCloudFunctionRequestHelper{}
 
 type _TCloudFunctionRequestHelper struct {
 }
 
-// Returns correlationId from request struct
+// Returns correlationId from request struct.
+// It is taken from "correlation_id" query parameter or header,
+// and falls back to the trace id from "traceparent" or "X-Cloud-Trace-Context" header.
 // Parameters:
 //		- req	request struct
 // Returns correlation id string or empty
@@ -24,6 +38,11 @@ func (c *_TCloudFunctionRequestHelper) GetCorrelationId(req *http.Request) strin
 	if correlationId == "" {
 		correlationId = req.Header.Get("correlation_id")
 	}
+	if correlationId == "" {
+		if trace, ok := c.GetTraceContext(req); ok {
+			correlationId = trace.TraceId
+		}
+	}
 	return correlationId
 }
 
@@ -57,22 +76,101 @@ func (c *_TCloudFunctionRequestHelper) GetCommand(req *http.Request) (string, er
 //		- target	the target instance to which the result will be written
 // Returns error
 func (c *_TCloudFunctionRequestHelper) DecodeBody(req *http.Request, target any) error {
-	bodyBytes, err := ioutil.ReadAll(req.Body)
+	bodyBytes, err := c.ReadBody(req, DefaultMaxBodySize)
 
 	if err != nil {
 		return err
 	}
 
-	err = json.Unmarshal(bodyBytes, target)
+	return json.Unmarshal(bodyBytes, target)
+}
+
+// Reads body of request. Bodies compressed with gzip, as set by "Content-Encoding" header,
+// are decompressed. The body is kept in the request, so it can be read again,
+// and the request is changed to carry the decompressed body. Bodies read before are checked
+// against the size again, as they could be read with a larger limit.
+// Parameters:
+//		- req		request struct
+//		- maxSize	maximum size of the body in bytes before and after decompression, 0 to read any body
+// Returns body bytes, or PAYLOAD_TOO_LARGE error with 413 status code when the body exceeds the size,
+// or UNSUPPORTED_ENCODING error with 415 status code when the body is compressed by unsupported algorithm.
+func (c *_TCloudFunctionRequestHelper) ReadBody(req *http.Request, maxSize int64) ([]byte, error) {
+	// The body was read before
+	if body, ok := req.Body.(*bufferedBody); ok {
+		req.Body = newBufferedBody(body.data)
+		// The body could be read with a larger limit
+		if maxSize > 0 && int64(len(body.data)) > maxSize {
+			return nil, c.newBodyError(req, errBodyTooLarge, maxSize)
+		}
+		return body.data, nil
+	}
+
+	if req.Body == nil || req.Body == http.NoBody {
+		req.Body = newBufferedBody([]byte{})
+		return []byte{}, nil
+	}
 
+	defer req.Body.Close()
+
+	var reader io.Reader = req.Body
+	if maxSize > 0 {
+		reader = &maxBytesReader{reader: reader, remaining: maxSize}
+	}
+
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	switch encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, c.newBodyError(req, err, maxSize)
+		}
+		defer gzipReader.Close()
+
+		reader = gzipReader
+		if maxSize > 0 {
+			// Limit decompressed body to protect from compression bombs
+			reader = &maxBytesReader{reader: reader, remaining: maxSize}
+		}
+	default:
+		return nil, cerr.NewBadRequestError(
+			c.GetCorrelationId(req),
+			"UNSUPPORTED_ENCODING",
+			"Content encoding "+encoding+" is not supported",
+		).
+			WithStatus(http.StatusUnsupportedMediaType).
+			WithDetails("encoding", encoding)
+	}
+
+	bodyBytes, err := ioutil.ReadAll(reader)
 	if err != nil {
-		return err
+		return nil, c.newBodyError(req, err, maxSize)
 	}
 
-	_ = req.Body.Close()
-	req.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+	req.Body = newBufferedBody(bodyBytes)
+	req.ContentLength = int64(len(bodyBytes))
+	req.Header.Del("Content-Encoding")
+	req.Header.Set("Content-Length", strconv.Itoa(len(bodyBytes)))
 
-	return nil
+	return bodyBytes, nil
+}
+
+func (c *_TCloudFunctionRequestHelper) newBodyError(req *http.Request, err error, maxSize int64) error {
+	if errors.Is(err, errBodyTooLarge) {
+		return cerr.NewBadRequestError(
+			c.GetCorrelationId(req),
+			"PAYLOAD_TOO_LARGE",
+			"Request body exceeds "+strconv.FormatInt(maxSize, 10)+" bytes",
+		).
+			WithStatus(http.StatusRequestEntityTooLarge).
+			WithDetails("max_size", maxSize)
+	}
+
+	return cerr.NewBadRequestError(
+		c.GetCorrelationId(req),
+		"INVALID_BODY",
+		"Failed to read request body",
+	).WithCause(err)
 }
 
 // Get body of request as Parameters struct
@@ -86,3 +184,377 @@ func (c *_TCloudFunctionRequestHelper) GetParameters(req *http.Request) *crun.Pa
 
 	return crun.NewParametersFromValue(params)
 }
+
+// Checks if request carries a CloudEvent in structured or binary content mode
+// Parameters:
+//		- req	request struct
+// Returns true if the request is a CloudEvent and false otherwise
+func (c *_TCloudFunctionRequestHelper) IsCloudEvent(req *http.Request) bool {
+	if req.Header.Get("ce-specversion") != "" {
+		return true
+	}
+	return strings.HasPrefix(req.Header.Get("Content-Type"), "application/cloudevents+json")
+}
+
+// Returns CloudEvent from request struct.
+// Both structured and binary content modes are supported.
+// Parameters:
+//		- req	request struct
+// Returns CloudEvent or error
+func (c *_TCloudFunctionRequestHelper) GetCloudEvent(req *http.Request) (*CloudEvent, error) {
+	bodyBytes, err := c.ReadBody(req, DefaultMaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	var event *CloudEvent
+	if req.Header.Get("ce-specversion") != "" {
+		event = c.decodeBinaryCloudEvent(req, bodyBytes)
+	} else {
+		event, err = c.decodeStructuredCloudEvent(bodyBytes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = event.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+func (c *_TCloudFunctionRequestHelper) decodeBinaryCloudEvent(req *http.Request, body []byte) *CloudEvent {
+	event := &CloudEvent{
+		DataContentType: req.Header.Get("Content-Type"),
+		Data:            body,
+		Extensions:      make(map[string]string),
+	}
+
+	for key, values := range req.Header {
+		name := strings.ToLower(key)
+		if !strings.HasPrefix(name, "ce-") || len(values) == 0 {
+			continue
+		}
+		name = name[3:]
+		value := values[0]
+
+		switch name {
+		case "specversion":
+			event.SpecVersion = value
+		case "id":
+			event.Id = value
+		case "source":
+			event.Source = value
+		case "type":
+			event.Type = value
+		case "subject":
+			event.Subject = value
+		case "time":
+			event.Time = value
+		case "dataschema":
+			event.DataSchema = value
+		default:
+			event.Extensions[name] = value
+		}
+	}
+
+	return event
+}
+
+func (c *_TCloudFunctionRequestHelper) decodeStructuredCloudEvent(body []byte) (*CloudEvent, error) {
+	var attributes map[string]json.RawMessage
+	err := json.Unmarshal(body, &attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &CloudEvent{
+		Extensions: make(map[string]string),
+	}
+
+	for name, raw := range attributes {
+		switch name {
+		case "data", "data_base64":
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			// Non-string extension values are kept in their JSON form
+			value = string(raw)
+		}
+
+		switch name {
+		case "specversion":
+			event.SpecVersion = value
+		case "id":
+			event.Id = value
+		case "source":
+			event.Source = value
+		case "type":
+			event.Type = value
+		case "subject":
+			event.Subject = value
+		case "time":
+			event.Time = value
+		case "datacontenttype":
+			event.DataContentType = value
+		case "dataschema":
+			event.DataSchema = value
+		default:
+			event.Extensions[name] = value
+		}
+	}
+
+	if raw, ok := attributes["data_base64"]; ok {
+		var encoded string
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return nil, errors.New("data_base64 attribute must be a string")
+		}
+		event.Data, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+	} else if raw, ok := attributes["data"]; ok {
+		event.Data = raw
+		// Non-JSON data is carried as a JSON string
+		if event.DataContentType != "" && !strings.Contains(event.DataContentType, "json") {
+			var text string
+			if err := json.Unmarshal(raw, &text); err == nil {
+				event.Data = []byte(text)
+			}
+		}
+	}
+
+	return event, nil
+}
+
+// Validates CloudEvent data against the schema
+// Parameters:
+//		- event	a CloudEvent to validate
+//		- schema	(optional) a validation schema
+// Returns error if the data is not a valid JSON or does not match the schema
+func (c *_TCloudFunctionRequestHelper) ValidateCloudEventData(event *CloudEvent, schema *cvalid.Schema) error {
+	if schema == nil {
+		return nil
+	}
+
+	var data any
+	if len(event.Data) > 0 {
+		err := event.DecodeData(&data)
+		if err != nil {
+			return cerr.NewBadRequestError(
+				event.CorrelationId(),
+				"INVALID_EVENT_DATA",
+				"Event data is not a valid JSON",
+			).
+				WithDetails("type", event.Type).
+				WithCause(err)
+		}
+	}
+
+	if err := schema.ValidateAndReturnError(event.CorrelationId(), data, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Returns Pub/Sub message from the body of a push subscription request.
+// Parameters:
+//		- req	request struct
+// Returns PubSubMessage or error
+func (c *_TCloudFunctionRequestHelper) GetPubSubMessage(req *http.Request) (*PubSubMessage, error) {
+	bodyBytes, err := c.ReadBody(req, DefaultMaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.decodePubSubEnvelope(bodyBytes)
+}
+
+// Returns Pub/Sub message carried by "google.cloud.pubsub.topic.v1.messagePublished" CloudEvent.
+// The topic is taken from the event source.
+// Parameters:
+//		- event	a CloudEvent
+// Returns PubSubMessage or error
+func (c *_TCloudFunctionRequestHelper) GetPubSubMessageFromEvent(event *CloudEvent) (*PubSubMessage, error) {
+	message, err := c.decodePubSubEnvelope(event.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Source looks like "//pubsub.googleapis.com/projects/myproject/topics/mytopic"
+	if pos := strings.LastIndex(event.Source, "/topics/"); pos >= 0 {
+		message.Topic = event.Source[pos+len("/topics/"):]
+	}
+
+	return message, nil
+}
+
+func (c *_TCloudFunctionRequestHelper) decodePubSubEnvelope(body []byte) (*PubSubMessage, error) {
+	var envelope pubSubPushEnvelope
+	err := json.Unmarshal(body, &envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.Message == nil {
+		return nil, errors.New("missing message in Pub/Sub envelope")
+	}
+
+	message := envelope.Message.PubSubMessage
+	if message.MessageId == "" {
+		message.MessageId = envelope.Message.MessageIdAlt
+	}
+	if message.PublishTime == "" {
+		message.PublishTime = envelope.Message.PublishTimeAlt
+	}
+	if message.MessageId == "" {
+		return nil, errors.New("missing messageId in Pub/Sub message")
+	}
+
+	message.Subscription = envelope.Subscription
+	message.DeliveryAttempt = envelope.DeliveryAttempt
+
+	return &message, nil
+}
+
+// Validates Pub/Sub message data against the schema
+// Parameters:
+//		- message	a Pub/Sub message to validate
+//		- schema	(optional) a validation schema
+// Returns error if the data is not a valid JSON or does not match the schema
+func (c *_TCloudFunctionRequestHelper) ValidatePubSubMessageData(message *PubSubMessage, schema *cvalid.Schema) error {
+	if schema == nil {
+		return nil
+	}
+
+	var data any
+	if len(message.Data) > 0 {
+		err := message.DecodeData(&data)
+		if err != nil {
+			return cerr.NewBadRequestError(
+				message.CorrelationId(),
+				"INVALID_MESSAGE_DATA",
+				"Message data is not a valid JSON",
+			).
+				WithDetails("message_id", message.MessageId).
+				WithCause(err)
+		}
+	}
+
+	if err := schema.ValidateAndReturnError(message.CorrelationId(), data, false); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Returns object metadata carried by Cloud Storage CloudEvent.
+// Parameters:
+//		- event	a CloudEvent
+// Returns StorageObjectData or error
+func (c *_TCloudFunctionRequestHelper) GetStorageObjectData(event *CloudEvent) (*StorageObjectData, error) {
+	var object StorageObjectData
+	err := event.DecodeData(&object)
+	if err != nil {
+		return nil, cerr.NewBadRequestError(
+			event.CorrelationId(),
+			"INVALID_EVENT_DATA",
+			"Event data is not a valid storage object",
+		).
+			WithDetails("type", event.Type).
+			WithCause(err)
+	}
+
+	return &object, nil
+}
+
+// Returns trace context from W3C "traceparent" header
+// or Google "X-Cloud-Trace-Context" header of the request.
+// Parameters:
+//		- req	request struct
+// Returns the trace context and true, or nil and false if the request has no valid trace headers.
+func (c *_TCloudFunctionRequestHelper) GetTraceContext(req *http.Request) (*TraceContext, bool) {
+	if trace, ok := ParseTraceParent(req.Header.Get("traceparent")); ok {
+		return trace, true
+	}
+	return ParseCloudTraceContext(req.Header.Get("X-Cloud-Trace-Context"))
+}
+
+// Adds correlation id and trace context headers to outgoing request.
+// The trace context from the context is sent as is, so callers shall start
+// a client span before, see StartSpan.
+// Parameters:
+//		- ctx			a context of the current operation
+//		- req			an outgoing request
+//		- correlationId	(optional) transaction id to trace execution through call chain.
+func (c *_TCloudFunctionRequestHelper) SetTraceHeaders(ctx context.Context, req *http.Request, correlationId string) {
+	if correlationId != "" {
+		req.Header.Set("correlation_id", correlationId)
+	}
+
+	if trace, ok := TraceContextFromContext(ctx); ok {
+		req.Header.Set("traceparent", trace.TraceParent())
+		req.Header.Set("X-Cloud-Trace-Context", trace.CloudTraceContext())
+	}
+}
+
+// Starts a new span as a child of the trace context in the context,
+// or a new sampled trace when the context has no trace context.
+// Parameters:
+//		- ctx	a context of the current operation
+//		- kind	the kind of the span.
+// Returns a new context with the span.
+func (c *_TCloudFunctionRequestHelper) StartSpan(ctx context.Context, kind string) context.Context {
+	var span *TraceContext
+	if trace, ok := TraceContextFromContext(ctx); ok {
+		span = trace.NewChildSpan(kind)
+	} else {
+		span = NewTraceContext(true)
+		span.Kind = kind
+	}
+	return ContextWithTraceContext(ctx, span)
+}
+
+var errBodyTooLarge = errors.New("request body too large")
+
+// Reader that fails when the source has more bytes than allowed
+type maxBytesReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (c *maxBytesReader) Read(p []byte) (int, error) {
+	if c.remaining <= 0 {
+		// Check if anything is left beyond the limit
+		var probe [1]byte
+		n, err := c.reader.Read(probe[:])
+		if n > 0 {
+			return 0, errBodyTooLarge
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > c.remaining {
+		p = p[:c.remaining]
+	}
+	n, err := c.reader.Read(p)
+	c.remaining -= int64(n)
+	return n, err
+}
+
+// Request body that was read and kept in memory to be read again
+type bufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newBufferedBody(data []byte) *bufferedBody {
+	return &bufferedBody{Reader: bytes.NewReader(data), data: data}
+}
+
+func (c *bufferedBody) Close() error {
+	return nil
+}
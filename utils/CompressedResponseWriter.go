@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Recommended minimum size of responses in bytes to compress them.
+// Compression is opt-in, so the threshold is used only when it is set explicitly.
+const DefaultCompressionThreshold = 1024
+
+// CompressedResponseWriter is a response writer that compresses responses with gzip
+// when they are larger than the threshold.
+//
+// Google Functions send responses when handlers return, so the response is buffered
+// to decide on compression by its size and sent when the writer is closed.
+// The writer shall be closed only when the action returns normally, so panics
+// do not send partial responses. Responses that are already encoded are sent as is.
+//
+//	Example:
+//		if utils.AcceptsGzip(req) {
+//			writer := utils.NewCompressedResponseWriter(res, utils.DefaultCompressionThreshold)
+//			action(writer, req)
+//			writer.Close()
+//		}
+type CompressedResponseWriter struct {
+	writer    http.ResponseWriter
+	threshold int
+	status    int
+	buffer    bytes.Buffer
+	closed    bool
+}
+
+// Creates a new instance of the writer.
+// Parameters:
+//		- writer	a response writer to send the response.
+//		- threshold	a minimum size of responses in bytes to compress them.
+func NewCompressedResponseWriter(writer http.ResponseWriter, threshold int) *CompressedResponseWriter {
+	return &CompressedResponseWriter{
+		writer:    writer,
+		threshold: threshold,
+	}
+}
+
+// Gets headers of the response.
+func (c *CompressedResponseWriter) Header() http.Header {
+	return c.writer.Header()
+}
+
+// Sets status code of the response. The status code is sent when the writer is closed.
+// Parameters:
+//		- status	a status code.
+func (c *CompressedResponseWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+}
+
+// Writes data to the response buffer.
+// Parameters:
+//		- data	data to write.
+// Returns the number of written bytes and error.
+func (c *CompressedResponseWriter) Write(data []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	return c.buffer.Write(data)
+}
+
+// Sends the buffered response, compressed when it is larger than the threshold.
+// Returns error when the response failed to be sent.
+func (c *CompressedResponseWriter) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+
+	header := c.writer.Header()
+	header.Add("Vary", "Accept-Encoding")
+
+	if c.buffer.Len() < c.threshold || header.Get("Content-Encoding") != "" ||
+		c.status == http.StatusNoContent || c.status == http.StatusNotModified {
+		if c.buffer.Len() > 0 {
+			header.Set("Content-Length", strconv.Itoa(c.buffer.Len()))
+		}
+		c.writer.WriteHeader(c.status)
+		_, err := c.writer.Write(c.buffer.Bytes())
+		return err
+	}
+
+	header.Set("Content-Encoding", "gzip")
+	header.Del("Content-Length")
+	c.writer.WriteHeader(c.status)
+
+	gzipWriter := gzip.NewWriter(c.writer)
+	if _, err := gzipWriter.Write(c.buffer.Bytes()); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// Checks if the request accepts responses compressed with gzip by "Accept-Encoding" header.
+// Parameters:
+//		- req	request struct
+// Returns true if gzip encoding is accepted and false otherwise.
+func AcceptsGzip(req *http.Request) bool {
+	for _, value := range req.Header.Values("Accept-Encoding") {
+		for _, item := range strings.Split(value, ",") {
+			parts := strings.Split(item, ";")
+			encoding := strings.ToLower(strings.TrimSpace(parts[0]))
+			if encoding != "gzip" && encoding != "*" {
+				continue
+			}
+
+			// Encodings with zero quality are not accepted
+			accepted := true
+			for _, param := range parts[1:] {
+				param = strings.ReplaceAll(param, " ", "")
+				if param == "q=0" || strings.HasPrefix(param, "q=0.") && strings.Trim(param[4:], "0") == "" {
+					accepted = false
+				}
+			}
+			return accepted
+		}
+	}
+	return false
+}
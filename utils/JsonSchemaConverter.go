@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"reflect"
+
+	cconv "github.com/pip-services3-gox/pip-services3-commons-gox/convert"
+	cvalid "github.com/pip-services3-gox/pip-services3-commons-gox/validate"
+)
+
+// Converts validation schemas into JSON Schema objects
+// to describe actions for tooling and client discovery.
+//
+// Concrete schemas, like ObjectSchema, ArraySchema and MapSchema, are converted with their properties.
+// Base schemas, e.g. ObjectSchema.Schema, do not expose the concrete schemas, so they are converted
+// into empty JSON Schema objects that accept any values.
+//
+//	Example:
+//		schema := validate.NewObjectSchema().
+//			WithRequiredProperty("id", convert.String).
+//			WithOptionalProperty("tags", validate.NewArraySchema(convert.String))
+//
+//		result := utils.JsonSchemaConverter.ToJsonSchema(schema)
+//		// {"type": "object", "properties": {"id": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}, "required": ["id"]}
+var JsonSchemaConverter = _TJsonSchemaConverter{}
+
+type _TJsonSchemaConverter struct {
+}
+
+type schemaWithProperties interface {
+	Properties() []*cvalid.PropertySchema
+}
+
+type schemaWithKeyAndValue interface {
+	KeyType() any
+	ValueType() any
+}
+
+type schemaWithValue interface {
+	ValueType() any
+}
+
+// Converts a validation schema or a type code into JSON Schema object.
+// Parameters:
+//		- schema	a validation schema, a type code or nil
+// Returns JSON Schema object or nil when the schema is nil
+func (c *_TJsonSchemaConverter) ToJsonSchema(schema any) map[string]any {
+	if schema == nil {
+		return nil
+	}
+	if value := reflect.ValueOf(schema); value.Kind() == reflect.Pointer && value.IsNil() {
+		return nil
+	}
+	return c.convert(schema)
+}
+
+func (c *_TJsonSchemaConverter) convert(schema any) map[string]any {
+	switch value := schema.(type) {
+	case cconv.TypeCode:
+		return c.convertTypeCode(value)
+	case schemaWithProperties:
+		result := map[string]any{"type": "object"}
+		properties := make(map[string]any)
+		required := make([]string, 0)
+		for _, property := range value.Properties() {
+			propertyData := map[string]any{}
+			if property.Type() != nil {
+				propertyData = c.convert(property.Type())
+			}
+			properties[property.Name()] = propertyData
+			if property.Required() {
+				required = append(required, property.Name())
+			}
+		}
+		if len(properties) > 0 {
+			result["properties"] = properties
+		}
+		if len(required) > 0 {
+			result["required"] = required
+		}
+		return result
+	case schemaWithKeyAndValue:
+		result := map[string]any{"type": "object"}
+		if value.ValueType() != nil {
+			result["additionalProperties"] = c.convert(value.ValueType())
+		}
+		return result
+	case schemaWithValue:
+		result := map[string]any{"type": "array"}
+		if value.ValueType() != nil {
+			result["items"] = c.convert(value.ValueType())
+		}
+		return result
+	}
+
+	return map[string]any{}
+}
+
+func (c *_TJsonSchemaConverter) convertTypeCode(typ cconv.TypeCode) map[string]any {
+	switch typ {
+	case cconv.String, cconv.Enum:
+		return map[string]any{"type": "string"}
+	case cconv.Boolean:
+		return map[string]any{"type": "boolean"}
+	case cconv.Integer:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case cconv.Long, cconv.Duration:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case cconv.Float:
+		return map[string]any{"type": "number", "format": "float"}
+	case cconv.Double:
+		return map[string]any{"type": "number", "format": "double"}
+	case cconv.DateTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case cconv.Object, cconv.Map:
+		return map[string]any{"type": "object"}
+	case cconv.Array:
+		return map[string]any{"type": "array"}
+	}
+	return map[string]any{}
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// PubSubMessage is a Pub/Sub message delivered to a Google Function
+// by a push subscription or by Eventarc as a
+// "google.cloud.pubsub.topic.v1.messagePublished" CloudEvent.
+//
+// see CloudFunctionRequestHelper.GetPubSubMessage
+// see CloudFunctionRequestHelper.GetPubSubMessageFromEvent
+type PubSubMessage struct {
+	// The unique message identifier assigned by Pub/Sub
+	MessageId string `json:"messageId"`
+	// The time when the message was published in RFC3339 format
+	PublishTime string `json:"publishTime,omitempty"`
+	// The message payload (decoded from base64)
+	Data []byte `json:"data,omitempty"`
+	// The message attributes
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// The ordering key of the message
+	OrderingKey string `json:"orderingKey,omitempty"`
+	// The full name of the subscription that delivered the message,
+	// e.g. "projects/myproject/subscriptions/mysubscription"
+	Subscription string `json:"-"`
+	// The short name of the topic the message was published to, when it is known
+	Topic string `json:"-"`
+	// The number of delivery attempts when dead lettering is enabled, or 0
+	DeliveryAttempt int `json:"-"`
+}
+
+// Name of the message attribute that carries correlation id
+const PubSubCorrelationIdAttribute = "correlation_id"
+
+// Gets correlation id of the message.
+// It is taken from the "correlation_id" attribute and falls back to the message id.
+// Returns correlation id string
+func (c *PubSubMessage) CorrelationId() string {
+	if c.Attributes != nil {
+		if correlationId, ok := c.Attributes[PubSubCorrelationIdAttribute]; ok && correlationId != "" {
+			return correlationId
+		}
+	}
+	return c.MessageId
+}
+
+// Gets value of the message attribute.
+// Parameters:
+//		- name	the attribute name
+// Returns the attribute value or empty string
+func (c *PubSubMessage) GetAttribute(name string) string {
+	if c.Attributes == nil {
+		return ""
+	}
+	return c.Attributes[name]
+}
+
+// Decodes the message data from JSON into the target value.
+// Parameters:
+//		- target	the target instance to which the data will be written
+// Returns error
+func (c *PubSubMessage) DecodeData(target any) error {
+	if len(c.Data) == 0 {
+		return errors.New("message data is empty")
+	}
+	return json.Unmarshal(c.Data, target)
+}
+
+// pubSubPushEnvelope is the body of Pub/Sub push requests
+// and the data of messagePublished CloudEvents.
+type pubSubPushEnvelope struct {
+	Message *struct {
+		PubSubMessage
+		// Pub/Sub duplicates some fields in snake case
+		MessageIdAlt   string `json:"message_id"`
+		PublishTimeAlt string `json:"publish_time"`
+	} `json:"message"`
+	Subscription    string `json:"subscription"`
+	DeliveryAttempt int    `json:"deliveryAttempt,omitempty"`
+}
@@ -0,0 +1,61 @@
+package utils
+
+// Types of CloudEvents emitted by Cloud Storage for object changes
+const (
+	StorageObjectFinalizedEventType       = "google.cloud.storage.object.v1.finalized"
+	StorageObjectDeletedEventType         = "google.cloud.storage.object.v1.deleted"
+	StorageObjectArchivedEventType        = "google.cloud.storage.object.v1.archived"
+	StorageObjectMetadataUpdatedEventType = "google.cloud.storage.object.v1.metadataUpdated"
+)
+
+// StorageObjectData is the object metadata carried by Cloud Storage CloudEvents.
+//
+// see CloudFunctionRequestHelper.GetStorageObjectData
+type StorageObjectData struct {
+	// The object resource kind, always "storage#object"
+	Kind string `json:"kind,omitempty"`
+	// The object id in "<bucket>/<name>/<generation>" format
+	Id string `json:"id,omitempty"`
+	// The link to the object resource
+	SelfLink string `json:"selfLink,omitempty"`
+	// The object name
+	Name string `json:"name"`
+	// The name of the bucket that contains the object
+	Bucket string `json:"bucket"`
+	// The content generation of the object
+	Generation int64 `json:"generation,string,omitempty"`
+	// The metadata generation of the object
+	Metageneration int64 `json:"metageneration,string,omitempty"`
+	// The content type of the object data
+	ContentType string `json:"contentType,omitempty"`
+	// The content encoding of the object data
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// The content disposition of the object data
+	ContentDisposition string `json:"contentDisposition,omitempty"`
+	// The cache control header of the object data
+	CacheControl string `json:"cacheControl,omitempty"`
+	// The object size in bytes
+	Size int64 `json:"size,string,omitempty"`
+	// The MD5 hash of the object data encoded in base64
+	Md5Hash string `json:"md5Hash,omitempty"`
+	// The CRC32C checksum of the object data encoded in base64
+	Crc32c string `json:"crc32c,omitempty"`
+	// The HTTP entity tag of the object
+	Etag string `json:"etag,omitempty"`
+	// The link to download the object data
+	MediaLink string `json:"mediaLink,omitempty"`
+	// The storage class of the object
+	StorageClass string `json:"storageClass,omitempty"`
+	// The creation time of the object in RFC3339 format
+	TimeCreated string `json:"timeCreated,omitempty"`
+	// The last modification time of the object metadata in RFC3339 format
+	Updated string `json:"updated,omitempty"`
+	// The deletion time of the object in RFC3339 format, when the object is not live
+	TimeDeleted string `json:"timeDeleted,omitempty"`
+	// The time when the storage class of the object was changed in RFC3339 format
+	TimeStorageClassUpdated string `json:"timeStorageClassUpdated,omitempty"`
+	// The user-provided custom time of the object in RFC3339 format
+	CustomTime string `json:"customTime,omitempty"`
+	// The user-provided metadata of the object
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+type traceContextKey struct{}
+
+// TraceContext identifies the distributed trace and span of the current request.
+// It is extracted from W3C "traceparent" or Google "X-Cloud-Trace-Context" headers.
+//
+// see CloudFunctionRequestHelper.GetTraceContext
+type TraceContext struct {
+	// The trace id as 32 lowercase hex characters
+	TraceId string
+	// The span id as 16 lowercase hex characters or empty string
+	SpanId string
+	// The id of the parent span or empty string for remote and root spans
+	ParentSpanId string
+	// The kind of the span: SpanKindServer, SpanKindClient or empty string for internal spans
+	Kind string
+	// The flag that the trace is sampled
+	Sampled bool
+
+	// The flag that the span was recorded, see MarkRecorded
+	recorded int32
+}
+
+// Kinds of spans recorded in TraceContext
+const (
+	SpanKindInternal = ""
+	SpanKindServer   = "server"
+	SpanKindClient   = "client"
+)
+
+var traceParentRegex = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+var cloudTraceContextRegex = regexp.MustCompile(`^([0-9a-fA-F]{32})(?:/([0-9]+))?(?:;o=([01]))?$`)
+
+// Parses W3C "traceparent" header value, e.g. "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"
+// Parameters:
+//		- value	the header value
+// Returns the trace context and true, or nil and false if the value is invalid.
+func ParseTraceParent(value string) (*TraceContext, bool) {
+	match := traceParentRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil || match[1] == "ff" {
+		return nil, false
+	}
+	if strings.Trim(match[2], "0") == "" || strings.Trim(match[3], "0") == "" {
+		return nil, false
+	}
+
+	flags, _ := strconv.ParseUint(match[4], 16, 8)
+	return &TraceContext{
+		TraceId: match[2],
+		SpanId:  match[3],
+		Sampled: flags&1 == 1,
+		// Remote spans are recorded by callers
+		recorded: 1,
+	}, true
+}
+
+// Parses Google "X-Cloud-Trace-Context" header value, e.g. "105445aa7843bc8bf206b12000100000/1;o=1".
+// The decimal span id is converted into hex form.
+// Parameters:
+//		- value	the header value
+// Returns the trace context and true, or nil and false if the value is invalid.
+func ParseCloudTraceContext(value string) (*TraceContext, bool) {
+	match := cloudTraceContextRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return nil, false
+	}
+
+	result := &TraceContext{
+		TraceId: strings.ToLower(match[1]),
+		Sampled: match[3] == "1",
+		// Remote spans are recorded by callers
+		recorded: 1,
+	}
+
+	if match[2] != "" {
+		spanId, err := strconv.ParseUint(match[2], 10, 64)
+		if err == nil && spanId != 0 {
+			result.SpanId = fmt.Sprintf("%016x", spanId)
+		}
+	}
+
+	return result, true
+}
+
+// Creates a new trace context with random trace and span ids.
+// Parameters:
+//		- sampled	true if the trace shall be sampled.
+// Returns a new trace context.
+func NewTraceContext(sampled bool) *TraceContext {
+	return &TraceContext{
+		TraceId: randomHex(16),
+		SpanId:  randomHex(8),
+		Sampled: sampled,
+	}
+}
+
+// Creates a child span in the same trace with a new random span id.
+// Parameters:
+//		- kind	the kind of the child span.
+// Returns a new trace context.
+func (c *TraceContext) NewChildSpan(kind string) *TraceContext {
+	return &TraceContext{
+		TraceId:      c.TraceId,
+		SpanId:       randomHex(8),
+		ParentSpanId: c.SpanId,
+		Kind:         kind,
+		Sampled:      c.Sampled,
+	}
+}
+
+// Marks the span as recorded by a tracer. Each span is recorded once, so operations
+// traced in the context after that are recorded as new child spans.
+// Remote spans parsed from headers are recorded by callers, so they are marked already.
+// Returns true if the span was not recorded before.
+func (c *TraceContext) MarkRecorded() bool {
+	return atomic.CompareAndSwapInt32(&c.recorded, 0, 1)
+}
+
+// Formats the trace context as W3C "traceparent" header value.
+// Returns the header value.
+func (c *TraceContext) TraceParent() string {
+	flags := "00"
+	if c.Sampled {
+		flags = "01"
+	}
+	spanId := c.SpanId
+	if spanId == "" {
+		spanId = "0000000000000001"
+	}
+	return "00-" + c.TraceId + "-" + spanId + "-" + flags
+}
+
+// Formats the trace context as Google "X-Cloud-Trace-Context" header value.
+// Returns the header value.
+func (c *TraceContext) CloudTraceContext() string {
+	result := c.TraceId
+	if spanId, err := strconv.ParseUint(c.SpanId, 16, 64); err == nil && spanId != 0 {
+		result += "/" + strconv.FormatUint(spanId, 10)
+	}
+	if c.Sampled {
+		result += ";o=1"
+	} else {
+		result += ";o=0"
+	}
+	return result
+}
+
+// Adds the trace context to the context.
+// Parameters:
+//		- ctx	a parent context.
+//		- trace	a trace context.
+// Returns a new context with the trace context.
+func ContextWithTraceContext(ctx context.Context, trace *TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey{}, trace)
+}
+
+// Gets the trace context from the context.
+// Parameters:
+//		- ctx	a context.
+// Returns the trace context and true, or nil and false if it is not set.
+func TraceContextFromContext(ctx context.Context) (*TraceContext, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	trace, ok := ctx.Value(traceContextKey{}).(*TraceContext)
+	return trace, ok && trace != nil
+}
+
+func randomHex(size int) string {
+	buffer := make([]byte, size)
+	for {
+		_, _ = rand.Read(buffer)
+		// All-zero ids are invalid
+		for _, b := range buffer {
+			if b != 0 {
+				return hex.EncodeToString(buffer)
+			}
+		}
+	}
+}